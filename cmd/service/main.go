@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"syscall"
 	"sysprobe/internal/config"
+	"sysprobe/internal/exporter"
 	"sysprobe/internal/monitor"
 	"sysprobe/internal/network"
 	"sysprobe/internal/service"
@@ -28,7 +29,10 @@ func main() {
 	utils.Log.Info("Config and logger initialized")
 
 	// init uuid
-	uuidInfo, _ := utils.InitUUID(cfg.Monitor.Data)
+	uuidInfo, err := utils.InitUUID(cfg.Monitor.Data)
+	if err != nil {
+		utils.Log.Error("failed to init uuid: %v", err)
+	}
 	utils.Log.Info("UUID: %s", uuidInfo.UUID)
 
 	// 建立 contex
@@ -38,11 +42,16 @@ func main() {
 	// 取得 HostInfo
 	host := service.NewHostUpdater(ctx, 15*time.Minute, uuidInfo.UUID)
 
+	// Prometheus /metrics，須在收集器之前啟動
+	if cfg.Exporter.Enable {
+		exporter.Start(ctx, cfg.Exporter)
+	}
+
 	// 載入 Monitor
 	monitor.LoadMonitor(ctx, cfg.Monitor, host)
 
 	// 載入 Network
-	network.LoadNetwork(ctx, *cfg)
+	network.LoadNetwork(ctx, *cfg, uuidInfo.UUID)
 
 	utils.Log.Info("Service initialized successfully")
 
@@ -7,9 +7,10 @@ import (
 )
 
 type Config struct {
-	Monitor MonitorConfig `yaml:"monitor"`
-	Network NetworkConfig `yaml:"network"`
-	Log     LogConfig     `yaml:"log"`
+	Monitor  MonitorConfig  `yaml:"monitor"`
+	Network  NetworkConfig  `yaml:"network"`
+	Exporter ExporterConfig `yaml:"exporter"`
+	Log      LogConfig      `yaml:"log"`
 }
 
 // ============ Monitor ===============
@@ -19,12 +20,197 @@ type MonitorModule struct {
 }
 
 type MonitorConfig struct {
-	Data   string        `yaml:"data"`
-	Days   int           `yaml:"days"`
-	CPU    MonitorModule `yaml:"cpu"`
-	Memory MonitorModule `yaml:"memory"`
-	Disk   MonitorModule `yaml:"disk"`
-	Net    MonitorModule `yaml:"net"`
+	Data      string          `yaml:"data"`
+	Days      int             `yaml:"days"`
+	CPU       MonitorModule   `yaml:"cpu"`
+	Memory    MonitorModule   `yaml:"memory"`
+	Disk      MonitorModule   `yaml:"disk"`
+	Net       MonitorModule   `yaml:"net"`
+	Package   PackageModule   `yaml:"package"`
+	Systemd   SystemdModule   `yaml:"systemd"`
+	Cgroup    CgroupModule    `yaml:"cgroup"`
+	Container ContainerModule `yaml:"container"`
+	Kmsg      KmsgModule      `yaml:"kmsg"`
+	AppLog    AppLogModule    `yaml:"applog"`
+	Auth      AuthModule      `yaml:"auth"`
+	Session   SessionModule   `yaml:"session"`
+	Integrity IntegrityModule `yaml:"integrity"`
+	Cert      CertModule      `yaml:"cert"`
+	Probe     ProbeModule     `yaml:"probe"`
+	Exec      ExecModule      `yaml:"exec"`
+	Scrape    ScrapeModule    `yaml:"scrape"`
+	Statsd    StatsdModule    `yaml:"statsd"`
+}
+
+// PackageModule 套件清單收集設定
+type PackageModule struct {
+	MonitorModule `yaml:",inline"`
+	DpkgStatus    string `yaml:"dpkg_status"` // dpkg status 檔路徑，空字串表示停用
+	RpmCommand    string `yaml:"rpm_command"` // 列出 RPM 套件的指令，空字串表示停用
+}
+
+// SystemdModule systemd 單元狀態收集設定
+type SystemdModule struct {
+	MonitorModule   `yaml:",inline"`
+	Units           []string `yaml:"units"`            // 單元名稱 glob，空陣列表示全部
+	SummaryInterval int      `yaml:"summary_interval"` // 秒
+}
+
+// CgroupModule cgroup v2 資源收集設定
+type CgroupModule struct {
+	MonitorModule `yaml:",inline"`
+	Root          string `yaml:"root"`      // cgroup v2 掛載點，預設 /sys/fs/cgroup
+	MaxDepth      int    `yaml:"max_depth"` // 走訪深度，0 表示不限制
+}
+
+// ContainerModule container runtime 資訊（名稱、映像檔、標籤）設定，interval 為重新整理清單的間隔
+type ContainerModule struct {
+	MonitorModule `yaml:",inline"`
+	Socket        string `yaml:"socket"` // Docker Engine API unix socket，預設 /var/run/docker.sock
+}
+
+// KmsgModule 核心訊息收集設定，interval 為一般檔案讀到結尾後的輪詢間隔
+type KmsgModule struct {
+	MonitorModule `yaml:",inline"`
+	Path          string `yaml:"path"`  // 預設 /dev/kmsg，測試時可指定一般檔案
+	Level         int    `yaml:"level"` // 未分類訊息只輸出優先權 <= level 的（0 emerg ~ 7 debug）
+}
+
+// AppLogModule 應用程式日誌收集設定，interval 為輪詢間隔
+type AppLogModule struct {
+	MonitorModule `yaml:",inline"`
+	Sources       []AppLogSource `yaml:"sources"`
+}
+
+// AppLogSource 一組日誌檔
+type AppLogSource struct {
+	Name          string            `yaml:"name"`
+	Paths         []string          `yaml:"paths"`          // glob
+	Labels        map[string]string `yaml:"labels"`         // 附加在每筆紀錄上
+	Multiline     string            `yaml:"multiline"`      // 新紀錄開頭的 regex，空字串表示一行一筆
+	MaxLineBytes  int               `yaml:"max_line_bytes"` // 單筆上限，超過會截斷
+	Encoding      string            `yaml:"encoding"`       // utf-8 / utf-16le / utf-16be / big5 / gbk ...
+	FromBeginning bool              `yaml:"from_beginning"` // 啟動時沒有紀錄位置的檔案從頭讀
+}
+
+// AuthModule 登入紀錄分析設定，interval 為讀取檔案的輪詢間隔
+type AuthModule struct {
+	MonitorModule `yaml:",inline"`
+	Paths         []string `yaml:"paths"`     // 預設 /var/log/auth.log、/var/log/secure
+	Journal       bool     `yaml:"journal"`   // 改由 journalctl 讀取 systemd journal
+	Window        int      `yaml:"window"`    // 秒，失敗次數統計週期
+	Threshold     int      `yaml:"threshold"` // 單一來源 IP 在 window 內失敗達此次數即視為暴力破解，0 表示停用
+}
+
+// SessionModule 登入使用者與連線紀錄（utmp / wtmp）設定
+type SessionModule struct {
+	MonitorModule `yaml:",inline"`
+	Utmp          string `yaml:"utmp"` // 預設 /var/run/utmp
+	Wtmp          string `yaml:"wtmp"` // 預設 /var/log/wtmp
+}
+
+// IntegrityModule 檔案完整性監控設定，interval 為完整重新掃描的間隔
+type IntegrityModule struct {
+	MonitorModule `yaml:",inline"`
+	Paths         []string `yaml:"paths"`   // 檔案或目錄（遞迴），可使用 glob
+	Inotify       bool     `yaml:"inotify"` // 檔案異動時立即檢查，不必等下一次掃描
+}
+
+// CertModule TLS 憑證到期檢查設定
+type CertModule struct {
+	MonitorModule `yaml:",inline"`
+	Paths         []string `yaml:"paths"`         // 憑證檔或目錄（遞迴），可使用 glob，支援 PEM / DER
+	Endpoints     []string `yaml:"endpoints"`     // host:port
+	WarnDays      int      `yaml:"warn_days"`     // 剩餘天數低於此值輸出 CERT_WARNING
+	CriticalDays  int      `yaml:"critical_days"` // 剩餘天數低於此值輸出 CERT_CRITICAL
+	Timeout       int      `yaml:"timeout"`       // 秒，端點連線逾時
+}
+
+// ProbeModule 主動探測設定，interval / timeout 為各探測未指定時的預設值
+type ProbeModule struct {
+	MonitorModule `yaml:",inline"`
+	Timeout       int         `yaml:"timeout"` // 秒
+	HTTP          []HTTPProbe `yaml:"http"`
+	TCP           []TCPProbe  `yaml:"tcp"`
+	DNS           []DNSProbe  `yaml:"dns"`
+}
+
+type HTTPProbe struct {
+	Name               string            `yaml:"name"`
+	URL                string            `yaml:"url"`
+	Method             string            `yaml:"method"` // 預設 GET
+	Headers            map[string]string `yaml:"headers"`
+	ExpectStatus       []int             `yaml:"expect_status"` // 空陣列表示 2xx
+	BodyRegex          string            `yaml:"body_regex"`    // 回應內容需符合的 regex
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"`
+	Interval           int               `yaml:"interval"` // 秒
+	Timeout            int               `yaml:"timeout"`  // 秒
+}
+
+type TCPProbe struct {
+	Name     string `yaml:"name"`
+	Address  string `yaml:"address"`  // host:port
+	Interval int    `yaml:"interval"` // 秒
+	Timeout  int    `yaml:"timeout"`  // 秒
+}
+
+type DNSProbe struct {
+	Name     string   `yaml:"name"`
+	Query    string   `yaml:"query"`    // 查詢的名稱
+	Type     string   `yaml:"type"`     // A / AAAA / CNAME / MX / TXT / NS，預設 A
+	Resolver string   `yaml:"resolver"` // host:port，空字串表示使用系統設定
+	Expect   []string `yaml:"expect"`   // 需出現在結果中的值，空陣列表示只要有結果
+	Interval int      `yaml:"interval"` // 秒
+	Timeout  int      `yaml:"timeout"`  // 秒
+}
+
+// ExecModule 執行外部指令收集自訂指標，interval / timeout 為各指令未指定時的預設值
+type ExecModule struct {
+	MonitorModule `yaml:",inline"`
+	Timeout       int           `yaml:"timeout"`     // 秒
+	Concurrency   int           `yaml:"concurrency"` // 同時執行的指令上限
+	Commands      []ExecCommand `yaml:"commands"`
+}
+
+type ExecCommand struct {
+	Name     string            `yaml:"name"`
+	Command  string            `yaml:"command"` // 執行檔路徑，不經過 shell
+	Args     []string          `yaml:"args"`
+	Env      map[string]string `yaml:"env"`      // 附加在目前環境變數之後
+	Dir      string            `yaml:"dir"`      // 工作目錄
+	Format   string            `yaml:"format"`   // json / jsonl / nagios / prometheus
+	Labels   map[string]string `yaml:"labels"`   // 附加在每筆紀錄上
+	Interval int               `yaml:"interval"` // 秒
+	Timeout  int               `yaml:"timeout"`  // 秒，逾時會終止整個 process group
+}
+
+// ScrapeModule 抓取 Prometheus / OpenMetrics 端點，interval / timeout 為各目標未指定時的預設值
+type ScrapeModule struct {
+	MonitorModule `yaml:",inline"`
+	Timeout       int            `yaml:"timeout"` // 秒
+	Targets       []ScrapeTarget `yaml:"targets"`
+}
+
+type ScrapeTarget struct {
+	Name     string            `yaml:"name"`
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	Labels   map[string]string `yaml:"labels"`   // 附加在每個樣本上，與樣本標籤衝突時以樣本為準
+	Allow    []string          `yaml:"allow"`    // metric 名稱 glob，空陣列表示全部
+	Deny     []string          `yaml:"deny"`     // metric 名稱 glob，優先於 allow
+	Interval int               `yaml:"interval"` // 秒
+	Timeout  int               `yaml:"timeout"`  // 秒
+}
+
+// StatsdModule StatsD / DogStatsD 接收設定，interval 為彙總輸出的間隔
+type StatsdModule struct {
+	MonitorModule `yaml:",inline"`
+	UDP           string    `yaml:"udp"`          // 監聽位址，例如 127.0.0.1:8125，空字串表示停用
+	Unix          string    `yaml:"unix"`         // unix datagram socket 路徑，空字串表示停用
+	MaxKeys       int       `yaml:"max_keys"`     // 每個週期不同 metric（名稱 + 類型 + tags）的上限，超過的丟棄
+	MaxSamples    int       `yaml:"max_samples"`  // 每個 timer / histogram 保留的樣本數，set 的不重複值上限
+	GaugeExpiry   int       `yaml:"gauge_expiry"` // gauge 連續幾個週期沒有更新就不再保留
+	Percentiles   []float64 `yaml:"percentiles"`  // 例如 [50, 90, 95, 99]
 }
 
 // ============= Log ================
@@ -38,10 +224,115 @@ type LogConfig struct {
 
 // ============= Network ================
 type NetworkConfig struct {
-	IgnoreOlder int      `yaml:"ignore_older"`
-	Host        string   `yaml:"host"`
-	Data        string   `yaml:"data"`
-	Category    []string `yaml:"category"`
+	IgnoreOlder int               `yaml:"ignore_older"`
+	Host        string            `yaml:"host"`
+	Endpoints   EndpointsConfig   `yaml:"endpoints"`
+	Data        string            `yaml:"data"`
+	Category    []string          `yaml:"category"`
+	Batch       BatchConfig       `yaml:"batch"`
+	Compression string            `yaml:"compression"` // none / gzip / zstd
+	TLS         TLSConfig         `yaml:"tls"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Labels      map[string]string `yaml:"labels"` // 附加在每筆事件上，例如 env: prod
+	Typed       bool              `yaml:"typed"`  // CPU / MEMORY / DISK / NETWORK 額外附上 protobuf 格式的樣本
+	Backoff     BackoffConfig     `yaml:"backoff"`
+	Sinks       []SinkConfig      `yaml:"sinks"`     // 沒有設定時只送到上面的 gRPC collector
+	Keepalive   int               `yaml:"keepalive"` // 秒，送出 ping 的間隔，0 為關閉；collector 需允許此頻率
+}
+
+// EndpointsConfig 多個 collector 與選擇方式，host 會排在 hosts 之前
+//   - failover：依 hosts 的順序（SRV 依 priority），優先的恢復後切回
+//   - round_robin：每條串流輪流使用
+//   - hash：依 agent UUID 固定使用同一台，該台離線時才換
+type EndpointsConfig struct {
+	Hosts         []string `yaml:"hosts"`          // host:port，主機名稱會解析所有 A / AAAA 記錄
+	SRV           string   `yaml:"srv"`            // DNS SRV 名稱，例如 _sysprobe._tcp.example.com
+	Strategy      string   `yaml:"strategy"`       // failover / round_robin / hash
+	Resolve       int      `yaml:"resolve"`        // 秒，重新解析 DNS 的間隔
+	HealthCheck   bool     `yaml:"health_check"`   // 使用 gRPC health protocol，NOT_SERVING 的 collector 不使用
+	HealthService string   `yaml:"health_service"` // health check 的 service 名稱，空字串為整台 server
+}
+
+// SinkConfig 輸出目的地，每個 sink 各自讀檔、重試並記錄 offset，互不影響
+// grpc 使用 network 的連線設定（host / endpoints / tls / auth / compression），只能有一個；
+// batch 各 sink 以 sinks[].batch 為準，grpc 沒有設定時沿用 network.batch
+type SinkConfig struct {
+	Name     string        `yaml:"name"`     // offset 檔以此區分，不可重複
+	Type     string        `yaml:"type"`     // grpc / http / syslog / file / stdout
+	Category []string      `yaml:"category"` // 空白時使用 network.category
+	Buffer   int           `yaml:"buffer"`   // 尚未確認的事件上限，達到時暫停讀檔
+	Batch    BatchConfig   `yaml:"batch"`    // 每次寫入的筆數，grpc 未設定時沿用 network.batch
+	Retry    BackoffConfig `yaml:"retry"`    // 寫入失敗後重試的退避，預設同 network.backoff
+	Path     string        `yaml:"path"`     // file：可使用 {category}、{date}
+
+	// http
+	URL        string            `yaml:"url"`
+	Format     string            `yaml:"format"`   // http：ndjson / json（陣列）；syslog：rfc5424 / rfc3164
+	Gzip       bool              `yaml:"gzip"`     // 壓縮 request body
+	Headers    map[string]string `yaml:"headers"`  // 額外的 header
+	Username   string            `yaml:"username"` // basic auth
+	Password   string            `yaml:"password"`
+	TokenFile  string            `yaml:"token_file"`  // bearer token，檔案更新時重新讀取
+	TokenEnv   string            `yaml:"token_env"`   // bearer token 的環境變數名稱
+	Timeout    int               `yaml:"timeout"`     // 秒，每個 request（syslog 為每次寫入）
+	DeadLetter string            `yaml:"dead_letter"` // 內容被拒絕（400 / 413 / 422）的批次寫到此檔
+
+	// syslog
+	Address    string            `yaml:"address"`    // host:port
+	Protocol   string            `yaml:"protocol"`   // udp / tcp / tls，tcp 與 tls 使用 octet counting
+	AppName    string            `yaml:"app_name"`   // 預設 sysprobe
+	Facility   string            `yaml:"facility"`   // 沒有對應的 category 使用，預設 local0
+	Facilities map[string]string `yaml:"facilities"` // category → facility，例如 auth: authpriv
+	Severities map[string]string `yaml:"severities"` // category 或事件（例如 AUTH_BRUTE_FORCE）→ severity，未設定時依事件決定
+	TLS        TLSConfig         `yaml:"tls"`        // protocol 為 tls 時使用
+}
+
+// BackoffConfig 重新連線的指數退避，每次等待時間另加隨機抖動
+type BackoffConfig struct {
+	Initial int `yaml:"initial"` // 秒
+	Max     int `yaml:"max"`     // 秒
+}
+
+// AuthConfig 每個 RPC 附帶的身分驗證
+//   - token：固定的 bearer token，從 token_env 或 token_file 讀取一次
+//   - token_file：每次建立串流前檢查 token_file，由其他程式輪替
+//   - hmac：以 agent UUID 專屬的 secret 簽章
+type AuthConfig struct {
+	Type       string `yaml:"type"` // none / token / token_file / hmac
+	TokenFile  string `yaml:"token_file"`
+	TokenEnv   string `yaml:"token_env"`   // 環境變數名稱，優先於 token_file
+	SecretFile string `yaml:"secret_file"` // hmac secret
+	SecretEnv  string `yaml:"secret_env"`
+}
+
+// TLSConfig 連線 collector 的 TLS 設定，預設以系統 CA 驗證；明文需設定 insecure
+type TLSConfig struct {
+	Insecure   bool   `yaml:"insecure"`    // 不加密，只用於測試或本機
+	CA         string `yaml:"ca"`          // CA bundle（PEM），空字串使用系統 CA
+	Cert       string `yaml:"cert"`        // client 憑證（mTLS）
+	Key        string `yaml:"key"`         // client 私鑰（mTLS）
+	ServerName string `yaml:"server_name"` // 驗證用的主機名稱，預設取 host
+	MinVersion string `yaml:"min_version"` // 1.2 / 1.3，預設 1.2
+	Reload     int    `yaml:"reload"`      // 秒，檢查憑證檔是否更新的間隔
+}
+
+// BatchConfig 批次傳送設定，任一條件達到就送出
+type BatchConfig struct {
+	Enable    bool `yaml:"enable"`     // 關閉時每行一筆 LogEvent
+	MaxEvents int  `yaml:"max_events"` // 每批最多幾筆
+	MaxBytes  int  `yaml:"max_bytes"`  // 每批 payload 總大小上限
+	Linger    int  `yaml:"linger"`     // 毫秒，第一筆進來後最多等待多久
+}
+
+// ============= Exporter ================
+// ExporterConfig 以 Prometheus 格式提供各收集器最新的樣本
+type ExporterConfig struct {
+	Enable   bool   `yaml:"enable"`
+	Listen   string `yaml:"listen"`   // 預設 127.0.0.1:9110，對外開放需明確設定，例如 0.0.0.0:9110
+	Path     string `yaml:"path"`     // 預設 /metrics
+	Username string `yaml:"username"` // basic auth，空字串表示不驗證
+	Password string `yaml:"password"`
+	Stale    int    `yaml:"stale"` // 秒，超過此時間沒有更新的樣本不再輸出
 }
 
 func Load(path string) (*Config, error) {
@@ -0,0 +1,305 @@
+package exporter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sysprobe/internal/monitor/certs"
+	"sysprobe/internal/monitor/cgroup"
+	"sysprobe/internal/monitor/cpu"
+	"sysprobe/internal/monitor/disk"
+	"sysprobe/internal/monitor/exec"
+	"sysprobe/internal/monitor/memory"
+	"sysprobe/internal/monitor/network"
+	"sysprobe/internal/monitor/packages"
+	"sysprobe/internal/monitor/probe"
+	"sysprobe/internal/monitor/scrape"
+	"sysprobe/internal/monitor/session"
+	"sysprobe/internal/monitor/statsd"
+	"sysprobe/internal/monitor/systemd"
+	"sysprobe/internal/service"
+	"time"
+)
+
+// 1 GiB，disk 收集器以 GiB 為單位（取整數）
+const gib = 1 << 30
+
+// update 依 category 轉換一筆紀錄，未列出的 category 與事件不輸出
+func (r *registry) update(category string, line []byte) {
+	switch category {
+	case cpu.Category:
+		var v cpu.CPUInfo
+		if json.Unmarshal(line, &v) == nil {
+			r.updateCPU(v)
+		}
+	case memory.Category:
+		var v memory.MemoryInfo
+		if json.Unmarshal(line, &v) == nil {
+			r.updateMemory(v)
+		}
+	case disk.Category:
+		var v disk.DiskInfoJSON
+		if json.Unmarshal(line, &v) == nil {
+			r.updateDisk(v)
+		}
+	case network.Category:
+		var v network.NetworkJSON
+		if json.Unmarshal(line, &v) == nil {
+			r.updateNetwork(v)
+		}
+	case cgroup.Category:
+		var v cgroup.CgroupJSON
+		if json.Unmarshal(line, &v) == nil {
+			r.updateCgroup(v)
+		}
+	case certs.Category:
+		r.updateCert(line)
+	case probe.Category:
+		r.updateProbe(line)
+	case exec.Category:
+		var v exec.ExecJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == exec.EventResult {
+			r.updateExec(v)
+		}
+	case scrape.Category:
+		// 抓取到的樣本本身不轉出，名稱與標籤由目標決定
+		var v scrape.StatusJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == scrape.EventStatus {
+			r.updateScrape(v)
+		}
+	case statsd.Category:
+		var v statsd.StatsJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == statsd.EventStats {
+			r.updateStatsd(v)
+		}
+	case systemd.Category:
+		var v systemd.SummaryJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == systemd.EventSummary {
+			r.updateSystemd(v)
+		}
+	case session.Category:
+		var v session.ActiveJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == session.EventActive {
+			r.set("sysprobe_session_active", typeGauge, "Number of logged in sessions.", hostLabels(v.Host), float64(v.Count))
+		}
+	case packages.Category:
+		var v packages.InventoryJSON
+		if json.Unmarshal(line, &v) == nil && v.Event == packages.EventInventory {
+			r.set("sysprobe_packages_installed", typeGauge, "Number of installed packages.", hostLabels(v.Host), float64(v.Count))
+		}
+	}
+}
+
+func hostLabels(h service.HostInfo) map[string]string {
+	return map[string]string{"uuid": h.UUID, "hostname": h.Hostname}
+}
+
+// with 複製標籤並加上額外的 key / value
+func with(base map[string]string, kv ...string) map[string]string {
+	out := make(map[string]string, len(base)+len(kv)/2)
+	for k, v := range base {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
+
+// ------------------------- 基本收集器 -------------------------
+func (r *registry) updateCPU(v cpu.CPUInfo) {
+	l := hostLabels(v.Host)
+	r.set("sysprobe_cpu_usage_percent", typeGauge, "Total CPU usage in percent.", l, v.CpuUsage)
+	r.set("sysprobe_cpu_cores", typeGauge, "Number of logical CPU cores.", l, float64(v.CoreCount))
+	r.set("sysprobe_cpu_frequency_mhz", typeGauge, "CPU frequency in MHz.", with(l, "model", v.CpuModel), v.CpuMHz)
+	for i, u := range v.CoreUsage {
+		r.set("sysprobe_cpu_core_usage_percent", typeGauge, "Per-core CPU usage in percent.", with(l, "core", strconv.Itoa(i)), u)
+	}
+	if load, ok := v.LoadAverage.([]any); ok && len(load) == 3 {
+		for i, name := range []string{"sysprobe_load1", "sysprobe_load5", "sysprobe_load15"} {
+			if f, ok := load[i].(float64); ok {
+				r.set(name, typeGauge, "System load average.", l, f)
+			}
+		}
+	}
+	for mode, sec := range map[string]float64{
+		"user":   v.CpuTime.User,
+		"system": v.CpuTime.System,
+		"idle":   v.CpuTime.Idle,
+		"nice":   v.CpuTime.Nice,
+		"iowait": v.CpuTime.IOWait,
+		"irq":    v.CpuTime.IRQ,
+	} {
+		r.set("sysprobe_cpu_seconds_total", typeCounter, "Seconds the CPUs spent in each mode.", with(l, "mode", mode), sec)
+	}
+}
+
+func (r *registry) updateMemory(v memory.MemoryInfo) {
+	l := hostLabels(v.Host)
+	r.set("sysprobe_memory_total_bytes", typeGauge, "Total memory in bytes.", l, float64(v.Total))
+	r.set("sysprobe_memory_used_bytes", typeGauge, "Used memory in bytes.", l, float64(v.Used))
+	r.set("sysprobe_memory_free_bytes", typeGauge, "Free memory in bytes.", l, float64(v.Free))
+	r.set("sysprobe_memory_used_percent", typeGauge, "Used memory in percent.", l, v.UsedPct)
+}
+
+func (r *registry) updateDisk(v disk.DiskInfoJSON) {
+	host := hostLabels(v.Host)
+	for _, p := range v.Partitions {
+		l := with(host, "device", p.Name, "mountpoint", p.Mount, "fstype", p.Fs)
+		r.set("sysprobe_disk_size_bytes", typeGauge, "Partition size in bytes (GiB precision).", l, float64(p.Total*gib))
+		r.set("sysprobe_disk_used_bytes", typeGauge, "Partition used space in bytes (GiB precision).", l, float64(p.Used*gib))
+		r.set("sysprobe_disk_free_bytes", typeGauge, "Partition free space in bytes (GiB precision).", l, float64(p.Free*gib))
+		r.set("sysprobe_disk_used_percent", typeGauge, "Partition used space in percent.", l, p.Usage)
+		r.set("sysprobe_disk_read_bytes_per_second", typeGauge, "Disk read throughput.", l, float64(p.ReadRate))
+		r.set("sysprobe_disk_write_bytes_per_second", typeGauge, "Disk write throughput.", l, float64(p.WriteRate))
+		r.set("sysprobe_disk_busy_percent", typeGauge, "Time the disk was busy in percent.", l, p.Busy)
+	}
+}
+
+func (r *registry) updateNetwork(v network.NetworkJSON) {
+	host := hostLabels(v.Host)
+	for _, iface := range v.Interfaces {
+		l := with(host, "interface", iface.Name)
+		r.set("sysprobe_network_transmit_bytes_per_second", typeGauge, "Interface transmit throughput.", l, float64(iface.Tx))
+		r.set("sysprobe_network_receive_bytes_per_second", typeGauge, "Interface receive throughput.", l, float64(iface.Rx))
+		r.set("sysprobe_network_transmit_packets_per_second", typeGauge, "Interface transmit packet rate.", l, iface.TxPPS)
+		r.set("sysprobe_network_receive_packets_per_second", typeGauge, "Interface receive packet rate.", l, iface.RxPPS)
+		for state, n := range iface.TCP {
+			r.set("sysprobe_network_tcp_connections", typeGauge, "TCP connections by state.", with(l, "state", state), float64(n))
+		}
+	}
+}
+
+// ------------------------- 其他收集器 -------------------------
+// 只輸出明確列出的數值欄位，標籤固定，避免使用者名稱、IP、序號等欄位造成無上限的 series；
+// 只有事件的收集器（APPLOG / AUTH / KMSG / INTEGRITY / CONTAINER）不輸出
+
+func (r *registry) updateCgroup(v cgroup.CgroupJSON) {
+	host := hostLabels(v.Host)
+	for _, c := range v.Cgroups {
+		l := with(host, "path", c.Path, "container_id", c.ContainerID, "container_name", c.ContainerName)
+		r.set("sysprobe_cgroup_cpu_usage_percent", typeGauge, "Cgroup CPU usage in percent (100 = one core).", l, c.CPUUsage)
+		r.set("sysprobe_cgroup_cpu_seconds_total", typeCounter, "Cgroup CPU time in seconds.", l, float64(c.CPUUsageUsec)/1e6)
+		r.set("sysprobe_cgroup_cpu_throttled_periods_total", typeCounter, "Cgroup periods throttled by the CPU quota.", l, float64(c.NrThrottled))
+		r.set("sysprobe_cgroup_cpu_throttled_seconds_total", typeCounter, "Cgroup time throttled by the CPU quota in seconds.", l, float64(c.ThrottledUsec)/1e6)
+		r.set("sysprobe_cgroup_memory_current_bytes", typeGauge, "Cgroup memory usage in bytes.", l, float64(c.MemoryCurrent))
+		r.set("sysprobe_cgroup_memory_max_bytes", typeGauge, "Cgroup memory limit in bytes (0 = unlimited).", l, float64(c.MemoryMax))
+		r.set("sysprobe_cgroup_oom_kills_total", typeCounter, "Processes in the cgroup killed by the OOM killer.", l, float64(c.OOMKill))
+		r.set("sysprobe_cgroup_io_read_bytes_per_second", typeGauge, "Cgroup read throughput.", l, float64(c.IOReadRate))
+		r.set("sysprobe_cgroup_io_write_bytes_per_second", typeGauge, "Cgroup write throughput.", l, float64(c.IOWriteRate))
+		r.set("sysprobe_cgroup_pids", typeGauge, "Number of processes in the cgroup.", l, float64(c.PidsCurrent))
+	}
+}
+
+func (r *registry) updateCert(line []byte) {
+	var v certs.CertJSON
+	if json.Unmarshal(line, &v) != nil {
+		return
+	}
+	l := with(hostLabels(v.Host), "source", v.Source, "target", v.Target)
+	if v.Event == certs.EventError {
+		r.set("sysprobe_cert_check_success", typeGauge, "Whether the certificate could be read.", l, 0)
+		return
+	}
+	if v.Position == 0 {
+		r.set("sysprobe_cert_check_success", typeGauge, "Whether the certificate could be read.", l, 1)
+	}
+	l = with(l, "position", strconv.Itoa(v.Position))
+	r.set("sysprobe_cert_days_remaining", typeGauge, "Days until the certificate expires (negative once expired).", l, float64(v.DaysRemaining))
+	if t, err := time.Parse(time.RFC3339, v.NotAfter); err == nil {
+		r.set("sysprobe_cert_not_after_seconds", typeGauge, "Certificate expiry as a Unix timestamp.", l, float64(t.Unix()))
+	}
+}
+
+func (r *registry) updateProbe(line []byte) {
+	var head struct {
+		Host service.HostInfo `json:"Host"`
+		Type string           `json:"Type"`
+		Name string           `json:"Name"`
+	}
+	if json.Unmarshal(line, &head) != nil {
+		return
+	}
+	l := with(hostLabels(head.Host), "type", head.Type, "name", head.Name)
+
+	var success bool
+	var duration float64 // 毫秒
+	switch head.Type {
+	case probe.TypeHTTP:
+		var v probe.HTTPResultJSON
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		success, duration = v.Success, v.Timing.Total
+		r.set("sysprobe_probe_http_status_code", typeGauge, "HTTP status code of the probe response.", l, float64(v.StatusCode))
+		for phase, ms := range map[string]float64{
+			"dns":     v.Timing.DNS,
+			"connect": v.Timing.Connect,
+			"tls":     v.Timing.TLS,
+			"ttfb":    v.Timing.TTFB,
+		} {
+			r.set("sysprobe_probe_http_phase_seconds", typeGauge, "Duration of each HTTP probe phase.", with(l, "phase", phase), ms/1000)
+		}
+	case probe.TypeTCP:
+		var v probe.TCPResultJSON
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		success, duration = v.Success, v.Duration
+	case probe.TypeDNS:
+		var v probe.DNSResultJSON
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		success, duration = v.Success, v.Duration
+	default:
+		return
+	}
+	r.set("sysprobe_probe_success", typeGauge, "Whether the probe succeeded.", l, boolValue(success))
+	r.set("sysprobe_probe_duration_seconds", typeGauge, "Total probe duration.", l, duration/1000)
+}
+
+func (r *registry) updateExec(v exec.ExecJSON) {
+	l := with(hostLabels(v.Host), "name", v.Name)
+	r.set("sysprobe_exec_success", typeGauge, "Whether the command succeeded.", l, boolValue(v.Success))
+	r.set("sysprobe_exec_exit_code", typeGauge, "Exit code of the command (-1 = timeout or not started).", l, float64(v.ExitCode))
+	r.set("sysprobe_exec_duration_seconds", typeGauge, "Command run time.", l, v.Duration/1000)
+}
+
+func (r *registry) updateScrape(v scrape.StatusJSON) {
+	l := with(hostLabels(v.Host), "job", v.Job, "target", v.Target)
+	r.set("sysprobe_scrape_up", typeGauge, "Whether the last scrape succeeded.", l, boolValue(v.Up))
+	r.set("sysprobe_scrape_duration_seconds", typeGauge, "Duration of the last scrape.", l, v.Duration/1000)
+	r.set("sysprobe_scrape_samples", typeGauge, "Samples parsed in the last scrape.", l, float64(v.Samples))
+	r.set("sysprobe_scrape_samples_kept", typeGauge, "Samples kept after allow / deny in the last scrape.", l, float64(v.Kept))
+}
+
+func (r *registry) updateStatsd(v statsd.StatsJSON) {
+	l := hostLabels(v.Host)
+	r.set("sysprobe_statsd_packets", typeGauge, "Packets received in the last interval.", l, float64(v.Packets))
+	r.set("sysprobe_statsd_metrics", typeGauge, "Metrics flushed in the last interval.", l, float64(v.Metrics))
+	r.set("sysprobe_statsd_dropped_values", typeGauge, "Values dropped by max_keys in the last interval.", l, float64(v.DroppedKeys))
+	r.set("sysprobe_statsd_malformed_lines", typeGauge, "Malformed lines in the last interval.", l, float64(v.Malformed))
+}
+
+func (r *registry) updateSystemd(v systemd.SummaryJSON) {
+	host := hostLabels(v.Host)
+	r.set("sysprobe_systemd_units", typeGauge, "Number of monitored units.", host, float64(v.Total))
+	r.set("sysprobe_systemd_units_active", typeGauge, "Number of active units.", host, float64(v.Active))
+	r.set("sysprobe_systemd_units_failed", typeGauge, "Number of failed units.", host, float64(v.Failed))
+	for _, u := range v.Units {
+		l := with(host, "unit", u.Name)
+		r.set("sysprobe_systemd_unit_active", typeGauge, "Whether the unit is active.", l, boolValue(u.ActiveState == "active"))
+		r.set("sysprobe_systemd_unit_failed", typeGauge, "Whether the unit has failed.", l, boolValue(u.ActiveState == "failed"))
+		if strings.HasSuffix(u.Name, ".service") {
+			r.set("sysprobe_systemd_unit_restarts_total", typeCounter, "Times systemd restarted the service.", l, float64(u.NRestarts))
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
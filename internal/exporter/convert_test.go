@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func render(r *registry) string {
+	var b strings.Builder
+	r.write(&b)
+	return b.String()
+}
+
+func TestUpdateAllowlist(t *testing.T) {
+	r := newRegistry(time.Minute)
+	host := `"Host":{"UUID":"u1","Hostname":"web1"}`
+
+	// 只有事件的收集器與逐筆樣本不輸出
+	for category, line := range map[string]string{
+		"AUTH":      `{` + host + `,"Category":"AUTH","Event":"SSH_LOGIN_FAILED","User":"root","SourceIP":"203.0.113.9","Port":51022}`,
+		"KMSG":      `{` + host + `,"Category":"KMSG","Event":"OOM_KILL","Seq":1042,"PID":1234,"Level":3}`,
+		"APPLOG":    `{` + host + `,"Category":"APPLOG","Path":"/var/log/app.log","Offset":90210}`,
+		"CONTAINER": `{` + host + `,"Category":"CONTAINER","Event":"CONTAINER_DIE","ContainerID":"abc","ExitCode":137}`,
+		"SCRAPE":    `{` + host + `,"Category":"SCRAPE","Event":"METRIC","Job":"node","Name":"http_requests_total","Labels":{"path":"/a"},"Value":5}`,
+		"STATSD":    `{` + host + `,"Category":"STATSD","Event":"STATSD_METRIC","Name":"api.latency","Tags":{"user":"x"},"Value":12}`,
+		"UNKNOWN":   `{` + host + `,"Value":1}`,
+	} {
+		r.update(category, []byte(line))
+	}
+	if n := len(r.series); n != 0 {
+		t.Fatalf("event records produced %d series:\n%s", n, render(r))
+	}
+
+	r.update("SCRAPE", []byte(`{`+host+`,"Category":"SCRAPE","Event":"SCRAPE_STATUS","Job":"node","Target":"http://127.0.0.1:9100/metrics","Up":true,"Duration":250,"Samples":812,"Kept":40}`))
+	r.update("PROBE", []byte(`{`+host+`,"Category":"PROBE","Event":"PROBE_RESULT","Type":"tcp","Name":"db","Target":"10.0.0.5:5432","Success":false,"Error":"connection refused","RemoteAddr":"10.0.0.5:5432","Duration":3}`))
+	r.update("CGROUP", []byte(`{`+host+`,"Category":"CGROUP","Cgroups":[{"Path":"/system.slice/nginx.service","Unit":"nginx.service","Slice":"system.slice","Labels":{"a":"b"},"CPUUsage":25,"CPUUsageUsec":4000000,"MemoryCurrent":1048576,"PidsCurrent":3}]}`))
+
+	out := render(r)
+	for _, want := range []string{
+		`sysprobe_scrape_up{hostname="web1",job="node",target="http://127.0.0.1:9100/metrics",uuid="u1"} 1`,
+		`sysprobe_scrape_duration_seconds{hostname="web1",job="node",target="http://127.0.0.1:9100/metrics",uuid="u1"} 0.25`,
+		`sysprobe_probe_success{hostname="web1",name="db",type="tcp",uuid="u1"} 0`,
+		`sysprobe_probe_duration_seconds{hostname="web1",name="db",type="tcp",uuid="u1"} 0.003`,
+		`sysprobe_cgroup_cpu_seconds_total{container_id="",container_name="",hostname="web1",path="/system.slice/nginx.service",uuid="u1"} 4`,
+		`sysprobe_cgroup_pids{container_id="",container_name="",hostname="web1",path="/system.slice/nginx.service",uuid="u1"} 3`,
+		"# TYPE sysprobe_cgroup_cpu_seconds_total counter",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s\n%s", want, out)
+		}
+	}
+	for _, bad := range []string{"remote_addr", "error=", "slice=", "unit=", `a="b"`} {
+		if strings.Contains(out, bad) {
+			t.Errorf("unexpected label %s\n%s", bad, out)
+		}
+	}
+}
+
+func TestUpdateCert(t *testing.T) {
+	r := newRegistry(time.Minute)
+	host := `"Host":{"UUID":"u1","Hostname":"web1"}`
+	r.update("CERT", []byte(`{`+host+`,"Category":"CERT","Event":"CERT_WARNING","Source":"endpoint","Target":"example.com:443","Position":0,"Subject":"CN=example.com","Serial":"1f","Fingerprint":"ab","NotAfter":"2026-11-08T00:00:00Z","DaysRemaining":19}`))
+	r.update("CERT", []byte(`{`+host+`,"Category":"CERT","Event":"CERT_ERROR","Source":"endpoint","Target":"down.example.com:443","Error":"timeout"}`))
+
+	out := render(r)
+	for _, want := range []string{
+		`sysprobe_cert_days_remaining{hostname="web1",position="0",source="endpoint",target="example.com:443",uuid="u1"} 19`,
+		`sysprobe_cert_not_after_seconds{hostname="web1",position="0",source="endpoint",target="example.com:443",uuid="u1"} 1.794096e+09`,
+		`sysprobe_cert_check_success{hostname="web1",source="endpoint",target="example.com:443",uuid="u1"} 1`,
+		`sysprobe_cert_check_success{hostname="web1",source="endpoint",target="down.example.com:443",uuid="u1"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "fingerprint") || strings.Contains(out, "subject") {
+		t.Errorf("unexpected label\n%s", out)
+	}
+}
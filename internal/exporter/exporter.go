@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sysprobe/internal/config"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const (
+	defaultListen = "127.0.0.1:9110" // 未設定時只在本機開放
+	defaultPath   = "/metrics"
+	defaultStale  = 300 // 秒
+)
+
+// Start 註冊寫入 hook 並啟動 HTTP server，需在收集器啟動前呼叫才不會漏掉第一筆樣本
+func Start(ctx context.Context, cfg config.ExporterConfig) {
+	if cfg.Listen == "" {
+		cfg.Listen = defaultListen
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultPath
+	}
+	if cfg.Stale <= 0 {
+		cfg.Stale = defaultStale
+	}
+
+	reg := newRegistry(time.Duration(cfg.Stale) * time.Second)
+	utils.AddWriteHook(reg.update)
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, basicAuth(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		reg.write(w)
+	})))
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		utils.Log.Info("[Exporter] listening on %s%s", cfg.Listen, cfg.Path)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.Log.Error("[Exporter] server fail: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+		utils.Log.Info("[Exporter] 已停止")
+	}()
+}
+
+// basicAuth 沒有設定帳號時不驗證
+func basicAuth(cfg config.ExporterConfig, next http.Handler) http.Handler {
+	if cfg.Username == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(cfg.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(cfg.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="sysprobe"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
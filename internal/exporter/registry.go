@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metric 類型
+const (
+	typeGauge   = "gauge"
+	typeCounter = "counter"
+)
+
+// 保留的 series 上限，避免高基數的標籤耗盡記憶體
+const maxSeries = 100000
+
+// series 單一 metric 名稱 + 標籤組合的最新值
+type series struct {
+	name    string
+	labels  map[string]string
+	value   float64
+	updated time.Time
+}
+
+type family struct {
+	typ  string
+	help string
+}
+
+// registry 保存每個 series 的最新值，由寫入 hook 更新、HTTP handler 讀取
+type registry struct {
+	mu       sync.Mutex
+	stale    time.Duration
+	series   map[string]*series
+	families map[string]family
+	dropped  int
+}
+
+func newRegistry(stale time.Duration) *registry {
+	return &registry{
+		stale:    stale,
+		series:   make(map[string]*series),
+		families: make(map[string]family),
+	}
+}
+
+// set 更新 series，同一個名稱第一次出現時決定類型與說明
+func (r *registry) set(name, typ, help string, labels map[string]string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	key := seriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[name]; !ok {
+		r.families[name] = family{typ: typ, help: help}
+	}
+	s, ok := r.series[key]
+	if !ok {
+		if len(r.series) >= maxSeries {
+			r.dropped++
+			return
+		}
+		s = &series{name: name, labels: labels}
+		r.series[key] = s
+	}
+	s.value = value
+	s.updated = time.Now()
+}
+
+// write 以 Prometheus text exposition 格式輸出，過期的 series 順便移除
+func (r *registry) write(w io.Writer) {
+	r.mu.Lock()
+	now := time.Now()
+	byName := make(map[string][]*series)
+	for key, s := range r.series {
+		if r.stale > 0 && now.Sub(s.updated) > r.stale {
+			delete(r.series, key)
+			continue
+		}
+		byName[s.name] = append(byName[s.name], s)
+	}
+	families := make(map[string]family, len(byName))
+	for name := range byName {
+		families[name] = r.families[name]
+	}
+	dropped := r.dropped
+	r.mu.Unlock()
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := families[name]
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, f.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.typ)
+
+		list := byName[name]
+		lines := make([]string, len(list))
+		for i, s := range list {
+			lines[i] = name + formatLabels(s.labels) + " " + strconv.FormatFloat(s.value, 'g', -1, 64)
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+
+	b.WriteString("# HELP sysprobe_exporter_dropped_series_total Series dropped because the series limit was reached.\n")
+	b.WriteString("# TYPE sysprobe_exporter_dropped_series_total counter\n")
+	fmt.Fprintf(&b, "sysprobe_exporter_dropped_series_total %d\n", dropped)
+
+	io.WriteString(w, b.String())
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	return name + formatLabels(labels)
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
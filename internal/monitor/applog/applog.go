@@ -0,0 +1,329 @@
+package applog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "APPLOG"
+
+const defaultMaxLineBytes = 16 * 1024
+
+// AppLogJSON 單筆應用程式日誌（多行合併後）
+type AppLogJSON struct {
+	Host      service.HostInfo  `json:"Host"`
+	Category  string            `json:"Category"`
+	Source    string            `json:"Source"`
+	Path      string            `json:"Path"`
+	Labels    map[string]string `json:"Labels"`
+	Message   string            `json:"Message"`
+	Lines     int               `json:"Lines"`
+	Truncated bool              `json:"Truncated"`
+	Offset    int64             `json:"Offset"` // 紀錄在原始檔案中的起始位置
+	Timestamp string            `json:"Timestamp"`
+}
+
+// source 一組設定編譯後的結果
+type source struct {
+	cfg       config.AppLogSource
+	multiline *regexp.Regexp
+	codec     *codec
+	maxLine   int
+}
+
+// fileTail 單一檔案的追蹤狀態
+type fileTail struct {
+	src     *source
+	path    string
+	fw      *utils.Follower
+	pending *AppLogJSON // 多行模式下尚未結束的紀錄
+	size    int         // pending 的原始位元組數
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		tails := make(map[string]*fileTail)
+
+		defer func() {
+			for _, t := range tails {
+				t.fw.Close()
+			}
+			if r := recover(); r != nil {
+				utils.Log.Error("[AppLog] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		sources := compileSources(cfg.AppLog.Sources)
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		statePath := cfg.Data + "/applog.json"
+		states := loadState(statePath)
+
+		ticker := time.NewTicker(time.Duration(cfg.AppLog.Interval) * time.Second)
+		defer ticker.Stop()
+
+		write := func(rec *AppLogJSON) {
+			rec.Host = host.Get()
+			b, _ := json.Marshal(rec)
+			logger.Write(b)
+		}
+
+		startup := true
+		for {
+			scan(sources, tails, states, startup, write)
+			startup = false
+
+			// 只保留仍在追蹤中的檔案位置
+			next := make(map[string]utils.FollowState, len(tails))
+			for key, t := range tails {
+				next[key] = t.committed()
+			}
+			states = next
+			if err := saveState(statePath, states); err != nil {
+				utils.Log.Error("[AppLog] 無法儲存狀態: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				utils.Log.Info("[AppLog] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+func compileSources(list []config.AppLogSource) []*source {
+	var out []*source
+	for _, c := range list {
+		s := &source{cfg: c, maxLine: c.MaxLineBytes}
+		if s.maxLine <= 0 {
+			s.maxLine = defaultMaxLineBytes
+		}
+
+		codec, err := newCodec(c.Encoding)
+		if err != nil {
+			utils.Log.Error("[AppLog] source %s: %v", c.Name, err)
+			continue
+		}
+		s.codec = codec
+		// 多位元組換行時，上限需對齊字元邊界
+		s.maxLine -= s.maxLine % len(codec.sep)
+
+		if c.Multiline != "" {
+			re, err := regexp.Compile(c.Multiline)
+			if err != nil {
+				utils.Log.Error("[AppLog] source %s: invalid multiline pattern: %v", c.Name, err)
+				continue
+			}
+			s.multiline = re
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// scan 展開 glob、建立新的追蹤並讀取所有檔案
+// 追蹤與位置依 source 與檔案識別（裝置:inode）記錄，輪替後改名的舊檔仍符合 glob 時沿用原本的追蹤，不會從頭重讀
+func scan(sources []*source, tails map[string]*fileTail, states map[string]utils.FollowState, startup bool, write func(*AppLogJSON)) {
+	seen := make(map[string]bool)
+
+	for _, src := range sources {
+		var paths []string
+		for _, pattern := range src.cfg.Paths {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				utils.Log.Error("[AppLog] invalid glob %s: %v", pattern, err)
+				continue
+			}
+			paths = append(paths, matches...)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			id := utils.FileKey(fi)
+			key := src.cfg.Name + ":" + path
+			if id != "" {
+				key = src.cfg.Name + ":" + id
+			}
+			// 同一個檔案（例如 hard link）只讀一次
+			if seen[key] {
+				continue
+			}
+
+			t, ok := tails[key]
+			if !ok {
+				t, err = newTail(src, key, id, path, states, startup)
+				if err != nil {
+					if !errors.Is(err, os.ErrNotExist) {
+						utils.Log.Error("[AppLog] open %s fail: %v", path, err)
+					}
+					continue
+				}
+				if t == nil {
+					continue
+				}
+				tails[key] = t
+			}
+			seen[key] = true
+			t.path = path
+
+			if err := t.poll(write); err != nil && !errors.Is(err, os.ErrNotExist) {
+				utils.Log.Error("[AppLog] read %s fail: %v", path, err)
+			}
+		}
+	}
+
+	// 不再符合 glob 的檔案（已刪除或改名到 glob 之外）讀完剩下的內容後停止追蹤
+	for key, t := range tails {
+		if !seen[key] {
+			t.poll(write)
+			t.flush(write)
+			t.fw.Close()
+			delete(tails, key)
+		}
+	}
+}
+
+// newTail 開始追蹤一個檔案；開啟時路徑已換成別的檔案則回傳 nil，下一輪再處理
+func newTail(src *source, key, id, path string, states map[string]utils.FollowState, startup bool) (*fileTail, error) {
+	state, known := states[key]
+	if !known {
+		// 舊版以路徑記錄的位置，inode 不符時 Follower 不會使用
+		state, known = states[src.cfg.Name+":"+path]
+	}
+	// 啟動時已存在、又沒有紀錄位置的檔案，預設從檔尾開始
+	fromEnd := startup && !known && !src.cfg.FromBeginning
+
+	t := &fileTail{src: src, path: path}
+	if id == "" {
+		// 沒有 inode（Windows）時依路徑追蹤，輪替由 Follower 處理
+		t.fw = utils.NewFollower(path, state, fromEnd, src.codec.sep, src.maxLine)
+		return t, nil
+	}
+
+	fw, err := utils.OpenFollower(path, state, fromEnd, src.codec.sep, src.maxLine)
+	if err != nil {
+		return nil, err
+	}
+	if fw.Key() != id {
+		fw.Close()
+		return nil, nil
+	}
+	t.fw = fw
+	return t, nil
+}
+
+// poll 讀取新資料並依多行規則組成紀錄；本輪沒有新資料時送出未結束的紀錄
+func (t *fileTail) poll(write func(*AppLogJSON)) error {
+	got := false
+	err := t.fw.Poll(func(raw []byte, start int64, truncated bool) {
+		got = true
+		line := t.src.codec.decode(raw)
+
+		if t.pending != nil && t.src.multiline != nil && !t.src.multiline.MatchString(line) {
+			t.appendLine(line, len(raw), truncated)
+			return
+		}
+
+		t.flush(write)
+		t.pending = &AppLogJSON{
+			Category:  Category,
+			Source:    t.src.cfg.Name,
+			Path:      t.path,
+			Labels:    t.src.cfg.Labels,
+			Message:   line,
+			Lines:     1,
+			Truncated: truncated,
+			Offset:    start,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		t.size = len(raw)
+
+		if t.src.multiline == nil {
+			t.flush(write)
+		}
+	})
+
+	if !got {
+		t.flush(write)
+	}
+	return err
+}
+
+// appendLine 多行合併，超過長度上限的部分捨棄
+func (t *fileTail) appendLine(line string, rawLen int, truncated bool) {
+	p := t.pending
+	p.Lines++
+	if truncated {
+		p.Truncated = true
+	}
+	if t.size+rawLen > t.src.maxLine {
+		p.Truncated = true
+		return
+	}
+	p.Message += "\n" + line
+	t.size += rawLen
+}
+
+func (t *fileTail) flush(write func(*AppLogJSON)) {
+	if t.pending == nil {
+		return
+	}
+	write(t.pending)
+	t.pending = nil
+	t.size = 0
+}
+
+// committed 可安全儲存的位置：尚未送出的多行紀錄需要在重啟後重讀
+func (t *fileTail) committed() utils.FollowState {
+	s := t.fw.State()
+	if t.pending != nil {
+		s.Offset = t.pending.Offset
+	}
+	return s
+}
+
+// ------------------------- State -------------------------
+func loadState(path string) map[string]utils.FollowState {
+	s := make(map[string]utils.FollowState)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return make(map[string]utils.FollowState)
+	}
+	return s
+}
+
+func saveState(path string, s map[string]utils.FollowState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// trimLine 去掉 Windows 換行殘留的 \r
+func trimLine(s string) string {
+	return strings.TrimSuffix(s, "\r")
+}
@@ -0,0 +1,145 @@
+package applog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/utils"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, l := range lines {
+		f.WriteString(l + "\n")
+	}
+}
+
+// collector 每輪 scan 後保存位置，模擬 Start 的迴圈
+type collector struct {
+	sources []*source
+	tails   map[string]*fileTail
+	states  map[string]utils.FollowState
+	got     []string
+}
+
+func newCollector(src config.AppLogSource, states map[string]utils.FollowState) *collector {
+	if states == nil {
+		states = make(map[string]utils.FollowState)
+	}
+	return &collector{sources: compileSources([]config.AppLogSource{src}), tails: make(map[string]*fileTail), states: states}
+}
+
+func (c *collector) scan(startup bool) []string {
+	c.got = nil
+	scan(c.sources, c.tails, c.states, startup, func(rec *AppLogJSON) {
+		c.got = append(c.got, filepath.Base(rec.Path)+":"+rec.Message)
+	})
+	c.states = make(map[string]utils.FollowState)
+	for key, t := range c.tails {
+		c.states[key] = t.committed()
+	}
+	return c.got
+}
+
+func (c *collector) close() {
+	for _, t := range c.tails {
+		t.fw.Close()
+	}
+}
+
+func TestScanRotationWithGlobMatchingRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, "a", "b")
+
+	c := newCollector(config.AppLogSource{Name: "app", Paths: []string{filepath.Join(dir, "app.log*")}, FromBeginning: true}, nil)
+	defer c.close()
+	if got := strings.Join(c.scan(true), ","); got != "app.log:a,app.log:b" {
+		t.Fatalf("first scan = %s", got)
+	}
+
+	// 輪替：app.log → app.log.1，建立新的 app.log；輪替前寫入的行只讀一次
+	appendLines(t, path, "c")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "d")
+	if got := strings.Join(c.scan(false), ","); got != "app.log:d,app.log.1:c" {
+		t.Fatalf("after rotation = %s, want app.log:d,app.log.1:c", got)
+	}
+
+	// 再輪替一次：app.log.1 → app.log.2、app.log → app.log.1
+	if err := os.Rename(path+".1", path+".2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "e")
+	if got := strings.Join(c.scan(false), ","); got != "app.log:e" {
+		t.Fatalf("second rotation = %s, want app.log:e", got)
+	}
+
+	// 重新啟動後依檔案識別繼續，不重讀輪替後的檔案
+	c.close()
+	appendLines(t, path, "f")
+	restarted := newCollector(config.AppLogSource{Name: "app", Paths: []string{filepath.Join(dir, "app.log*")}, FromBeginning: true}, c.states)
+	defer restarted.close()
+	if got := strings.Join(restarted.scan(true), ","); got != "app.log:f" {
+		t.Fatalf("after restart = %s, want app.log:f", got)
+	}
+}
+
+func TestScanRotatedOutOfGlob(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, "a")
+
+	c := newCollector(config.AppLogSource{Name: "app", Paths: []string{path}, FromBeginning: true}, nil)
+	defer c.close()
+	c.scan(true)
+
+	// 改名到 glob 之外前寫入的行仍要讀到
+	appendLines(t, path, "b")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "c")
+	if got := strings.Join(c.scan(false), ","); got != "app.log:c,app.log:b" {
+		t.Fatalf("scan = %s, want app.log:c,app.log:b", got)
+	}
+	if len(c.tails) != 1 {
+		t.Errorf("tracking %d files, want 1", len(c.tails))
+	}
+}
+
+func TestScanMultiline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, "2026-10-19 ERROR boom", "  at main.go:1", "  at main.go:2", "2026-10-19 INFO ok")
+
+	c := newCollector(config.AppLogSource{Name: "app", Paths: []string{path}, Multiline: `^\d{4}-`, FromBeginning: true}, nil)
+	defer c.close()
+	got := c.scan(true)
+	// 最後一筆在下一輪沒有新資料時才送出
+	got = append(got, c.scan(false)...)
+	want := []string{"app.log:2026-10-19 ERROR boom\n  at main.go:1\n  at main.go:2", "app.log:2026-10-19 INFO ok"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("records = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package applog
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// codec 原始位元組 → UTF-8，以及該編碼的換行序列
+type codec struct {
+	enc encoding.Encoding // nil 表示 UTF-8
+	sep []byte
+}
+
+func newCodec(name string) (*codec, error) {
+	switch strings.ToLower(name) {
+	case "", "utf-8", "utf8":
+		return &codec{sep: []byte{'\n'}}, nil
+	case "utf-16le", "utf16le":
+		return &codec{enc: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), sep: []byte{'\n', 0}}, nil
+	case "utf-16be", "utf16be":
+		return &codec{enc: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), sep: []byte{0, '\n'}}, nil
+	}
+
+	// 其他與 ASCII 相容的編碼（big5、gbk、shift_jis、iso-8859-1 ...）
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return &codec{enc: enc, sep: []byte{'\n'}}, nil
+}
+
+// decode 轉成 UTF-8，無法轉換的位元組以替代字元取代
+func (c *codec) decode(raw []byte) string {
+	if c.enc == nil {
+		return trimLine(strings.ToValidUTF8(string(raw), "\uFFFD"))
+	}
+	b, err := c.enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		return trimLine(strings.ToValidUTF8(string(raw), "\uFFFD"))
+	}
+	// 去掉 UTF-16 檔頭的 BOM
+	return trimLine(strings.TrimPrefix(string(b), "\uFEFF"))
+}
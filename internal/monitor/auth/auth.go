@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "AUTH"
+
+var defaultPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// AuthEventJSON 單筆 sshd / sudo 紀錄
+type AuthEventJSON struct {
+	Host       service.HostInfo `json:"Host"`
+	Category   string           `json:"Category"`
+	Event      string           `json:"Event"`
+	Program    string           `json:"Program"`
+	User       string           `json:"User"`
+	SourceIP   string           `json:"SourceIP"`
+	Port       int              `json:"Port"`
+	Method     string           `json:"Method"` // password / publickey / keyboard-interactive / sudo
+	Success    bool             `json:"Success"`
+	TargetUser string           `json:"TargetUser"` // sudo 的目標使用者
+	TTY        string           `json:"TTY"`
+	Command    string           `json:"Command"`
+	Message    string           `json:"Message"` // 原始訊息
+	Timestamp  string           `json:"Timestamp"`
+}
+
+// FailedSummaryJSON 每個統計週期各來源 IP 的登入失敗次數
+type FailedSummaryJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Window    int              `json:"Window"` // 秒
+	Total     int              `json:"Total"`
+	BySource  map[string]int   `json:"BySource"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// BruteForceJSON 單一來源 IP 在統計週期內失敗次數達到門檻
+type BruteForceJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	SourceIP  string           `json:"SourceIP"`
+	Failures  int              `json:"Failures"`
+	Threshold int              `json:"Threshold"`
+	Window    int              `json:"Window"` // 秒
+	Users     []string         `json:"Users"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// authState 持久化於 monitor.data
+type authState struct {
+	Files  map[string]utils.FollowState `json:"files"`
+	Cursor string                       `json:"cursor"` // journal cursor
+}
+
+// collector 單一 goroutine 內使用，不需要鎖
+type collector struct {
+	cfg    config.AuthModule
+	host   *service.HostUpdater
+	logger interface {
+		Write(data any) error
+	}
+
+	failures map[string]int             // 來源 IP → 本週期失敗次數
+	users    map[string]map[string]bool // 來源 IP → 嘗試過的帳號
+	alerted  map[string]bool            // 本週期已輸出暴力破解事件的來源 IP
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		followers := make(map[string]*utils.Follower)
+
+		defer func() {
+			for _, fw := range followers {
+				fw.Close()
+			}
+			if r := recover(); r != nil {
+				utils.Log.Error("[Auth] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		c := &collector{
+			cfg:    cfg.Auth,
+			host:   host,
+			logger: utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days),
+		}
+		c.resetWindow()
+
+		statePath := cfg.Data + "/auth.json"
+		state := loadState(statePath)
+
+		// journal 由另一個 goroutine 讀取，透過 channel 回到這裡處理
+		var journal chan journalEntry
+		if cfg.Auth.Journal {
+			journal = make(chan journalEntry, 256)
+			go followJournal(ctx, state.Cursor, journal)
+		} else {
+			paths := cfg.Auth.Paths
+			if len(paths) == 0 {
+				paths = defaultPaths
+			}
+			for _, p := range paths {
+				st, known := state.Files[p]
+				// 第一次啟動不回頭處理舊紀錄；檔案還不存在時，之後出現的內容都是新的，從頭讀取
+				_, err := os.Stat(p)
+				followers[p] = utils.NewFollower(p, st, !known && err == nil, nil, 0)
+			}
+		}
+
+		pollTicker := time.NewTicker(time.Duration(cfg.Auth.Interval) * time.Second)
+		defer pollTicker.Stop()
+		windowTicker := time.NewTicker(time.Duration(cfg.Auth.Window) * time.Second)
+		defer windowTicker.Stop()
+
+		for {
+			select {
+			case <-pollTicker.C:
+				// journal cursor 也在這裡保存，不必每筆紀錄寫一次磁碟
+				for p, fw := range followers {
+					err := fw.Poll(func(line []byte, _ int64, _ bool) {
+						if ev, ok := parseSyslogLine(string(line), time.Now()); ok {
+							c.handle(ev, string(line))
+						}
+					})
+					if err != nil {
+						if !errors.Is(err, os.ErrNotExist) {
+							utils.Log.Error("[Auth] read %s fail: %v", p, err)
+						}
+						continue
+					}
+					state.Files[p] = fw.State()
+				}
+				if err := saveState(statePath, state); err != nil {
+					utils.Log.Error("[Auth] 無法儲存狀態: %v", err)
+				}
+			case e := <-journal:
+				if ev, ok := parseMessage(e.Identifier, e.Message); ok {
+					ev.Time = e.time()
+					c.handle(ev, e.Message)
+				}
+				state.Cursor = e.Cursor
+			case <-windowTicker.C:
+				c.flushWindow()
+			case <-ctx.Done():
+				if err := saveState(statePath, state); err != nil {
+					utils.Log.Error("[Auth] 無法儲存狀態: %v", err)
+				}
+				utils.Log.Info("[Auth] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// handle 輸出單筆事件並累計失敗次數
+func (c *collector) handle(ev authEvent, raw string) {
+	at := ev.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	data := AuthEventJSON{
+		Host:       c.host.Get(),
+		Category:   Category,
+		Event:      ev.Event,
+		Program:    ev.Program,
+		User:       ev.User,
+		SourceIP:   ev.SourceIP,
+		Port:       ev.Port,
+		Method:     ev.Method,
+		Success:    ev.Success,
+		TargetUser: ev.TargetUser,
+		TTY:        ev.TTY,
+		Command:    ev.Command,
+		Message:    raw,
+		Timestamp:  at.Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("%s", string(b))
+	c.logger.Write(b)
+
+	// Invalid user 通常接著一筆 Failed，max attempts 是前面幾筆 Failed 的彙總，只計算 Failed 避免重複
+	if ev.Event != EventLoginFailed || ev.SourceIP == "" {
+		return
+	}
+
+	c.failures[ev.SourceIP]++
+	if c.users[ev.SourceIP] == nil {
+		c.users[ev.SourceIP] = make(map[string]bool)
+	}
+	c.users[ev.SourceIP][ev.User] = true
+
+	if c.cfg.Threshold > 0 && c.failures[ev.SourceIP] >= c.cfg.Threshold && !c.alerted[ev.SourceIP] {
+		c.alerted[ev.SourceIP] = true
+		c.writeBruteForce(ev.SourceIP)
+	}
+}
+
+func (c *collector) writeBruteForce(ip string) {
+	users := make([]string, 0, len(c.users[ip]))
+	for u := range c.users[ip] {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+
+	data := BruteForceJSON{
+		Host:      c.host.Get(),
+		Category:  Category,
+		Event:     EventBruteForce,
+		SourceIP:  ip,
+		Failures:  c.failures[ip],
+		Threshold: c.cfg.Threshold,
+		Window:    c.cfg.Window,
+		Users:     users,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Warn("[Auth] brute force suspected from %s (%d failures)", ip, data.Failures)
+	c.logger.Write(b)
+}
+
+// flushWindow 輸出本週期的失敗統計（沒有失敗則略過）並歸零
+func (c *collector) flushWindow() {
+	defer c.resetWindow()
+
+	total := 0
+	for _, n := range c.failures {
+		total += n
+	}
+	if total == 0 {
+		return
+	}
+
+	data := FailedSummaryJSON{
+		Host:      c.host.Get(),
+		Category:  Category,
+		Event:     EventFailedSummary,
+		Window:    c.cfg.Window,
+		Total:     total,
+		BySource:  c.failures,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("%s", string(b))
+	c.logger.Write(b)
+}
+
+func (c *collector) resetWindow() {
+	c.failures = make(map[string]int)
+	c.users = make(map[string]map[string]bool)
+	c.alerted = make(map[string]bool)
+}
+
+// ------------------------- State -------------------------
+func loadState(path string) authState {
+	s := authState{Files: make(map[string]utils.FollowState)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil || s.Files == nil {
+		return authState{Files: make(map[string]utils.FollowState)}
+	}
+	return s
+}
+
+func saveState(path string, s authState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
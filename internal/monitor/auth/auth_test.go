@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestParseSyslogTime(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		line string
+		want time.Time
+	}{
+		{"2026-01-02T09:15:30.123456+08:00 web1 sshd[812]: Failed password for root from 203.0.113.9 port 51022 ssh2",
+			time.Date(2026, 1, 2, 9, 15, 30, 123456000, time.FixedZone("", 8*3600))},
+		{"Jan  2 09:15:30 web1 sshd[812]: Failed password for root from 203.0.113.9 port 51022 ssh2",
+			time.Date(2026, 1, 2, 9, 15, 30, 0, time.Local)},
+		// 跨年讀到去年的紀錄
+		{"Dec 31 23:59:58 web1 sshd[812]: Failed password for root from 203.0.113.9 port 51022 ssh2",
+			time.Date(2025, 12, 31, 23, 59, 58, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		ev, ok := parseSyslogLine(tt.line, now)
+		if !ok || ev.Event != EventLoginFailed || ev.SourceIP != "203.0.113.9" {
+			t.Fatalf("parse %q = %+v, %v", tt.line, ev, ok)
+		}
+		if !ev.Time.Equal(tt.want) {
+			t.Errorf("time of %q = %s, want %s", tt.line, ev.Time, tt.want)
+		}
+	}
+
+	e := journalEntry{Realtime: "1767319530123456"}
+	if got := e.time(); !got.Equal(time.UnixMicro(1767319530123456)) {
+		t.Errorf("journal time = %s", got)
+	}
+}
+
+// 啟動時還不存在的檔案，出現後第一行也要讀到，時間取自紀錄本身
+func TestFileCreatedAfterStart(t *testing.T) {
+	// 收集器停止時才寫入最後的狀態，不用 t.TempDir 以免清理時目錄仍在寫入
+	dir, _ := os.MkdirTemp("", "sysprobe-auth")
+	path := filepath.Join(dir, "auth.log")
+
+	var mu sync.Mutex
+	var got []AuthEventJSON
+	utils.AddWriteHook(func(category string, line []byte) {
+		if category != Category {
+			return
+		}
+		var ev AuthEventJSON
+		json.Unmarshal(line, &ev)
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, ev)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		time.Sleep(100 * time.Millisecond)
+		os.RemoveAll(dir)
+	}()
+	cfg := config.MonitorConfig{Data: dir, Days: 1}
+	cfg.Auth = config.AuthModule{
+		MonitorModule: config.MonitorModule{Enable: true, Interval: 1},
+		Paths:         []string{path},
+		Window:        60,
+	}
+	Start(ctx, cfg, &service.HostUpdater{})
+
+	time.Sleep(1200 * time.Millisecond)
+	os.WriteFile(path, []byte(
+		"2026-10-19T08:00:01+00:00 web1 sshd[812]: Accepted publickey for deploy from 198.51.100.4 port 40022 ssh2\n"+
+			"2026-10-19T08:00:02+00:00 web1 sshd[813]: Failed password for root from 203.0.113.9 port 51022 ssh2\n"), 0644)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].Event != EventLoginSuccess || got[0].User != "deploy" || got[0].Timestamp != "2026-10-19T08:00:01Z" {
+		t.Errorf("first event = %+v", got[0])
+	}
+	if got[1].Event != EventLoginFailed || got[1].Timestamp != "2026-10-19T08:00:02Z" {
+		t.Errorf("second event = %+v", got[1])
+	}
+}
+
+type memLogger struct{ lines [][]byte }
+
+func (l *memLogger) Write(data any) error {
+	l.lines = append(l.lines, data.([]byte))
+	return nil
+}
+
+// sshd 超過 MaxAuthTries 時的彙總行自成一個事件，不可再多算一次失敗
+func TestMaxAttemptsNotCounted(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	failed := "Oct 19 08:00:02 web1 sshd[813]: Failed password for root from 203.0.113.9 port 51022 ssh2"
+	maxed := "Oct 19 08:00:05 web1 sshd[813]: error: maximum authentication attempts exceeded for root from 203.0.113.9 port 51022 ssh2 [preauth]"
+
+	ev, ok := parseSyslogLine(maxed, now)
+	if !ok {
+		t.Fatalf("parse %q failed", maxed)
+	}
+	if ev.Event != EventMaxAttempts || ev.User != "root" || ev.SourceIP != "203.0.113.9" || ev.Port != 51022 {
+		t.Errorf("max attempts = %+v", ev)
+	}
+
+	logger := &memLogger{}
+	c := &collector{
+		cfg:    config.AuthModule{Threshold: 4, Window: 60},
+		host:   &service.HostUpdater{},
+		logger: logger,
+	}
+	c.resetWindow()
+	for i := 0; i < 3; i++ {
+		ev, _ := parseSyslogLine(failed, now)
+		c.handle(ev, failed)
+	}
+	ev, _ = parseSyslogLine(maxed, now)
+	c.handle(ev, maxed)
+
+	if got := c.failures["203.0.113.9"]; got != 3 {
+		t.Errorf("failures = %d, want 3", got)
+	}
+	if c.alerted["203.0.113.9"] {
+		t.Error("brute force raised below threshold")
+	}
+	if len(logger.lines) != 4 {
+		t.Fatalf("got %d events, want 4", len(logger.lines))
+	}
+	var last AuthEventJSON
+	json.Unmarshal(logger.lines[3], &last)
+	if last.Event != EventMaxAttempts {
+		t.Errorf("last event = %s, want %s", last.Event, EventMaxAttempts)
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+// journalctl 結束後重新啟動的間隔
+const journalRetryTime = 10 * time.Second
+
+// journalEntry journalctl -o json 的欄位
+type journalEntry struct {
+	Cursor     string `json:"__CURSOR"`
+	Realtime   string `json:"__REALTIME_TIMESTAMP"` // 微秒
+	Identifier string `json:"SYSLOG_IDENTIFIER"`
+	Message    string `json:"MESSAGE"`
+}
+
+// time 紀錄寫入 journal 的時間，無法解析時為零值
+func (e journalEntry) time() time.Time {
+	usec, err := strconv.ParseInt(e.Realtime, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMicro(usec)
+}
+
+// followJournal 以 journalctl -f 追蹤 sshd / sudo，從上次的 cursor 繼續
+func followJournal(ctx context.Context, cursor string, out chan<- journalEntry) {
+	for {
+		args := []string{"-f", "-o", "json", "--no-pager",
+			"SYSLOG_IDENTIFIER=sshd", "SYSLOG_IDENTIFIER=sshd-session", "SYSLOG_IDENTIFIER=sudo"}
+		if cursor != "" {
+			args = append(args, "--after-cursor="+cursor)
+		} else {
+			args = append(args, "-n", "0")
+		}
+
+		cmd := exec.CommandContext(ctx, "journalctl", args...)
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			utils.Log.Error("[Auth] 無法啟動 journalctl: %v", err)
+		} else {
+			scanner := bufio.NewScanner(stdout)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				var e journalEntry
+				if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+					continue
+				}
+				cursor = e.Cursor
+				select {
+				case out <- e:
+				case <-ctx.Done():
+				}
+			}
+			if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+				utils.Log.Error("[Auth] journalctl exited: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(journalRetryTime):
+		}
+	}
+}
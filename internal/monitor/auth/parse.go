@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 事件種類
+const (
+	EventLoginSuccess   = "AUTH_LOGIN_SUCCESS"
+	EventLoginFailed    = "AUTH_LOGIN_FAILED"
+	EventInvalidUser    = "AUTH_INVALID_USER"
+	EventSudoCommand    = "SUDO_COMMAND"
+	EventSudoAuthFailed = "SUDO_AUTH_FAILED"
+	EventMaxAttempts    = "AUTH_MAX_ATTEMPTS" // sshd 斷線前的彙總，不另計失敗次數
+	EventFailedSummary  = "AUTH_FAILED_SUMMARY"
+	EventBruteForce     = "AUTH_BRUTE_FORCE"
+)
+
+// authEvent 解析後的單筆登入 / sudo 紀錄
+type authEvent struct {
+	Event      string
+	Program    string
+	User       string
+	SourceIP   string
+	Port       int
+	Method     string
+	Success    bool
+	TargetUser string
+	TTY        string
+	Command    string
+	Time       time.Time // 紀錄本身的時間，無法解析時為零值
+}
+
+// syslog 行首："Oct 19 08:00:00 host prog[pid]: msg" 或 "2025-10-19T08:00:00+08:00 host prog[pid]: msg"
+var syslogPattern = regexp.MustCompile(`^([A-Z][a-z]{2}\s+\d+\s+[\d:]+|\d{4}-\d{2}-\d{2}T\S+)\s+\S+\s+([^\s\[:]+)(?:\[\d+\])?:\s+(.*)$`)
+
+var (
+	sshAccepted    = regexp.MustCompile(`^Accepted (\S+) for (\S+) from (\S+) port (\d+)`)
+	sshFailed      = regexp.MustCompile(`^Failed (\S+) for (?:invalid user )?(\S*) from (\S+) port (\d+)`)
+	sshMaxAttempts = regexp.MustCompile(`^(?:error: )?maximum authentication attempts exceeded for (?:invalid user )?(\S*) from (\S+) port (\d+)`)
+	sshInvalidUser = regexp.MustCompile(`^Invalid user (\S*) from (\S+)(?: port (\d+))?`)
+	sudoCommand    = regexp.MustCompile(`^\s*(\S+) : (?:.*?; )?TTY=(\S+) ; PWD=.*? ; USER=(\S+) ;(?: .*? ;)? COMMAND=(.*)$`)
+	sudoFailed     = regexp.MustCompile(`^\s*(\S+) : \d+ incorrect password attempts? ; TTY=(\S+) ; PWD=.*? ; USER=(\S+) ;(?: .*? ;)? COMMAND=(.*)$`)
+)
+
+// parseSyslogLine 拆出時間、程式名稱與訊息後交給 parseMessage
+func parseSyslogLine(line string, now time.Time) (authEvent, bool) {
+	m := syslogPattern.FindStringSubmatch(line)
+	if m == nil {
+		return authEvent{}, false
+	}
+	ev, ok := parseMessage(m[2], m[3])
+	if ok {
+		ev.Time = parseSyslogTime(m[1], now)
+	}
+	return ev, ok
+}
+
+// parseSyslogTime 解析 RFC3339 或傳統 syslog 時間（沒有年份，以本地時區、now 的年份補上）
+func parseSyslogTime(s string, now time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	t, err := time.ParseInLocation(time.Stamp, strings.Join(strings.Fields(s), " "), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+	// 跨年時讀到去年 12 月的紀錄
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// parseMessage 解析 sshd / sudo 的訊息本體
+func parseMessage(program, msg string) (authEvent, bool) {
+	switch {
+	case program == "sshd" || strings.HasPrefix(program, "sshd-"):
+		return parseSSHD(msg)
+	case program == "sudo":
+		return parseSudo(msg)
+	}
+	return authEvent{}, false
+}
+
+func parseSSHD(msg string) (authEvent, bool) {
+	ev := authEvent{Program: "sshd"}
+
+	if m := sshAccepted.FindStringSubmatch(msg); m != nil {
+		ev.Event, ev.Success = EventLoginSuccess, true
+		ev.Method, ev.User, ev.SourceIP = m[1], m[2], m[3]
+		ev.Port, _ = strconv.Atoi(m[4])
+		return ev, true
+	}
+	if m := sshFailed.FindStringSubmatch(msg); m != nil {
+		ev.Event = EventLoginFailed
+		ev.Method, ev.User, ev.SourceIP = m[1], m[2], m[3]
+		ev.Port, _ = strconv.Atoi(m[4])
+		return ev, true
+	}
+	if m := sshMaxAttempts.FindStringSubmatch(msg); m != nil {
+		ev.Event = EventMaxAttempts
+		ev.User, ev.SourceIP = m[1], m[2]
+		ev.Port, _ = strconv.Atoi(m[3])
+		return ev, true
+	}
+	if m := sshInvalidUser.FindStringSubmatch(msg); m != nil {
+		ev.Event = EventInvalidUser
+		ev.User, ev.SourceIP = m[1], m[2]
+		ev.Port, _ = strconv.Atoi(m[3])
+		return ev, true
+	}
+	return authEvent{}, false
+}
+
+func parseSudo(msg string) (authEvent, bool) {
+	ev := authEvent{Program: "sudo", Method: "sudo"}
+
+	if m := sudoFailed.FindStringSubmatch(msg); m != nil {
+		ev.Event = EventSudoAuthFailed
+		ev.User, ev.TTY, ev.TargetUser, ev.Command = m[1], m[2], m[3], m[4]
+		return ev, true
+	}
+	if m := sudoCommand.FindStringSubmatch(msg); m != nil {
+		ev.Event, ev.Success = EventSudoCommand, true
+		ev.User, ev.TTY, ev.TargetUser, ev.Command = m[1], m[2], m[3], m[4]
+		return ev, true
+	}
+	return authEvent{}, false
+}
@@ -0,0 +1,203 @@
+package certs
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "CERT"
+
+// 事件種類
+const (
+	EventOK       = "CERT_OK"
+	EventWarning  = "CERT_WARNING"  // 剩餘天數低於 warn_days
+	EventCritical = "CERT_CRITICAL" // 剩餘天數低於 critical_days 或已過期
+	EventError    = "CERT_ERROR"    // 端點無法連線或握手失敗
+)
+
+// 來源種類
+const (
+	SourceFile     = "file"
+	SourceEndpoint = "endpoint"
+)
+
+const (
+	defaultWarnDays     = 30
+	defaultCriticalDays = 7
+	defaultTimeout      = 10 // 秒
+)
+
+// CertJSON 單張憑證的到期狀態
+type CertJSON struct {
+	Host          service.HostInfo `json:"Host"`
+	Category      string           `json:"Category"`
+	Event         string           `json:"Event"`
+	Source        string           `json:"Source"`   // file / endpoint
+	Target        string           `json:"Target"`   // 檔案路徑或 host:port
+	Position      int              `json:"Position"` // 檔案或憑證鏈中的順序，端點的 0 為伺服器憑證
+	Subject       string           `json:"Subject"`
+	Issuer        string           `json:"Issuer"`
+	SANs          []string         `json:"SANs"`
+	Serial        string           `json:"Serial"`
+	Fingerprint   string           `json:"Fingerprint"` // sha256
+	NotBefore     string           `json:"NotBefore"`
+	NotAfter      string           `json:"NotAfter"`
+	DaysRemaining int              `json:"DaysRemaining"` // 已過期為負數
+	Expired       bool             `json:"Expired"`
+	Timestamp     string           `json:"Timestamp"`
+}
+
+// ErrorJSON 端點檢查失敗
+type ErrorJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Source    string           `json:"Source"`
+	Target    string           `json:"Target"`
+	Error     string           `json:"Error"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Cert] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+
+		// 啟動時先檢查一次，不必等第一個週期
+		for _, line := range scan(ctx, cfg.Cert, host) {
+			logger.Write(line)
+		}
+
+		ticker := time.NewTicker(time.Duration(cfg.Cert.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, line := range scan(ctx, cfg.Cert, host) {
+					logger.Write(line)
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Cert] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// scan 檢查所有設定的檔案與端點
+func scan(ctx context.Context, cfg config.CertModule, host *service.HostUpdater) [][]byte {
+	warnDays, criticalDays := cfg.WarnDays, cfg.CriticalDays
+	if warnDays <= 0 {
+		warnDays = defaultWarnDays
+	}
+	if criticalDays <= 0 {
+		criticalDays = defaultCriticalDays
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout * time.Second
+	}
+
+	var out [][]byte
+	report := func(source, target string, certs []*x509.Certificate) {
+		for i, c := range certs {
+			data := describe(c, warnDays, criticalDays)
+			data.Host = host.Get()
+			data.Source = source
+			data.Target = target
+			data.Position = i
+			b, _ := json.Marshal(data)
+			if data.Event == EventOK {
+				utils.Log.Debug("%s", string(b))
+			} else {
+				utils.Log.Warn("[Cert] %s %s (%s) expires in %d days", data.Event, target, data.Subject, data.DaysRemaining)
+			}
+			out = append(out, b)
+		}
+	}
+
+	for _, path := range expandPaths(cfg.Paths) {
+		certs, err := readCertFile(path)
+		if err != nil {
+			utils.Log.Debug("[Cert] skip %s: %v", path, err)
+			continue
+		}
+		report(SourceFile, path, certs)
+	}
+
+	for _, endpoint := range cfg.Endpoints {
+		certs, err := fetchEndpoint(ctx, endpoint, timeout)
+		if err != nil {
+			utils.Log.Error("[Cert] %s fail: %v", endpoint, err)
+			data := ErrorJSON{
+				Host:      host.Get(),
+				Category:  Category,
+				Event:     EventError,
+				Source:    SourceEndpoint,
+				Target:    endpoint,
+				Error:     err.Error(),
+				Timestamp: time.Now().Format(time.RFC3339),
+			}
+			b, _ := json.Marshal(data)
+			out = append(out, b)
+			continue
+		}
+		report(SourceEndpoint, endpoint, certs)
+	}
+	return out
+}
+
+// describe 轉換憑證資訊並依剩餘天數分級
+func describe(c *x509.Certificate, warnDays, criticalDays int) CertJSON {
+	now := time.Now()
+	days := int(math.Floor(c.NotAfter.Sub(now).Hours() / 24))
+
+	event := EventOK
+	switch {
+	case days < criticalDays:
+		event = EventCritical
+	case days < warnDays:
+		event = EventWarning
+	}
+
+	sans := []string{}
+	sans = append(sans, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		sans = append(sans, u.String())
+	}
+
+	sum := sha256.Sum256(c.Raw)
+	return CertJSON{
+		Category:      Category,
+		Event:         event,
+		Subject:       c.Subject.String(),
+		Issuer:        c.Issuer.String(),
+		SANs:          sans,
+		Serial:        c.SerialNumber.Text(16),
+		Fingerprint:   hex.EncodeToString(sum[:]),
+		NotBefore:     c.NotBefore.Format(time.RFC3339),
+		NotAfter:      c.NotAfter.Format(time.RFC3339),
+		DaysRemaining: days,
+		Expired:       now.After(c.NotAfter),
+		Timestamp:     now.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,242 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testCert 測試用憑證，parent 為 nil 時自簽
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, notAfter time.Time, parent *testCert) *testCert {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		DNSNames:              []string{cn},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) keyPEM() []byte {
+	der, _ := x509.MarshalECPrivateKey(c.key)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func TestReadCertFile(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test ca", time.Now().Add(365*24*time.Hour), nil)
+	leaf := newTestCert(t, "www.example.com", time.Now().Add(90*24*time.Hour), ca)
+
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		os.WriteFile(p, data, 0644)
+		return p
+	}
+	// 私鑰區塊略過，只取憑證
+	chain := write("chain.pem", append(append(leaf.keyPEM(), leaf.pem()...), ca.pem()...))
+	der := write("leaf.der", leaf.cert.Raw)
+	keyOnly := write("key.pem", leaf.keyPEM())
+	garbage := write("garbage.crt", []byte("not a certificate"))
+
+	certs, err := readCertFile(chain)
+	if err != nil || len(certs) != 2 || certs[0].Subject.CommonName != "www.example.com" || certs[1].Subject.CommonName != "test ca" {
+		t.Fatalf("chain: %d certs, err %v", len(certs), err)
+	}
+	certs, err = readCertFile(der)
+	if err != nil || len(certs) != 1 || !certs[0].Equal(leaf.cert) {
+		t.Fatalf("der: %d certs, err %v", len(certs), err)
+	}
+	if _, err := readCertFile(keyOnly); err == nil {
+		t.Error("key only file should fail")
+	}
+	if _, err := readCertFile(garbage); err == nil {
+		t.Error("garbage file should fail")
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pem", "b.CRT", "server.key", "notes.txt", "nested/c.der"} {
+		p := filepath.Join(dir, "certs", name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, nil, 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "explicit.txt"), nil, 0644)
+
+	got := expandPaths([]string{
+		filepath.Join(dir, "certs"),
+		filepath.Join(dir, "explicit.txt"), // 直接指定的檔案不檢查副檔名
+		filepath.Join(dir, "certs", "*.pem"),
+		filepath.Join(dir, "missing", "*.pem"),
+	})
+	want := []string{
+		filepath.Join(dir, "certs", "a.pem"),
+		filepath.Join(dir, "certs", "b.CRT"),
+		filepath.Join(dir, "certs", "nested", "c.der"),
+		filepath.Join(dir, "explicit.txt"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("path %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		expires time.Duration
+		days    int
+		event   string
+		expired bool
+	}{
+		{"ok", 60 * 24 * time.Hour, 60, EventOK, false},
+		{"warning", 20 * 24 * time.Hour, 20, EventWarning, false},
+		{"critical", 3 * 24 * time.Hour, 3, EventCritical, false},
+		{"expired", -36 * time.Hour, -2, EventCritical, true}, // 無條件捨去
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCert(t, "www.example.com", time.Now().Add(tt.expires+time.Hour), nil)
+			data := describe(c.cert, 30, 7)
+			if data.Event != tt.event || data.Expired != tt.expired {
+				t.Errorf("event %s expired %v, want %s %v", data.Event, data.Expired, tt.event, tt.expired)
+			}
+			if data.DaysRemaining != tt.days {
+				t.Errorf("days = %d, want %d", data.DaysRemaining, tt.days)
+			}
+			if len(data.SANs) != 2 || data.SANs[0] != "www.example.com" || data.SANs[1] != "127.0.0.1" {
+				t.Errorf("sans = %v", data.SANs)
+			}
+		})
+	}
+}
+
+// tlsServer 以指定憑證鏈完成 handshake 的本機 TLS server
+func tlsServer(t *testing.T, leaf *testCert, chain ...*testCert) string {
+	t.Helper()
+	cert := tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.cert.Raw)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.(*tls.Conn).Handshake()
+			c.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestScanEndpoint(t *testing.T) {
+	// 自簽 CA 簽發、即將到期的憑證也要能取得
+	ca := newTestCert(t, "test ca", time.Now().Add(365*24*time.Hour), nil)
+	leaf := newTestCert(t, "localhost", time.Now().Add(3*24*time.Hour+time.Hour), ca)
+	addr := tlsServer(t, leaf, ca)
+
+	// 取得一個已關閉的埠作為無法連線的端點
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := ln.Addr().String()
+	ln.Close()
+
+	lines := scan(context.Background(), config.CertModule{
+		Endpoints: []string{addr, closed},
+		Timeout:   2,
+	}, &service.HostUpdater{})
+	if len(lines) != 3 {
+		t.Fatalf("got %d records, want 3", len(lines))
+	}
+
+	var server, issuer CertJSON
+	json.Unmarshal(lines[0], &server)
+	json.Unmarshal(lines[1], &issuer)
+	if server.Source != SourceEndpoint || server.Target != addr || server.Position != 0 || server.Event != EventCritical || server.DaysRemaining != 3 {
+		t.Errorf("server cert = %+v", server)
+	}
+	if issuer.Position != 1 || issuer.Event != EventOK || issuer.Subject != "CN=test ca" {
+		t.Errorf("issuer cert = %+v", issuer)
+	}
+
+	var fail ErrorJSON
+	json.Unmarshal(lines[2], &fail)
+	if fail.Event != EventError || fail.Target != closed || fail.Error == "" {
+		t.Errorf("unreachable endpoint = %+v", fail)
+	}
+}
+
+func TestScanFiles(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestCert(t, "www.example.com", time.Now().Add(20*24*time.Hour+time.Hour), nil)
+	os.WriteFile(filepath.Join(dir, "server.pem"), c.pem(), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("broken"), 0644)
+
+	lines := scan(context.Background(), config.CertModule{Paths: []string{dir}}, &service.HostUpdater{})
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want 1", len(lines))
+	}
+	var data CertJSON
+	json.Unmarshal(lines[0], &data)
+	// 未設定時使用預設的 warn_days / critical_days
+	if data.Source != SourceFile || data.Target != filepath.Join(dir, "server.pem") || data.Event != EventWarning {
+		t.Errorf("file cert = %+v", data)
+	}
+}
@@ -0,0 +1,144 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+// 目錄中只讀取這些副檔名，避免把私鑰或其他檔案當成憑證
+var certExts = map[string]bool{
+	".pem":  true,
+	".crt":  true,
+	".cer":  true,
+	".cert": true,
+	".der":  true,
+}
+
+// 超過此大小的檔案不是憑證
+const maxCertFile = 1 << 20
+
+// expandPaths 展開 glob；直接指定的檔案一律讀取，目錄則遞迴找出憑證副檔名的檔案
+func expandPaths(patterns []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			utils.Log.Error("[Cert] invalid glob %s: %v", pattern, err)
+			continue
+		}
+		for _, m := range matches {
+			fi, err := os.Stat(m)
+			if err != nil {
+				continue
+			}
+			if !fi.IsDir() {
+				add(m)
+				continue
+			}
+			filepath.WalkDir(m, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				if certExts[strings.ToLower(filepath.Ext(p))] {
+					add(p)
+				}
+				return nil
+			})
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// readCertFile 讀取 PEM（可包含多張憑證）或 DER 格式的憑證檔
+func readCertFile(path string) ([]*x509.Certificate, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxCertFile {
+		return nil, errors.New("file too large")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	rest := data
+	foundPEM := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		foundPEM = true
+		// 略過私鑰、CSR 等其他區塊
+		if block.Type != "CERTIFICATE" && block.Type != "TRUSTED CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+
+	if !foundPEM {
+		return x509.ParseCertificates(data)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// fetchEndpoint 與 host:port 進行 TLS 握手並取得對方送出的憑證鏈
+// 只關心到期日，因此不驗證憑證（自簽或已過期的憑證也要能回報）
+func fetchEndpoint(ctx context.Context, endpoint string, timeout time.Duration) ([]*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d := tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := d.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate presented")
+	}
+	return certs, nil
+}
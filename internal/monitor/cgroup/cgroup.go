@@ -0,0 +1,339 @@
+package cgroup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/container"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "CGROUP"
+
+const defaultRoot = "/sys/fs/cgroup"
+
+// CgroupStat 對應每個 cgroup 的 JSON
+type CgroupStat struct {
+	Path          string            `json:"Path"` // 相對於 root，例如 /system.slice/nginx.service
+	ContainerID   string            `json:"ContainerID"`
+	ContainerName string            `json:"ContainerName"` // 以下需啟用 container 模組
+	Image         string            `json:"Image"`
+	State         string            `json:"State"`
+	Labels        map[string]string `json:"Labels"`
+	Runtime       string            `json:"Runtime"`  // docker / containerd / crio / podman
+	Slice         string            `json:"Slice"`    // systemd slice
+	Unit          string            `json:"Unit"`     // systemd service / scope
+	CPUUsage      float64           `json:"CPUUsage"` // %，100 表示一顆核心
+	CPUUsageUsec  uint64            `json:"CPUUsageUsec"`
+	CPUUserUsec   uint64            `json:"CPUUserUsec"`
+	CPUSystemUsec uint64            `json:"CPUSystemUsec"`
+	NrPeriods     uint64            `json:"NrPeriods"`
+	NrThrottled   uint64            `json:"NrThrottled"`
+	ThrottledUsec uint64            `json:"ThrottledUsec"`
+	MemoryCurrent uint64            `json:"MemoryCurrent"` // bytes
+	MemoryMax     uint64            `json:"MemoryMax"`     // bytes，0 表示無上限
+	MemoryHigh    uint64            `json:"MemoryHigh"`    // memory.events high
+	MemoryMaxHit  uint64            `json:"MemoryMaxHit"`  // memory.events max
+	OOM           uint64            `json:"OOM"`
+	OOMKill       uint64            `json:"OOMKill"`
+	IOReadBytes   uint64            `json:"IOReadBytes"`
+	IOWriteBytes  uint64            `json:"IOWriteBytes"`
+	IOReadRate    uint64            `json:"IOReadRate"`  // B/s
+	IOWriteRate   uint64            `json:"IOWriteRate"` // B/s
+	PidsCurrent   uint64            `json:"PidsCurrent"`
+	Timestamp     string            `json:"Timestamp"`
+}
+
+// cgroupSample 上一輪的讀數與讀取時間，速率以實際經過的時間計算（ticker 可能延遲或漏掉）
+type cgroupSample struct {
+	at    time.Time
+	stats map[string]CgroupStat
+}
+
+// CgroupJSON 對應整個 JSON
+type CgroupJSON struct {
+	Host     service.HostInfo `json:"Host"`
+	Category string           `json:"Category"`
+	Cgroups  []CgroupStat     `json:"Cgroups"`
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Cgroup] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		root := cfg.Cgroup.Root
+		if root == "" {
+			root = defaultRoot
+		}
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		ticker := time.NewTicker(time.Duration(cfg.Cgroup.Interval) * time.Second)
+		defer ticker.Stop()
+
+		var prev cgroupSample
+
+		for {
+			select {
+			case <-ticker.C:
+				var cgroupData []byte
+				prev, cgroupData = monitorCgroup(root, cfg.Cgroup.MaxDepth, prev, time.Now(), host)
+				if len(cgroupData) > 0 {
+					logger.Write(cgroupData)
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Cgroup] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+func monitorCgroup(root string, maxDepth int, prev cgroupSample, at time.Time, host *service.HostUpdater) (cgroupSample, []byte) {
+	// 1️⃣ 走訪 cgroup 樹，只收有 cpu.stat 的目錄
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// cgroup 可能在走訪過程中被刪除
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		if maxDepth > 0 && rel != "." && strings.Count(filepath.ToSlash(rel), "/")+1 > maxDepth {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(p, "cpu.stat")); err == nil {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Log.Error("[Cgroup] 無法走訪 %s: %v", root, err)
+		return prev, nil
+	}
+
+	now := at.Format(time.RFC3339)
+	elapsed := at.Sub(prev.at).Seconds()
+	current := make(map[string]CgroupStat)
+	stats := make([]CgroupStat, 0, len(dirs))
+
+	for _, dir := range dirs {
+		rel, _ := filepath.Rel(root, dir)
+		if rel == "." {
+			rel = "/"
+		} else {
+			rel = "/" + filepath.ToSlash(rel)
+		}
+
+		st := readCgroup(dir)
+		st.Path = rel
+		st.Timestamp = now
+		mapCgroup(&st)
+		enrichCgroup(&st)
+
+		// 2️⃣ 計算速率（若有 prev）
+		if p, ok := prev.stats[rel]; ok && elapsed > 0 {
+			if st.CPUUsageUsec >= p.CPUUsageUsec {
+				st.CPUUsage = float64(st.CPUUsageUsec-p.CPUUsageUsec) / (elapsed * 1e6) * 100
+			}
+			if st.IOReadBytes >= p.IOReadBytes {
+				st.IOReadRate = uint64(float64(st.IOReadBytes-p.IOReadBytes) / elapsed)
+			}
+			if st.IOWriteBytes >= p.IOWriteBytes {
+				st.IOWriteRate = uint64(float64(st.IOWriteBytes-p.IOWriteBytes) / elapsed)
+			}
+		}
+
+		current[rel] = st
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Path < stats[j].Path
+	})
+
+	data := CgroupJSON{
+		Host:     host.Get(),
+		Category: Category,
+		Cgroups:  stats,
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("[Cgroup] %d cgroups", len(stats))
+
+	return cgroupSample{at: at, stats: current}, b
+}
+
+// readCgroup 讀取單一 cgroup 目錄下的統計檔，缺少的控制器會維持 0
+func readCgroup(dir string) CgroupStat {
+	var st CgroupStat
+
+	cpu := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	st.CPUUsageUsec = cpu["usage_usec"]
+	st.CPUUserUsec = cpu["user_usec"]
+	st.CPUSystemUsec = cpu["system_usec"]
+	st.NrPeriods = cpu["nr_periods"]
+	st.NrThrottled = cpu["nr_throttled"]
+	st.ThrottledUsec = cpu["throttled_usec"]
+
+	st.MemoryCurrent, _ = readUint(filepath.Join(dir, "memory.current"))
+	st.MemoryMax, _ = readUint(filepath.Join(dir, "memory.max"))
+
+	events := readKeyValues(filepath.Join(dir, "memory.events"))
+	st.MemoryHigh = events["high"]
+	st.MemoryMaxHit = events["max"]
+	st.OOM = events["oom"]
+	st.OOMKill = events["oom_kill"]
+
+	st.IOReadBytes, st.IOWriteBytes = readIOStat(filepath.Join(dir, "io.stat"))
+	st.PidsCurrent, _ = readUint(filepath.Join(dir, "pids.current"))
+
+	return st
+}
+
+// readKeyValues 解析 "key value" 每行一組的檔案（cpu.stat、memory.events）
+func readKeyValues(path string) map[string]uint64 {
+	out := make(map[string]uint64)
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			out[fields[0]] = v
+		}
+	}
+	return out
+}
+
+// readUint 讀取單一數值檔，"max" 視為 0（無上限）
+func readUint(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(b))
+	if s == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// readIOStat 加總 io.stat 所有裝置的 rbytes / wbytes
+func readIOStat(path string) (uint64, uint64) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	var rbytes, wbytes uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0
+		for _, kv := range strings.Fields(scanner.Text()) {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch k {
+			case "rbytes":
+				rbytes += n
+			case "wbytes":
+				wbytes += n
+			}
+		}
+	}
+	return rbytes, wbytes
+}
+
+// ------------------------- cgroup → container / systemd -------------------------
+
+// enrichCgroup 從 container runtime 快取補上名稱、映像檔、狀態與標籤
+func enrichCgroup(st *CgroupStat) {
+	if st.ContainerID == "" {
+		return
+	}
+	if m, ok := container.Lookup(st.ContainerID); ok {
+		st.ContainerName = m.Name
+		st.Image = m.Image
+		st.State = m.State
+		st.Labels = m.Labels
+	}
+}
+
+var containerIDPattern = regexp.MustCompile(`([0-9a-f]{64})`)
+
+// 依 scope 名稱前綴判斷 container runtime
+var runtimePrefixes = []struct {
+	prefix  string
+	runtime string
+}{
+	{"docker-", "docker"},
+	{"cri-containerd-", "containerd"},
+	{"crio-", "crio"},
+	{"libpod-", "podman"},
+}
+
+// mapCgroup 從路徑推測 container ID 以及 systemd slice / unit
+func mapCgroup(st *CgroupStat) {
+	parts := strings.Split(strings.Trim(st.Path, "/"), "/")
+
+	for _, p := range parts {
+		switch {
+		case strings.HasSuffix(p, ".slice"):
+			st.Slice = p
+		case strings.HasSuffix(p, ".service"), strings.HasSuffix(p, ".scope"):
+			st.Unit = p
+		}
+	}
+
+	// 由最深層往上找 container ID，例如 docker-<id>.scope 或 /docker/<id>
+	for i := len(parts) - 1; i >= 0; i-- {
+		id := containerIDPattern.FindString(parts[i])
+		if id == "" {
+			continue
+		}
+		st.ContainerID = id
+		for _, rp := range runtimePrefixes {
+			if strings.HasPrefix(parts[i], rp.prefix) {
+				st.Runtime = rp.runtime
+			}
+		}
+		if st.Runtime == "" && i > 0 {
+			switch parts[i-1] {
+			case "docker":
+				st.Runtime = "docker"
+			case "libpod_parent":
+				st.Runtime = "podman"
+			}
+		}
+		return
+	}
+}
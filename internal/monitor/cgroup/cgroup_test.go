@@ -0,0 +1,160 @@
+package cgroup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+const (
+	dockerID     = "3f2a9c1e5b7d4f6a8c0e2b4d6f8a1c3e5b7d9f0a2c4e6b8d0f1a3c5e7b9d1f2a"
+	containerdID = "4e2a9c1e5b7d4f6a8c0e2b4d6f8a1c3e5b7d9f0a2c4e6b8d0f1a3c5e7b9d1f2a"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fixture 複製 testdata/cgroup，測試可以修改其中的計數
+func fixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.CopyFS(root, os.DirFS("testdata/cgroup")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func collect(t *testing.T, root string, maxDepth int, prev cgroupSample, at time.Time) (cgroupSample, map[string]CgroupStat) {
+	t.Helper()
+	sample, b := monitorCgroup(root, maxDepth, prev, at, &service.HostUpdater{})
+	var data CgroupJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]CgroupStat)
+	for _, st := range data.Cgroups {
+		out[st.Path] = st
+	}
+	return sample, out
+}
+
+func TestMonitorCgroupParse(t *testing.T) {
+	_, stats := collect(t, "testdata/cgroup", 0, cgroupSample{}, time.Now())
+
+	// 沒有 cpu.stat 的目錄不是 cgroup
+	want := []string{
+		"/",
+		"/kubepods.slice",
+		"/kubepods.slice/kubepods-burstable.slice",
+		"/kubepods.slice/kubepods-burstable.slice/cri-containerd-" + containerdID + ".scope",
+		"/system.slice",
+		"/system.slice/docker-" + dockerID + ".scope",
+		"/system.slice/nginx.service",
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d cgroups, want %d: %v", len(stats), len(want), stats)
+	}
+	for _, p := range want {
+		if _, ok := stats[p]; !ok {
+			t.Errorf("missing %s", p)
+		}
+	}
+
+	nginx := stats["/system.slice/nginx.service"]
+	checks := map[string][2]uint64{
+		"CPUUsageUsec":  {nginx.CPUUsageUsec, 2000000},
+		"CPUUserUsec":   {nginx.CPUUserUsec, 1500000},
+		"CPUSystemUsec": {nginx.CPUSystemUsec, 500000},
+		"NrPeriods":     {nginx.NrPeriods, 100},
+		"NrThrottled":   {nginx.NrThrottled, 7},
+		"ThrottledUsec": {nginx.ThrottledUsec, 12345},
+		"MemoryCurrent": {nginx.MemoryCurrent, 52428800},
+		"MemoryMax":     {nginx.MemoryMax, 268435456},
+		"MemoryHigh":    {nginx.MemoryHigh, 3},
+		"MemoryMaxHit":  {nginx.MemoryMaxHit, 2},
+		"OOM":           {nginx.OOM, 1},
+		"OOMKill":       {nginx.OOMKill, 1},
+		"IOReadBytes":   {nginx.IOReadBytes, 1048576 + 1024},
+		"IOWriteBytes":  {nginx.IOWriteBytes, 2097152},
+		"PidsCurrent":   {nginx.PidsCurrent, 12},
+	}
+	for name, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("nginx %s = %d, want %d", name, c[0], c[1])
+		}
+	}
+	if nginx.Slice != "system.slice" || nginx.Unit != "nginx.service" || nginx.ContainerID != "" {
+		t.Errorf("nginx mapping = slice %q unit %q container %q", nginx.Slice, nginx.Unit, nginx.ContainerID)
+	}
+	// 第一輪沒有基準，不計算速率
+	if nginx.CPUUsage != 0 || nginx.IOReadRate != 0 {
+		t.Errorf("rates on first sample: cpu %v io %d", nginx.CPUUsage, nginx.IOReadRate)
+	}
+
+	docker := stats["/system.slice/docker-"+dockerID+".scope"]
+	if docker.ContainerID != dockerID || docker.Runtime != "docker" || docker.MemoryMax != 0 {
+		t.Errorf("docker = id %q runtime %q memory.max %d", docker.ContainerID, docker.Runtime, docker.MemoryMax)
+	}
+	cri := stats["/kubepods.slice/kubepods-burstable.slice/cri-containerd-"+containerdID+".scope"]
+	if cri.ContainerID != containerdID || cri.Runtime != "containerd" || cri.Slice != "kubepods-burstable.slice" {
+		t.Errorf("containerd = id %q runtime %q slice %q", cri.ContainerID, cri.Runtime, cri.Slice)
+	}
+}
+
+func TestMonitorCgroupMaxDepth(t *testing.T) {
+	_, stats := collect(t, "testdata/cgroup", 1, cgroupSample{}, time.Now())
+	if len(stats) != 3 {
+		t.Errorf("max_depth 1: got %d cgroups, want /, /kubepods.slice, /system.slice", len(stats))
+	}
+}
+
+func TestMonitorCgroupRates(t *testing.T) {
+	root := fixture(t)
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	prev, _ := collect(t, root, 0, cgroupSample{}, start)
+
+	dir := filepath.Join(root, "system.slice", "nginx.service")
+	// 多用了 1 秒 CPU，多讀 4 MiB、多寫 1 MiB
+	os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 3000000\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "io.stat"), []byte("8:0 rbytes=5243904 wbytes=3145728\n"), 0644)
+
+	// 速率依實際經過的 4 秒計算，與設定的 interval 無關
+	_, stats := collect(t, root, 0, prev, start.Add(4*time.Second))
+	nginx := stats["/system.slice/nginx.service"]
+	if nginx.CPUUsage != 25 {
+		t.Errorf("CPUUsage = %v, want 25", nginx.CPUUsage)
+	}
+	if nginx.IOReadRate != 1<<20 {
+		t.Errorf("IOReadRate = %d, want %d", nginx.IOReadRate, 1<<20)
+	}
+	if nginx.IOWriteRate != 1<<18 {
+		t.Errorf("IOWriteRate = %d, want %d", nginx.IOWriteRate, 1<<18)
+	}
+	// 沒有變化的 cgroup 速率為 0
+	if st := stats["/system.slice"]; st.CPUUsage != 0 {
+		t.Errorf("unchanged cgroup CPUUsage = %v", st.CPUUsage)
+	}
+}
+
+func TestMonitorCgroupCounterReset(t *testing.T) {
+	root := fixture(t)
+	start := time.Now()
+	prev, _ := collect(t, root, 0, cgroupSample{}, start)
+
+	// 計數變小（cgroup 重建）時不輸出負的速率
+	os.WriteFile(filepath.Join(root, "system.slice", "nginx.service", "cpu.stat"), []byte("usage_usec 10\n"), 0644)
+	_, stats := collect(t, root, 0, prev, start.Add(time.Second))
+	if st := stats["/system.slice/nginx.service"]; st.CPUUsage != 0 {
+		t.Errorf("CPUUsage after reset = %v, want 0", st.CPUUsage)
+	}
+}
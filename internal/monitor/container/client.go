@@ -0,0 +1,111 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// dockerClient 透過 unix socket 呼叫 Docker Engine API
+type dockerClient struct {
+	http *http.Client // 一般查詢，有逾時
+	long *http.Client // /events 長連線，不設逾時
+}
+
+// dockerContainer 對應 GET /containers/json 的欄位
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	Labels map[string]string `json:"Labels"`
+	State  string            `json:"State"`
+}
+
+// dockerEvent 對應 GET /events 的每一筆事件
+type dockerEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+func newDockerClient(socket string) *dockerClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &dockerClient{
+		http: &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		long: &http.Client{Transport: transport},
+	}
+}
+
+// host 部分不會被使用，實際連線走 unix socket
+const dockerBaseURL = "http://docker"
+
+func (c *dockerClient) listContainers(ctx context.Context) ([]dockerContainer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dockerBaseURL+"/containers/json?all=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list containers: %s", resp.Status)
+	}
+
+	var out []dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// streamEvents 持續讀取 container 生命週期事件，直到連線中斷或 ctx 取消
+func (c *dockerClient) streamEvents(ctx context.Context, handle func(dockerEvent)) error {
+	filters, _ := json.Marshal(map[string][]string{
+		"type":  {"container"},
+		"event": lifecycleActions,
+	})
+	u := dockerBaseURL + "/events?filters=" + url.QueryEscape(string(filters))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.long.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("events: %s", resp.Status)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var ev dockerEvent
+		if err := dec.Decode(&ev); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		handle(ev)
+	}
+}
@@ -0,0 +1,208 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "CONTAINER"
+
+const defaultSocket = "/var/run/docker.sock"
+
+// 事件串流中斷後重新連線的間隔
+const eventsRetryTime = 10 * time.Second
+
+// 事件種類，為 eventPrefix 加上大寫的 action
+const (
+	eventPrefix = "CONTAINER_"
+	EventStart  = eventPrefix + "START"
+	EventStop   = eventPrefix + "STOP"
+	EventDie    = eventPrefix + "DIE"
+	EventOOM    = eventPrefix + "OOM"
+)
+
+// 關注的 container 生命週期事件
+var lifecycleActions = []string{"start", "stop", "die", "oom"}
+
+// Meta container 的名稱、映像檔、標籤與狀態
+type Meta struct {
+	ID     string            `json:"ID"`
+	Name   string            `json:"Name"`
+	Image  string            `json:"Image"`
+	Labels map[string]string `json:"Labels"`
+	State  string            `json:"State"`
+}
+
+// EventJSON container 生命週期事件
+type EventJSON struct {
+	Host        service.HostInfo  `json:"Host"`
+	Category    string            `json:"Category"`
+	Event       string            `json:"Event"` // CONTAINER_START / STOP / DIE / OOM
+	ContainerID string            `json:"ContainerID"`
+	Name        string            `json:"Name"`
+	Image       string            `json:"Image"`
+	Labels      map[string]string `json:"Labels"`
+	ExitCode    *int              `json:"ExitCode"` // 只有 die 事件有值
+	Timestamp   string            `json:"Timestamp"`
+}
+
+// registry 以 container ID 為 key 的快取，給其他收集器查詢
+var registry = struct {
+	mu sync.RWMutex
+	m  map[string]Meta
+}{m: make(map[string]Meta)}
+
+// Lookup 依完整 container ID 取得 Meta；未啟用或找不到時回傳 false
+func Lookup(id string) (Meta, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	m, ok := registry.m[id]
+	return m, ok
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	socket := cfg.Container.Socket
+	if socket == "" {
+		socket = defaultSocket
+	}
+	client := newDockerClient(socket)
+
+	startWatch(ctx, cfg, client, host)
+	startRefresh(ctx, cfg, client)
+}
+
+// startWatch 在背景訂閱 container 事件
+func startWatch(ctx context.Context, cfg config.MonitorConfig, client *dockerClient, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Container] goroutine panic: %v", r)
+				startWatch(ctx, cfg, client, host)
+			}
+		}()
+		watchEvents(ctx, cfg, client, host)
+	}()
+}
+
+// startRefresh 定期重新整理 container 清單
+func startRefresh(ctx context.Context, cfg config.MonitorConfig, client *dockerClient) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Container] goroutine panic: %v", r)
+				startRefresh(ctx, cfg, client)
+			}
+		}()
+
+		ticker := time.NewTicker(time.Duration(cfg.Container.Interval) * time.Second)
+		defer ticker.Stop()
+
+		refresh(ctx, client)
+		for {
+			select {
+			case <-ticker.C:
+				refresh(ctx, client)
+			case <-ctx.Done():
+				utils.Log.Info("[Container] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// refresh 重新取得完整 container 清單並取代快取
+func refresh(ctx context.Context, client *dockerClient) {
+	list, err := client.listContainers(ctx)
+	if err != nil {
+		utils.Log.Debug("[Container] 無法取得 container 清單: %v", err)
+		return
+	}
+
+	m := make(map[string]Meta, len(list))
+	for _, c := range list {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		m[c.ID] = Meta{
+			ID:     c.ID,
+			Name:   name,
+			Image:  c.Image,
+			Labels: c.Labels,
+			State:  c.State,
+		}
+	}
+
+	registry.mu.Lock()
+	registry.m = m
+	registry.mu.Unlock()
+	utils.Log.Debug("[Container] %d containers", len(m))
+}
+
+// watchEvents 訂閱 /events，斷線後自動重連
+func watchEvents(ctx context.Context, cfg config.MonitorConfig, client *dockerClient, host *service.HostUpdater) {
+	logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+
+	for {
+		err := client.streamEvents(ctx, func(ev dockerEvent) {
+			if ev.Type != "container" || !slices.Contains(lifecycleActions, ev.Action) {
+				return
+			}
+
+			b := eventJSON(ev, host)
+			utils.Log.Debug("%s", string(b))
+			logger.Write(b)
+
+			// 狀態改變，立即更新快取
+			refresh(ctx, client)
+		})
+		if err != nil && ctx.Err() == nil {
+			utils.Log.Debug("[Container] 事件串流中斷: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryTime):
+		}
+	}
+}
+
+func eventJSON(ev dockerEvent, host *service.HostUpdater) []byte {
+	attrs := ev.Actor.Attributes
+
+	// Attributes 同時包含 name、image、exitCode 與所有 label
+	labels := make(map[string]string)
+	for k, v := range attrs {
+		switch k {
+		case "name", "image", "exitCode", "signal":
+			continue
+		}
+		labels[k] = v
+	}
+
+	data := EventJSON{
+		Host:        host.Get(),
+		Category:    Category,
+		Event:       eventPrefix + strings.ToUpper(ev.Action),
+		ContainerID: ev.Actor.ID,
+		Name:        attrs["name"],
+		Image:       attrs["image"],
+		Labels:      labels,
+		Timestamp:   time.Unix(0, ev.TimeNano).Format(time.RFC3339),
+	}
+	if code, err := strconv.Atoi(attrs["exitCode"]); err == nil {
+		data.ExitCode = &code
+	}
+
+	b, _ := json.Marshal(data)
+	return b
+}
@@ -0,0 +1,175 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+const (
+	webID   = "1111111111111111111111111111111111111111111111111111111111111111"
+	batchID = "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// stubDocker 在 unix socket 上模擬 Docker Engine API
+type stubDocker struct {
+	socket     string
+	containers atomic.Value // []dockerContainer
+	lists      atomic.Int32
+	filters    atomic.Value // string
+	events     []string
+}
+
+func newStubDocker(t *testing.T, events ...string) *stubDocker {
+	t.Helper()
+	d := &stubDocker{socket: filepath.Join(t.TempDir(), "docker.sock"), events: events}
+	d.containers.Store([]dockerContainer{
+		{ID: webID, Names: []string{"/web"}, Image: "nginx:1.27", Labels: map[string]string{"app": "web"}, State: "running"},
+	})
+
+	ln, err := net.Listen("unix", d.socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		d.lists.Add(1)
+		json.NewEncoder(w).Encode(d.containers.Load())
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		d.filters.Store(r.URL.Query().Get("filters"))
+		for _, ev := range d.events {
+			fmt.Fprintln(w, ev)
+			w.(http.Flusher).Flush()
+		}
+		// 保持連線直到 client 結束，模擬長連線
+		<-r.Context().Done()
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return d
+}
+
+func TestRefreshFromStub(t *testing.T) {
+	d := newStubDocker(t)
+	refresh(context.Background(), newDockerClient(d.socket))
+
+	m, ok := Lookup(webID)
+	if !ok {
+		t.Fatal("container not in registry")
+	}
+	if m.Name != "web" || m.Image != "nginx:1.27" || m.State != "running" || m.Labels["app"] != "web" {
+		t.Errorf("meta = %+v", m)
+	}
+
+	// 清單整個取代，已刪除的 container 不再查得到
+	d.containers.Store([]dockerContainer{{ID: batchID, Names: []string{"/batch"}, State: "exited"}})
+	refresh(context.Background(), newDockerClient(d.socket))
+	if _, ok := Lookup(webID); ok {
+		t.Error("removed container still in registry")
+	}
+	if m, ok := Lookup(batchID); !ok || m.Name != "batch" {
+		t.Errorf("batch = %+v, %v", m, ok)
+	}
+}
+
+func TestRefreshUnavailable(t *testing.T) {
+	d := newStubDocker(t)
+	refresh(context.Background(), newDockerClient(d.socket))
+
+	// daemon 無法連線時保留上次的清單
+	refresh(context.Background(), newDockerClient(filepath.Join(t.TempDir(), "missing.sock")))
+	if _, ok := Lookup(webID); !ok {
+		t.Error("registry cleared after a failed refresh")
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC).UnixNano()
+	d := newStubDocker(t,
+		fmt.Sprintf(`{"Type":"container","Action":"start","Actor":{"ID":"%s","Attributes":{"name":"batch","image":"busybox","team":"ops"}},"timeNano":%d}`, batchID, ts),
+		fmt.Sprintf(`{"Type":"container","Action":"exec_start","Actor":{"ID":"%s","Attributes":{"name":"batch"}},"timeNano":%d}`, batchID, ts),
+		fmt.Sprintf(`{"Type":"container","Action":"die","Actor":{"ID":"%s","Attributes":{"name":"batch","image":"busybox","exitCode":"137","team":"ops"}},"timeNano":%d}`, batchID, ts),
+	)
+
+	var mu sync.Mutex
+	var got []EventJSON
+	done := make(chan struct{})
+	utils.AddWriteHook(func(category string, line []byte) {
+		if category != Category {
+			return
+		}
+		var ev EventJSON
+		json.Unmarshal(line, &ev)
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, ev)
+		if len(got) == 2 {
+			close(done)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := config.MonitorConfig{Data: t.TempDir(), Days: 1}
+	cfg.Container.Interval = 3600
+	cfg.Container.Socket = d.socket
+	Start(ctx, cfg, &service.HostUpdater{})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	start, die := got[0], got[1]
+	if start.Event != "CONTAINER_START" || start.ContainerID != batchID || start.Name != "batch" || start.Image != "busybox" || start.ExitCode != nil {
+		t.Errorf("start = %+v", start)
+	}
+	if start.Labels["team"] != "ops" || start.Labels["name"] != "" || start.Labels["image"] != "" {
+		t.Errorf("start labels = %v", start.Labels)
+	}
+	if start.Timestamp != time.Unix(0, ts).Format(time.RFC3339) {
+		t.Errorf("timestamp = %s", start.Timestamp)
+	}
+	if die.Event != "CONTAINER_DIE" || die.ExitCode == nil || *die.ExitCode != 137 || die.Labels["exitCode"] != "" {
+		t.Errorf("die = %+v", die)
+	}
+
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(d.filters.Load().(string)), &filters); err != nil || filters["type"][0] != "container" {
+		t.Errorf("filters = %v, %v", filters, err)
+	}
+	// 每個生命週期事件寫出後都重新整理清單（加上啟動時的一次）
+	deadline := time.Now().Add(5 * time.Second)
+	for d.lists.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := d.lists.Load(); n < 3 {
+		t.Errorf("container list fetched %d times, want at least 3", n)
+	}
+}
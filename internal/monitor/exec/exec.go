@@ -0,0 +1,285 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "EXEC"
+
+// 輸出格式
+const (
+	FormatJSON       = "json"
+	FormatJSONLines  = "jsonl"
+	FormatNagios     = "nagios"
+	FormatPrometheus = "prometheus"
+)
+
+const EventResult = "EXEC_RESULT"
+
+const (
+	defaultInterval    = 60 // 秒
+	defaultTimeout     = 30 // 秒
+	defaultConcurrency = 4
+
+	maxStdout = 4 << 20  // 超過的輸出會被截斷
+	maxStderr = 64 << 10 // 只保留錯誤訊息的開頭
+	waitDelay = 2 * time.Second
+)
+
+// ExecJSON 單次執行的結果；json 格式一次一筆，jsonl 每行一筆
+type ExecJSON struct {
+	Host      service.HostInfo   `json:"Host"`
+	Category  string             `json:"Category"`
+	Event     string             `json:"Event"`
+	Name      string             `json:"Name"`
+	Command   string             `json:"Command"`
+	Format    string             `json:"Format"`
+	Labels    map[string]string  `json:"Labels"`
+	ExitCode  int                `json:"ExitCode"` // 逾時或無法執行為 -1
+	Duration  float64            `json:"Duration"` // 毫秒
+	Success   bool               `json:"Success"`
+	Error     string             `json:"Error"`
+	Data      any                `json:"Data"`     // json / jsonl
+	Status    string             `json:"Status"`   // nagios：OK / WARNING / CRITICAL / UNKNOWN
+	Output    string             `json:"Output"`   // nagios 的文字訊息
+	PerfData  []PerfData         `json:"PerfData"` // nagios
+	Samples   []utils.PromSample `json:"Samples"`  // prometheus
+	Timestamp string             `json:"Timestamp"`
+}
+
+// Start 每個指令各自一個 goroutine，依自己的 interval 執行，同時執行的數量受 concurrency 限制
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+
+	limit := cfg.Exec.Concurrency
+	if limit <= 0 {
+		limit = defaultConcurrency
+	}
+	sem := make(chan struct{}, limit)
+
+	for _, c := range cfg.Exec.Commands {
+		if c.Command == "" {
+			utils.Log.Error("[Exec] %s 設定錯誤: command is empty", c.Name)
+			continue
+		}
+		switch c.Format {
+		case "":
+			c.Format = FormatJSON
+		case FormatJSON, FormatJSONLines, FormatNagios, FormatPrometheus:
+		default:
+			utils.Log.Error("[Exec] %s 設定錯誤: unsupported format %s", c.Name, c.Format)
+			continue
+		}
+		if c.Name == "" {
+			c.Name = c.Command
+		}
+		if c.Interval <= 0 {
+			c.Interval = cfg.Exec.Interval
+		}
+		if c.Interval <= 0 {
+			c.Interval = defaultInterval
+		}
+		if c.Timeout <= 0 {
+			c.Timeout = cfg.Exec.Timeout
+		}
+		if c.Timeout <= 0 {
+			c.Timeout = defaultTimeout
+		}
+		startCommand(ctx, c, sem, host, logger)
+	}
+}
+
+func startCommand(ctx context.Context, c config.ExecCommand, sem chan struct{}, host *service.HostUpdater, logger interface {
+	Write(data any) error
+}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Exec] %s goroutine panic: %v", c.Name, r)
+				startCommand(ctx, c, sem, host, logger)
+			}
+		}()
+
+		ticker := time.NewTicker(time.Duration(c.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case sem <- struct{}{}:
+				for _, line := range runOnce(ctx, c, host) {
+					logger.Write(line)
+				}
+				<-sem
+			case <-ctx.Done():
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				utils.Log.Info("[Exec] %s 已停止", c.Name)
+				return
+			}
+		}
+	}()
+}
+
+// runOnce 執行指令並依格式轉成紀錄
+func runOnce(ctx context.Context, c config.ExecCommand, host *service.HostUpdater) [][]byte {
+	base := ExecJSON{
+		Host:      host.Get(),
+		Category:  Category,
+		Event:     EventResult,
+		Name:      c.Name,
+		Command:   c.Command,
+		Format:    c.Format,
+		Labels:    c.Labels,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if base.Labels == nil {
+		base.Labels = map[string]string{}
+	}
+
+	stdout, stderr, exitCode, dur, runErr := run(ctx, c)
+	base.ExitCode = exitCode
+	base.Duration = float64(dur.Microseconds()) / 1000
+
+	var records []ExecJSON
+	switch c.Format {
+	case FormatJSON:
+		r := base
+		if len(bytes.TrimSpace(stdout)) > 0 {
+			if err := json.Unmarshal(stdout, &r.Data); err != nil {
+				r.Error = "invalid json: " + err.Error()
+			}
+		}
+		records = append(records, r)
+	case FormatJSONLines:
+		for _, line := range bytes.Split(stdout, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			r := base
+			if err := json.Unmarshal(line, &r.Data); err != nil {
+				r.Data = nil
+				r.Error = "invalid json: " + err.Error()
+			}
+			records = append(records, r)
+		}
+		if len(records) == 0 {
+			records = append(records, base)
+		}
+	case FormatNagios:
+		r := base
+		r.Status = nagiosStatus(exitCode)
+		r.Output, r.PerfData = parseNagios(string(stdout))
+		records = append(records, r)
+	case FormatPrometheus:
+		r := base
+		samples, err := utils.ParsePromText(bytes.NewReader(stdout), false)
+		r.Samples = samples
+		if err != nil {
+			r.Error = "invalid prometheus text: " + err.Error()
+		}
+		records = append(records, r)
+	}
+
+	var out [][]byte
+	for _, r := range records {
+		switch {
+		case runErr != nil:
+			r.Error = runErr.Error()
+		case c.Format == FormatNagios:
+			// nagios 以結束碼表示狀態，非 0 不算執行失敗
+			r.Success = r.Error == "" && exitCode >= 0 && exitCode <= 3
+		default:
+			r.Success = r.Error == "" && exitCode == 0
+			if exitCode != 0 && r.Error == "" {
+				r.Error = fmt.Sprintf("exit status %d", exitCode)
+				if msg := bytes.TrimSpace(stderr); len(msg) > 0 {
+					r.Error += ": " + string(msg)
+				}
+			}
+		}
+		if r.PerfData == nil {
+			r.PerfData = []PerfData{}
+		}
+		if r.Samples == nil {
+			r.Samples = []utils.PromSample{}
+		}
+
+		b, err := json.Marshal(r)
+		if err != nil {
+			utils.Log.Error("[Exec] %s marshal fail: %v", c.Name, err)
+			continue
+		}
+		utils.Log.Debug("%s", string(b))
+		out = append(out, b)
+	}
+	return out
+}
+
+// run 執行指令，回傳 stdout、stderr、結束碼與耗時
+// 逾時會終止整個 process group，避免指令產生的子程序殘留
+func run(ctx context.Context, c config.ExecCommand) ([]byte, []byte, int, time.Duration, error) {
+	timeout := time.Duration(c.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := osexec.CommandContext(ctx, c.Command, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = os.Environ()
+	for k, v := range c.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdout := &limitedBuffer{max: maxStdout}
+	stderr := &limitedBuffer{max: maxStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// 子程序仍持有 pipe 時，不要無限等待
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	start := time.Now()
+	err := cmd.Run()
+	dur := time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return stdout.Bytes(), stderr.Bytes(), -1, dur, fmt.Errorf("timeout after %s", timeout)
+	}
+	if err != nil {
+		var exitErr *osexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			return stdout.Bytes(), stderr.Bytes(), exitErr.ExitCode(), dur, nil
+		}
+		return stdout.Bytes(), stderr.Bytes(), -1, dur, err
+	}
+	return stdout.Bytes(), stderr.Bytes(), 0, dur, nil
+}
+
+// limitedBuffer 超過上限的資料直接丟棄，不讓指令因 pipe 阻塞
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,111 @@
+package exec
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PerfData nagios 效能資料 'label'=value[UOM];[warn];[crit];[min];[max]
+type PerfData struct {
+	Label string  `json:"Label"`
+	Value float64 `json:"Value"`
+	Unit  string  `json:"Unit"`
+	Warn  string  `json:"Warn"` // 可能是範圍，例如 10:20
+	Crit  string  `json:"Crit"`
+	Min   string  `json:"Min"`
+	Max   string  `json:"Max"`
+}
+
+func nagiosStatus(code int) string {
+	switch code {
+	case 0:
+		return "OK"
+	case 1:
+		return "WARNING"
+	case 2:
+		return "CRITICAL"
+	}
+	return "UNKNOWN"
+}
+
+// parseNagios 第一行 | 之前是訊息，之後是 perfdata；後續行（long output）也可以在 | 之後接 perfdata
+func parseNagios(out string) (string, []PerfData) {
+	var text []string
+	var perf []PerfData
+	inPerf := false
+	for i, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if inPerf {
+			perf = append(perf, parsePerfData(line)...)
+			continue
+		}
+		msg, pd, found := strings.Cut(line, "|")
+		if i == 0 || msg != "" {
+			text = append(text, strings.TrimSpace(msg))
+		}
+		if found {
+			perf = append(perf, parsePerfData(pd)...)
+			// long output 中出現 | 之後，剩下的行都是 perfdata
+			inPerf = i > 0
+		}
+	}
+	return strings.Join(text, "\n"), perf
+}
+
+func parsePerfData(s string) []PerfData {
+	var out []PerfData
+	for _, item := range splitPerfData(s) {
+		label, rest, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		label = strings.Trim(label, "'")
+		parts := strings.Split(rest, ";")
+
+		// 數值後面接單位，例如 12.5ms、80%、1024B
+		raw := parts[0]
+		end := len(raw)
+		for end > 0 && !strings.ContainsRune("0123456789.", rune(raw[end-1])) {
+			end--
+		}
+		v, err := strconv.ParseFloat(raw[:end], 64)
+		if err != nil {
+			// U 表示無法取得數值
+			continue
+		}
+
+		pd := PerfData{Label: label, Value: v, Unit: raw[end:]}
+		fields := []*string{&pd.Warn, &pd.Crit, &pd.Min, &pd.Max}
+		for i, f := range fields {
+			if i+1 < len(parts) {
+				*f = parts[i+1]
+			}
+		}
+		out = append(out, pd)
+	}
+	return out
+}
+
+// splitPerfData 以空白分隔，但保留單引號內的空白
+func splitPerfData(s string) []string {
+	var out []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			b.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if b.Len() > 0 {
+				out = append(out, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		out = append(out, b.String())
+	}
+	return out
+}
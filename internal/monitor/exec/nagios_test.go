@@ -0,0 +1,43 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPerfData(t *testing.T) {
+	got := splitPerfData(`'disk usage /'=80%;85;95  load1=0.5	'it''s'=1`)
+	want := []string{`'disk usage /'=80%;85;95`, "load1=0.5", `'it''s'=1`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPerfData = %q, want %q", got, want)
+	}
+}
+
+func TestParsePerfData(t *testing.T) {
+	got := parsePerfData(`'disk usage /'=80%;85;95;0;100 time=12.5ms;;;0 size=1024B load1=0.5;1:2;@3:4 missing=U;1;2 bad count=7c`)
+	want := []PerfData{
+		{Label: "disk usage /", Value: 80, Unit: "%", Warn: "85", Crit: "95", Min: "0", Max: "100"},
+		{Label: "time", Value: 12.5, Unit: "ms", Min: "0"},
+		{Label: "size", Value: 1024, Unit: "B"},
+		{Label: "load1", Value: 0.5, Warn: "1:2", Crit: "@3:4"},
+		{Label: "count", Value: 7, Unit: "c"},
+	}
+	// U 表示無法取得數值、沒有 = 的項目都略過
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePerfData:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseNagios(t *testing.T) {
+	out := "DISK OK - free space: / 3326 MB | '/'=2643MB;5948;5958;0;5968\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | '/boot'=68MB;88;93;0;98\n" +
+		"'/home'=69357MB;253404;253409;0;253414\n"
+	text, perf := parseNagios(out)
+	if want := "DISK OK - free space: / 3326 MB\n/ 15272 MB (77%);\n/boot 68 MB (69%);"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	if len(perf) != 3 || perf[0].Label != "/" || perf[1].Label != "/boot" || perf[2].Label != "/home" || perf[2].Value != 69357 {
+		t.Errorf("perfdata = %+v", perf)
+	}
+}
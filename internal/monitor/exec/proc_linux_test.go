@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sysprobe/internal/config"
+	"testing"
+	"time"
+)
+
+// running 行程仍存在且不是 zombie（容器內的 init 不一定會回收孤兒程序）
+func running(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	return !strings.HasPrefix(rest, "Z")
+}
+
+func TestRunExitCode(t *testing.T) {
+	stdout, stderr, code, _, err := run(context.Background(), config.ExecCommand{
+		Command: "/bin/sh",
+		Args:    []string{"-c", `echo "$GREETING"; echo oops >&2; exit 3`},
+		Env:     map[string]string{"GREETING": "hello"},
+		Timeout: 5,
+	})
+	if err != nil || code != 3 || string(stdout) != "hello\n" || string(stderr) != "oops\n" {
+		t.Errorf("run = %q, %q, %d, %v", stdout, stderr, code, err)
+	}
+
+	if _, _, code, _, err := run(context.Background(), config.ExecCommand{Command: "/nonexistent/check", Timeout: 5}); err == nil || code != -1 {
+		t.Errorf("missing command = %d, %v", code, err)
+	}
+}
+
+// 逾時時終止整個 process group：背景的 sleep 也要結束，否則它持有 stdout 會拖到 waitDelay
+func TestRunTimeoutKillsProcessGroup(t *testing.T) {
+	start := time.Now()
+	stdout, _, code, _, err := run(context.Background(), config.ExecCommand{
+		Command: "/bin/sh",
+		Args:    []string{"-c", "sleep 60 & echo $!; sleep 60"},
+		Timeout: 1,
+	})
+	elapsed := time.Since(start)
+	if err == nil || !strings.Contains(err.Error(), "timeout") || code != -1 {
+		t.Fatalf("run = %d, %v, want timeout", code, err)
+	}
+	if elapsed >= time.Second+waitDelay {
+		t.Errorf("run took %s, background child kept the pipe open", elapsed)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(stdout)))
+	if err != nil {
+		t.Fatalf("background pid %q: %v", stdout, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for running(pid) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if running(pid) {
+		t.Errorf("background sleep %d still running", pid)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package exec
+
+import (
+	osexec "os/exec"
+)
+
+// setProcessGroup Windows 沒有 process group，逾時只終止指令本身
+func setProcessGroup(cmd *osexec.Cmd) {}
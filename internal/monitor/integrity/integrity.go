@@ -0,0 +1,316 @@
+package integrity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "INTEGRITY"
+
+// 事件種類
+const (
+	EventCreated     = "FILE_CREATED"
+	EventModified    = "FILE_MODIFIED"
+	EventDeleted     = "FILE_DELETED"
+	EventPermChanged = "FILE_PERM_CHANGED"
+)
+
+// Attr 檔案的基準屬性
+type Attr struct {
+	Hash  string `json:"Hash"` // sha256
+	Size  int64  `json:"Size"`
+	Mode  string `json:"Mode"` // 例如 -rw-r--r--
+	UID   int    `json:"UID"`  // Windows 為 -1
+	GID   int    `json:"GID"`  // Windows 為 -1
+	MTime string `json:"MTime"`
+}
+
+// IntegrityJSON 單一檔案的異動事件
+type IntegrityJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Path      string           `json:"Path"`
+	Before    *Attr            `json:"Before"`
+	After     *Attr            `json:"After"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// checker 持有基準並比對異動，只在收集器的 goroutine 內使用
+type checker struct {
+	paths        []string
+	baselinePath string
+	baseline     map[string]Attr
+	host         *service.HostUpdater
+	logger       interface {
+		Write(data any) error
+	}
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		var watcher *watcher
+
+		defer func() {
+			if watcher != nil {
+				watcher.close()
+			}
+			if r := recover(); r != nil {
+				utils.Log.Error("[Integrity] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		c := &checker{
+			paths:        cfg.Integrity.Paths,
+			baselinePath: cfg.Data + "/integrity.json",
+			host:         host,
+			logger:       utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days),
+		}
+
+		// 沒有基準時先建立，不輸出事件
+		baseline, ok := loadBaseline(c.baselinePath)
+		if ok {
+			c.baseline = baseline
+			c.prune()
+			c.scan()
+		} else {
+			c.baseline = make(map[string]Attr)
+			for _, p := range c.expand() {
+				if a, err := readAttr(p); err == nil {
+					c.baseline[p] = a
+				}
+			}
+			utils.Log.Info("[Integrity] baseline created with %d files", len(c.baseline))
+			c.save()
+		}
+
+		var changed <-chan string
+		if cfg.Integrity.Inotify {
+			w, err := newWatcher(c.paths)
+			if err != nil {
+				utils.Log.Error("[Integrity] inotify unavailable, falling back to periodic scan: %v", err)
+			} else {
+				watcher = w
+				changed = w.changed
+			}
+		}
+
+		ticker := time.NewTicker(time.Duration(cfg.Integrity.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.scan()
+			case p := <-changed:
+				if c.inScope(p) {
+					c.check(p)
+					c.save()
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Integrity] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// scan 完整掃描：設定的路徑加上基準中的檔案（用來偵測刪除）
+func (c *checker) scan() {
+	seen := make(map[string]bool)
+	var all []string
+	for _, p := range c.expand() {
+		seen[p] = true
+		all = append(all, p)
+	}
+	for p := range c.baseline {
+		if !seen[p] {
+			all = append(all, p)
+		}
+	}
+	sort.Strings(all)
+
+	for _, p := range all {
+		c.check(p)
+	}
+	c.save()
+}
+
+// check 比對單一路徑與基準並更新基準
+func (c *checker) check(path string) {
+	before, had := c.baseline[path]
+	after, err := readAttr(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.Log.Error("[Integrity] read %s fail: %v", path, err)
+		return
+	}
+
+	switch {
+	case !had && exists:
+		c.emit(EventCreated, path, nil, &after)
+	case had && !exists:
+		c.emit(EventDeleted, path, &before, nil)
+	case had && exists:
+		if before.Hash != after.Hash || before.Size != after.Size {
+			c.emit(EventModified, path, &before, &after)
+		}
+		if before.Mode != after.Mode || before.UID != after.UID || before.GID != after.GID {
+			c.emit(EventPermChanged, path, &before, &after)
+		}
+	}
+
+	if exists {
+		c.baseline[path] = after
+	} else {
+		delete(c.baseline, path)
+	}
+}
+
+func (c *checker) emit(event, path string, before, after *Attr) {
+	data := IntegrityJSON{
+		Host:      c.host.Get(),
+		Category:  Category,
+		Event:     event,
+		Path:      path,
+		Before:    before,
+		After:     after,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Warn("[Integrity] %s %s", event, path)
+	c.logger.Write(b)
+}
+
+// expand 展開 glob，目錄遞迴列出所有一般檔案
+func (c *checker) expand() []string {
+	var out []string
+	for _, pattern := range c.paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			utils.Log.Error("[Integrity] invalid glob %s: %v", pattern, err)
+			continue
+		}
+		for _, m := range matches {
+			filepath.WalkDir(m, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if d.Type().IsRegular() {
+					out = append(out, p)
+				}
+				return nil
+			})
+		}
+	}
+	return out
+}
+
+// inScope 路徑是否在基準中或屬於設定的檔案或目錄
+func (c *checker) inScope(path string) bool {
+	if _, ok := c.baseline[path]; ok {
+		return true
+	}
+	return c.configured(path)
+}
+
+// configured 路徑本身或任一上層目錄符合設定的 pattern，不需要檔案存在
+func (c *checker) configured(path string) bool {
+	for _, pattern := range c.paths {
+		p := path
+		for {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				return true
+			}
+			parent := filepath.Dir(p)
+			if parent == p {
+				break
+			}
+			p = parent
+		}
+	}
+	return false
+}
+
+// prune 移除不再屬於 paths 的基準（設定變更後），避免持續追蹤或誤報刪除
+func (c *checker) prune() {
+	n := 0
+	for p := range c.baseline {
+		if !c.configured(p) {
+			delete(c.baseline, p)
+			n++
+		}
+	}
+	if n > 0 {
+		utils.Log.Info("[Integrity] removed %d baseline entries no longer in paths", n)
+	}
+}
+
+func (c *checker) save() {
+	b, err := json.Marshal(c.baseline)
+	if err == nil {
+		tmp := c.baselinePath + ".tmp"
+		if err = os.WriteFile(tmp, b, 0600); err == nil {
+			err = os.Rename(tmp, c.baselinePath)
+		}
+	}
+	if err != nil {
+		utils.Log.Error("[Integrity] 無法儲存基準: %v", err)
+	}
+}
+
+func readAttr(path string) (Attr, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return Attr{}, err
+	}
+	if !fi.Mode().IsRegular() {
+		return Attr{}, os.ErrNotExist
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Attr{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Attr{}, err
+	}
+
+	uid, gid := owner(fi)
+	return Attr{
+		Hash:  hex.EncodeToString(h.Sum(nil)),
+		Size:  fi.Size(),
+		Mode:  fi.Mode().String(),
+		UID:   uid,
+		GID:   gid,
+		MTime: fi.ModTime().Format(time.RFC3339),
+	}, nil
+}
+
+func loadBaseline(path string) (map[string]Attr, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var m map[string]Attr
+	if err := json.Unmarshal(data, &m); err != nil || m == nil {
+		return nil, false
+	}
+	return m, true
+}
@@ -0,0 +1,216 @@
+package integrity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// memLogger 收集 checker 輸出的事件
+type memLogger struct{ events []IntegrityJSON }
+
+func (l *memLogger) Write(data any) error {
+	var ev IntegrityJSON
+	json.Unmarshal(data.([]byte), &ev)
+	l.events = append(l.events, ev)
+	return nil
+}
+
+func (l *memLogger) take() map[string]string {
+	out := make(map[string]string)
+	for _, ev := range l.events {
+		out[ev.Path] += ev.Event + " "
+	}
+	l.events = nil
+	return out
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 不受 umask 影響
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestChecker(dir string, paths ...string) (*checker, *memLogger) {
+	logger := &memLogger{}
+	return &checker{
+		paths:        paths,
+		baselinePath: filepath.Join(dir, "integrity.json"),
+		baseline:     make(map[string]Attr),
+		host:         &service.HostUpdater{},
+		logger:       logger,
+	}, logger
+}
+
+func TestScanChanges(t *testing.T) {
+	dir := t.TempDir()
+	etc, bin := filepath.Join(dir, "etc"), filepath.Join(dir, "bin")
+	conf, hosts, tool := filepath.Join(etc, "app.conf"), filepath.Join(etc, "hosts.conf"), filepath.Join(bin, "tool")
+	writeFile(t, conf, "listen 80\n")
+	writeFile(t, hosts, "127.0.0.1 localhost\n")
+	writeFile(t, tool, "#!/bin/sh\n")
+	writeFile(t, filepath.Join(etc, "ignored.txt"), "x")
+
+	c, logger := newTestChecker(dir, filepath.Join(etc, "*.conf"), bin)
+	c.scan()
+	if got := logger.take(); len(got) != 3 || got[conf] != EventCreated+" " || got[tool] != EventCreated+" " {
+		t.Fatalf("initial scan = %v", got)
+	}
+
+	writeFile(t, conf, "listen 8080\n")
+	if err := os.Chmod(tool, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(hosts); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(bin, "sub", "helper")
+	writeFile(t, added, "#!/bin/sh\n")
+
+	c.scan()
+	got := logger.take()
+	want := map[string]string{
+		conf:  EventModified + " ",
+		tool:  EventPermChanged + " ",
+		hosts: EventDeleted + " ",
+		added: EventCreated + " ",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for p, ev := range want {
+		if got[p] != ev {
+			t.Errorf("%s: %q, want %q", p, got[p], ev)
+		}
+	}
+
+	// 基準已更新並寫入檔案，再掃描一次不應有事件
+	c.scan()
+	if got := logger.take(); len(got) != 0 {
+		t.Errorf("rescan = %v", got)
+	}
+	saved, ok := loadBaseline(c.baselinePath)
+	if !ok || len(saved) != 3 || saved[tool].Mode != "-rwx------" {
+		t.Errorf("saved baseline = %+v", saved)
+	}
+	if _, ok := saved[hosts]; ok {
+		t.Error("deleted file still in baseline")
+	}
+}
+
+func TestCheckEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "passwd")
+	c, logger := newTestChecker(dir, path)
+
+	writeFile(t, path, "root:x:0:0\n")
+	c.check(path)
+	writeFile(t, path, "root:x:0:0\nuser:x:1000:1000\n")
+	os.Chmod(path, 0600)
+	c.check(path)
+	os.Remove(path)
+	c.check(path)
+	c.check(path)
+
+	want := []string{EventCreated, EventModified, EventPermChanged, EventDeleted}
+	if len(logger.events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(logger.events), len(want), logger.events)
+	}
+	for i, ev := range logger.events {
+		if ev.Event != want[i] {
+			t.Errorf("event %d = %s, want %s", i, ev.Event, want[i])
+		}
+	}
+	if ev := logger.events[0]; ev.Before != nil || ev.After == nil || ev.After.Size != 11 {
+		t.Errorf("created = %+v", ev)
+	}
+	if ev := logger.events[1]; ev.Before == nil || ev.After == nil || ev.Before.Hash == ev.After.Hash {
+		t.Errorf("modified = %+v", ev)
+	}
+	if ev := logger.events[2]; ev.Before.Mode != "-rw-r--r--" || ev.After.Mode != "-rw-------" {
+		t.Errorf("perm changed = %+v %+v", ev.Before, ev.After)
+	}
+	if ev := logger.events[3]; ev.Before == nil || ev.After != nil {
+		t.Errorf("deleted = %+v", ev)
+	}
+}
+
+func TestInScope(t *testing.T) {
+	dir := t.TempDir()
+	etc := filepath.Join(dir, "etc")
+	writeFile(t, filepath.Join(etc, "ssh", "sshd_config"), "")
+
+	c, _ := newTestChecker(dir, filepath.Join(etc, "*.conf"), filepath.Join(etc, "ssh"))
+	c.baseline[filepath.Join(dir, "old", "file")] = Attr{}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(etc, "app.conf"), true},
+		{filepath.Join(etc, "app.conf.bak"), false},
+		{filepath.Join(etc, "ssh", "sshd_config"), true},
+		{filepath.Join(etc, "ssh", "sshd_config.d", "new.conf"), true},
+		{filepath.Join(etc, "sshd"), false},
+		{filepath.Join(dir, "old", "file"), true}, // 基準中的檔案
+		{filepath.Join(dir, "other"), false},
+	}
+	for _, tt := range tests {
+		if got := c.inScope(tt.path); got != tt.want {
+			t.Errorf("inScope(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// paths 變更後，不再監看的基準移除，不輸出刪除事件
+func TestPruneBaseline(t *testing.T) {
+	dir := t.TempDir()
+	kept, gone := filepath.Join(dir, "etc", "app.conf"), filepath.Join(dir, "var", "old.conf")
+	writeFile(t, kept, "a")
+	writeFile(t, gone, "b")
+
+	c, logger := newTestChecker(dir, filepath.Join(dir, "etc"), filepath.Join(dir, "var"))
+	c.scan()
+	logger.take()
+
+	// 已不存在的監看目錄仍算在 paths 內，其中的檔案要報刪除而不是被移除
+	removedDir := filepath.Join(dir, "etc", "conf.d", "x.conf")
+	writeFile(t, removedDir, "c")
+	c.scan()
+	logger.take()
+	os.RemoveAll(filepath.Join(dir, "etc", "conf.d"))
+
+	c.paths = []string{filepath.Join(dir, "etc")}
+	c.prune()
+	if _, ok := c.baseline[gone]; ok {
+		t.Errorf("%s still in baseline", gone)
+	}
+	if _, ok := c.baseline[kept]; !ok {
+		t.Errorf("%s pruned", kept)
+	}
+
+	c.scan()
+	got := logger.take()
+	if len(got) != 1 || got[removedDir] != EventDeleted+" " {
+		t.Errorf("events after prune = %v", got)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package integrity
+
+import (
+	"os"
+	"syscall"
+)
+
+// owner 取得檔案的 uid / gid
+func owner(fi os.FileInfo) (int, int) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid)
+	}
+	return -1, -1
+}
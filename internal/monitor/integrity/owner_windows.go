@@ -0,0 +1,10 @@
+//go:build windows
+
+package integrity
+
+import "os"
+
+// owner Windows 沒有 uid / gid，只比對 mode 與內容
+func owner(fi os.FileInfo) (int, int) {
+	return -1, -1
+}
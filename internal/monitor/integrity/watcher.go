@@ -0,0 +1,118 @@
+package integrity
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/utils"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 同一個檔案在這段時間內的連續事件合併成一次檢查
+const debounceTime = time.Second
+
+// watcher 監看設定路徑所在的目錄，把異動的檔案路徑送到 changed
+type watcher struct {
+	fs      *fsnotify.Watcher
+	changed chan string
+	done    chan struct{}
+}
+
+func newWatcher(patterns []string) (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{
+		fs:      fw,
+		changed: make(chan string, 256),
+		done:    make(chan struct{}),
+	}
+
+	// 檔案監看其所在目錄（編輯器常以 rename 取代原檔），目錄則遞迴監看
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		if len(matches) == 0 {
+			// 尚未存在的檔案：監看上層目錄以偵測建立
+			w.add(filepath.Dir(pattern))
+			continue
+		}
+		for _, m := range matches {
+			fi, err := os.Stat(m)
+			if err != nil {
+				continue
+			}
+			if fi.IsDir() {
+				w.addTree(m)
+			} else {
+				w.add(filepath.Dir(m))
+			}
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func (w *watcher) add(dir string) {
+	if err := w.fs.Add(dir); err != nil {
+		utils.Log.Debug("[Integrity] watch %s fail: %v", dir, err)
+	}
+}
+
+func (w *watcher) addTree(root string) {
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			w.add(p)
+		}
+		return nil
+	})
+}
+
+func (w *watcher) run() {
+	pending := make(map[string]time.Time)
+	ticker := time.NewTicker(debounceTime / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			// 新建立的子目錄也要監看
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					w.addTree(ev.Name)
+				}
+			}
+			pending[ev.Name] = time.Now()
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			utils.Log.Error("[Integrity] inotify error: %v", err)
+		case <-ticker.C:
+			for p, t := range pending {
+				if time.Since(t) < debounceTime {
+					continue
+				}
+				delete(pending, p)
+				select {
+				case w.changed <- p:
+				case <-w.done:
+					return
+				}
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *watcher) close() {
+	close(w.done)
+	w.fs.Close()
+}
@@ -0,0 +1,74 @@
+package kmsg
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// 事件種類
+const (
+	EventOOMKill       = "OOM_KILL"
+	EventSegfault      = "SEGFAULT"
+	EventFSError       = "FS_ERROR"
+	EventIOError       = "IO_ERROR"
+	EventLinkUp        = "LINK_UP"
+	EventLinkDown      = "LINK_DOWN"
+	EventHungTask      = "HUNG_TASK"
+	EventHardwareError = "HARDWARE_ERROR"
+	EventKernel        = "KERNEL" // 未分類
+)
+
+// classification 分類結果，Event 為空表示未分類
+type classification struct {
+	Event   string
+	Process string
+	PID     int
+	Device  string
+}
+
+// rule 依序比對，第一個符合的規則決定分類。
+// 具名群組 proc / pid / dev 會填入對應欄位。
+type rule struct {
+	event   string
+	pattern *regexp.Regexp
+}
+
+var rules = []rule{
+	{EventOOMKill, regexp.MustCompile(`Out of memory: Killed process (?P<pid>\d+) \((?P<proc>[^)]+)\)`)},
+	{EventOOMKill, regexp.MustCompile(`Memory cgroup out of memory: Killed process (?P<pid>\d+) \((?P<proc>[^)]+)\)`)},
+	{EventOOMKill, regexp.MustCompile(`(?P<proc>\S+) invoked oom-killer`)},
+	{EventSegfault, regexp.MustCompile(`(?P<proc>[^\s\[]+)\[(?P<pid>\d+)\]: segfault at`)},
+	{EventFSError, regexp.MustCompile(`EXT4-fs (?:error|critical) \(device (?P<dev>[^)]+)\)`)},
+	{EventFSError, regexp.MustCompile(`XFS \((?P<dev>[^)]+)\): .*(?:[Cc]orruption|error|I/O Error|Filesystem has been shut down)`)},
+	{EventFSError, regexp.MustCompile(`BTRFS (?:error|critical) \(device (?P<dev>[^)]+)\)`)},
+	{EventIOError, regexp.MustCompile(`(?:blk_update_request|I/O error), dev (?P<dev>[^,\s]+)`)},
+	{EventIOError, regexp.MustCompile(`Buffer I/O error on dev(?:ice)? (?P<dev>[^,\s]+)`)},
+	{EventIOError, regexp.MustCompile(`I/O error`)},
+	{EventLinkUp, regexp.MustCompile(`(?P<dev>[^\s:]+):? (?:NIC )?[Ll]ink is [Uu]p`)},
+	{EventLinkDown, regexp.MustCompile(`(?P<dev>[^\s:]+):? (?:NIC )?[Ll]ink is [Dd]own`)},
+	{EventHungTask, regexp.MustCompile(`task (?P<proc>\S+):(?P<pid>\d+) blocked for more than \d+ seconds`)},
+	{EventHardwareError, regexp.MustCompile(`(?:[Mm]achine [Cc]heck|mce: \[Hardware Error\]|Hardware Error|EDAC .*(?:CE|UE) )`)},
+}
+
+func classify(msg string) classification {
+	for _, r := range rules {
+		m := r.pattern.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+
+		c := classification{Event: r.event}
+		for i, name := range r.pattern.SubexpNames() {
+			switch name {
+			case "proc":
+				c.Process = m[i]
+			case "pid":
+				c.PID, _ = strconv.Atoi(m[i])
+			case "dev":
+				c.Device = m[i]
+			}
+		}
+		return c
+	}
+	return classification{}
+}
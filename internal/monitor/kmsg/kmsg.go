@@ -0,0 +1,285 @@
+package kmsg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+const Category = "KMSG"
+
+const (
+	defaultPath = "/dev/kmsg"
+	bootIDPath  = "/proc/sys/kernel/random/boot_id"
+)
+
+// KmsgJSON 單筆核心訊息
+type KmsgJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"` // OOM_KILL / SEGFAULT / FS_ERROR ...，未分類為 KERNEL
+	Level     int              `json:"Level"` // 0 emerg ~ 7 debug
+	Facility  int              `json:"Facility"`
+	Seq       int64            `json:"Seq"`
+	Monotonic int64            `json:"Monotonic"` // 開機後經過的微秒
+	Process   string           `json:"Process"`
+	PID       int              `json:"PID"`
+	Device    string           `json:"Device"` // 裝置、檔案系統或網卡名稱
+	Message   string           `json:"Message"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// kmsgState 持久化於 monitor.data，重啟後從上次的序號繼續
+type kmsgState struct {
+	BootID string `json:"bootId"`
+	Seq    int64  `json:"seq"`
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Kmsg] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		path := cfg.Kmsg.Path
+		if path == "" {
+			path = defaultPath
+		}
+		interval := time.Duration(cfg.Kmsg.Interval) * time.Second
+
+		r := &reader{
+			logger:    utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days),
+			statePath: cfg.Data + "/kmsg.json",
+			level:     cfg.Kmsg.Level,
+			host:      host,
+			bootTime:  bootTime(),
+		}
+
+		// 重新開機後序號會從 0 開始
+		bootID := readBootID()
+		r.state = loadState(r.statePath)
+		if r.state.BootID != bootID {
+			r.state = kmsgState{BootID: bootID, Seq: -1}
+		}
+
+		for {
+			err := r.follow(ctx, path, interval)
+			if ctx.Err() != nil {
+				utils.Log.Info("[Kmsg] 收集器已停止")
+				return
+			}
+			if err != nil {
+				utils.Log.Error("[Kmsg] read %s fail: %v", path, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				utils.Log.Info("[Kmsg] 收集器已停止")
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+type reader struct {
+	logger interface {
+		Write(data any) error
+	}
+	statePath string
+	level     int
+	host      *service.HostUpdater
+	bootTime  time.Time
+	state     kmsgState
+}
+
+// follow 讀取 /dev/kmsg 或一般檔案（測試用），直到 ctx 取消或發生錯誤
+func (r *reader) follow(ctx context.Context, path string, interval time.Duration) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	// ctx 取消時關閉檔案，中斷阻塞中的 read
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		f.Close()
+	}()
+
+	if st, err := f.Stat(); err == nil && st.Mode()&os.ModeCharDevice != 0 {
+		return r.readDevice(ctx, f)
+	}
+	return r.readFile(ctx, f, interval)
+}
+
+// readDevice 每次 read 回傳一筆完整紀錄（含字典續行），緩衝區需大於單筆紀錄
+func (r *reader) readDevice(ctx context.Context, f *os.File) error {
+	buf := make([]byte, 16*1024)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, syscall.EPIPE) {
+				// 讀取太慢，ring buffer 已覆蓋舊紀錄，直接從下一筆繼續
+				utils.Log.Warn("[Kmsg] ring buffer overrun, some messages were lost")
+				continue
+			}
+			return err
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			r.handle(line)
+		}
+	}
+}
+
+// readFile 一般檔案逐行讀取，讀到結尾後輪詢新資料
+func (r *reader) readFile(ctx context.Context, f *os.File, interval time.Duration) error {
+	br := bufio.NewReader(f)
+	var partial string
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			// 保留未完整的一行，等待新資料
+			partial += line
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+			continue
+		}
+
+		line = partial + line
+		partial = ""
+		r.handle(strings.TrimRight(line, "\n"))
+	}
+}
+
+// handle 解析 "pri,seq,ts,flags;message"；以空白開頭的字典續行略過
+func (r *reader) handle(line string) {
+	if line == "" || line[0] == ' ' {
+		return
+	}
+
+	header, msg, ok := strings.Cut(line, ";")
+	if !ok {
+		return
+	}
+	fields := strings.Split(header, ",")
+	if len(fields) < 3 {
+		return
+	}
+	pri, err1 := strconv.Atoi(fields[0])
+	seq, err2 := strconv.ParseInt(fields[1], 10, 64)
+	usec, err3 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return
+	}
+
+	// 已處理過的序號
+	if seq <= r.state.Seq {
+		return
+	}
+	r.state.Seq = seq
+
+	level := pri & 7
+	c := classify(msg)
+	if c.Event == "" {
+		if level > r.level {
+			return
+		}
+		c.Event = EventKernel
+	}
+
+	data := KmsgJSON{
+		Host:      r.host.Get(),
+		Category:  Category,
+		Event:     c.Event,
+		Level:     level,
+		Facility:  pri >> 3,
+		Seq:       seq,
+		Monotonic: usec,
+		Process:   c.Process,
+		PID:       c.PID,
+		Device:    c.Device,
+		Message:   msg,
+		Timestamp: r.bootTime.Add(time.Duration(usec) * time.Microsecond).Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("%s", string(b))
+	r.logger.Write(b)
+
+	if err := saveState(r.statePath, r.state); err != nil {
+		utils.Log.Error("[Kmsg] 無法儲存狀態: %v", err)
+	}
+}
+
+func bootTime() time.Time {
+	bt, err := host.BootTime()
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(int64(bt), 0)
+}
+
+func readBootID() string {
+	b, err := os.ReadFile(bootIDPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// ------------------------- State -------------------------
+func loadState(path string) kmsgState {
+	s := kmsgState{Seq: -1}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return kmsgState{Seq: -1}
+	}
+	return s
+}
+
+func saveState(path string, s kmsgState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,201 @@
+package kmsg
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// memLogger 收集寫出的紀錄
+type memLogger struct {
+	mu      sync.Mutex
+	records []KmsgJSON
+}
+
+func (l *memLogger) Write(data any) error {
+	var rec KmsgJSON
+	json.Unmarshal(data.([]byte), &rec)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, rec)
+	return nil
+}
+
+func (l *memLogger) list() []KmsgJSON {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]KmsgJSON(nil), l.records...)
+}
+
+var testBoot = time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+func newTestReader(t *testing.T, statePath string) (*reader, *memLogger) {
+	t.Helper()
+	l := &memLogger{}
+	return &reader{
+		logger:    l,
+		statePath: statePath,
+		level:     4,
+		host:      &service.HostUpdater{},
+		bootTime:  testBoot,
+		state:     loadState(statePath),
+	}, l
+}
+
+// readAll 以一般檔案模式讀完 path 後停止
+func readAll(t *testing.T, r *reader, path string, want int, l *memLogger) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.follow(ctx, path, 10*time.Millisecond) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(l.list()) < want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 多等一輪輪詢，確認沒有多餘的紀錄
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadFixture(t *testing.T) {
+	r, l := newTestReader(t, filepath.Join(t.TempDir(), "kmsg.json"))
+	readAll(t, r, "testdata/kmsg", 9, l)
+
+	type want struct {
+		seq     int64
+		event   string
+		level   int
+		process string
+		pid     int
+		device  string
+	}
+	// 100（level 6）、109（level 7）、110（level 6）未分類且低於 level 4，不輸出
+	wants := []want{
+		{101, EventOOMKill, 3, "java", 1234, ""},
+		{102, EventSegfault, 4, "nginx", 4321, ""},
+		{103, EventFSError, 3, "", 0, "sda1"},
+		{104, EventIOError, 3, "", 0, "sdb"},
+		{105, EventLinkUp, 6, "", 0, "eth0"},
+		{106, EventLinkDown, 6, "", 0, "eno1"},
+		{107, EventHungTask, 3, "kworker/0:1", 55, ""},
+		{108, EventHardwareError, 0, "", 0, ""},
+		{111, EventKernel, 3, "", 0, ""},
+	}
+	got := l.list()
+	if len(got) != len(wants) {
+		t.Fatalf("got %d records, want %d: %+v", len(got), len(wants), got)
+	}
+	for i, w := range wants {
+		g := got[i]
+		if g.Seq != w.seq || g.Event != w.event || g.Level != w.level || g.Process != w.process || g.PID != w.pid || g.Device != w.device {
+			t.Errorf("record %d = seq %d %s level %d proc %q pid %d dev %q, want %+v", i, g.Seq, g.Event, g.Level, g.Process, g.PID, g.Device, w)
+		}
+	}
+
+	oom := got[0]
+	if oom.Facility != 0 || oom.Monotonic != 2000000 || oom.Category != Category {
+		t.Errorf("oom header = facility %d monotonic %d category %s", oom.Facility, oom.Monotonic, oom.Category)
+	}
+	if oom.Timestamp != testBoot.Add(2*time.Second).Format(time.RFC3339) {
+		t.Errorf("oom timestamp = %s", oom.Timestamp)
+	}
+	// 字典續行不屬於訊息內容
+	if fs := got[2]; fs.Message != "EXT4-fs error (device sda1): ext4_lookup:1855: inode #2: comm ls: deleted inode referenced: 12" {
+		t.Errorf("fs message = %q", fs.Message)
+	}
+}
+
+func TestResumeFromSavedSeq(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "kmsg.json")
+	path := filepath.Join(dir, "kmsg")
+	fixture, _ := os.ReadFile("testdata/kmsg")
+	os.WriteFile(path, fixture, 0644)
+
+	r, l := newTestReader(t, statePath)
+	readAll(t, r, path, 9, l)
+	if s := loadState(statePath); s.Seq != 111 {
+		t.Fatalf("saved seq = %d, want 111", s.Seq)
+	}
+
+	// 重啟後整個檔案重新讀取，只輸出新的序號
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("3,112,3900000,-;Out of memory: Killed process 99 (python3)\n")
+	f.Close()
+
+	r, l = newTestReader(t, statePath)
+	readAll(t, r, path, 1, l)
+	got := l.list()
+	if len(got) != 1 || got[0].Seq != 112 || got[0].Process != "python3" {
+		t.Fatalf("after restart = %+v, want only seq 112", got)
+	}
+}
+
+func TestPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kmsg")
+	os.WriteFile(path, []byte("3,1,100,-;Out of memory: Killed pro"), 0644)
+
+	r, l := newTestReader(t, filepath.Join(t.TempDir(), "kmsg.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.follow(ctx, path, 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	if n := len(l.list()); n != 0 {
+		t.Fatalf("incomplete line produced %d records", n)
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("cess 7 (ruby)\n")
+	f.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(l.list()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := l.list(); len(got) != 1 || got[0].Process != "ruby" || got[0].PID != 7 {
+		t.Fatalf("records = %+v", got)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		msg   string
+		event string
+		proc  string
+		pid   int
+		dev   string
+	}{
+		{"Memory cgroup out of memory: Killed process 812 (node) total-vm:1kB", EventOOMKill, "node", 812, ""},
+		{"postgres invoked oom-killer: gfp_mask=0x100cca(GFP_HIGHUSER_MOVABLE), order=0", EventOOMKill, "postgres", 0, ""},
+		{"XFS (dm-0): Corruption detected. Unmount and run xfs_repair", EventFSError, "", 0, "dm-0"},
+		{"BTRFS error (device nvme0n1p2): bdev /dev/nvme0n1p2 errs: wr 1", EventFSError, "", 0, "nvme0n1p2"},
+		{"Buffer I/O error on dev sdc1, logical block 0, async page read", EventIOError, "", 0, "sdc1"},
+		{"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_Ha#0", EventHardwareError, "", 0, ""},
+		{"usb 1-1: new high-speed USB device number 2", "", "", 0, ""},
+	}
+	for _, tt := range tests {
+		c := classify(tt.msg)
+		if c.Event != tt.event || c.Process != tt.proc || c.PID != tt.pid || c.Device != tt.dev {
+			t.Errorf("classify(%q) = %+v, want %s %q %d %q", tt.msg, c, tt.event, tt.proc, tt.pid, tt.dev)
+		}
+	}
+}
@@ -3,10 +3,24 @@ package monitor
 import (
 	"context"
 	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/applog"
+	"sysprobe/internal/monitor/auth"
+	"sysprobe/internal/monitor/certs"
+	"sysprobe/internal/monitor/cgroup"
+	"sysprobe/internal/monitor/container"
 	"sysprobe/internal/monitor/cpu"
 	"sysprobe/internal/monitor/disk"
+	"sysprobe/internal/monitor/exec"
+	"sysprobe/internal/monitor/integrity"
+	"sysprobe/internal/monitor/kmsg"
 	"sysprobe/internal/monitor/memory"
 	"sysprobe/internal/monitor/network"
+	"sysprobe/internal/monitor/packages"
+	"sysprobe/internal/monitor/probe"
+	"sysprobe/internal/monitor/scrape"
+	"sysprobe/internal/monitor/session"
+	"sysprobe/internal/monitor/statsd"
+	"sysprobe/internal/monitor/systemd"
 	"sysprobe/internal/service"
 	"sysprobe/internal/utils"
 )
@@ -29,5 +43,61 @@ func LoadMonitor(ctx context.Context, cfg config.MonitorConfig, host *service.Ho
 		utils.Log.Info("→ Starting Network monitor")
 		network.Start(ctx, cfg, host)
 	}
+	if cfg.Package.Enable {
+		utils.Log.Info("→ Starting Package monitor")
+		packages.Start(ctx, cfg, host)
+	}
+	if cfg.Systemd.Enable {
+		utils.Log.Info("→ Starting Systemd monitor")
+		systemd.Start(ctx, cfg, host)
+	}
+	if cfg.Container.Enable {
+		utils.Log.Info("→ Starting Container monitor")
+		container.Start(ctx, cfg, host)
+	}
+	if cfg.Cgroup.Enable {
+		utils.Log.Info("→ Starting Cgroup monitor")
+		cgroup.Start(ctx, cfg, host)
+	}
+	if cfg.Kmsg.Enable {
+		utils.Log.Info("→ Starting Kmsg monitor")
+		kmsg.Start(ctx, cfg, host)
+	}
+	if cfg.AppLog.Enable {
+		utils.Log.Info("→ Starting AppLog monitor")
+		applog.Start(ctx, cfg, host)
+	}
+	if cfg.Auth.Enable {
+		utils.Log.Info("→ Starting Auth monitor")
+		auth.Start(ctx, cfg, host)
+	}
+	if cfg.Session.Enable {
+		utils.Log.Info("→ Starting Session monitor")
+		session.Start(ctx, cfg, host)
+	}
+	if cfg.Integrity.Enable {
+		utils.Log.Info("→ Starting Integrity monitor")
+		integrity.Start(ctx, cfg, host)
+	}
+	if cfg.Cert.Enable {
+		utils.Log.Info("→ Starting Cert monitor")
+		certs.Start(ctx, cfg, host)
+	}
+	if cfg.Probe.Enable {
+		utils.Log.Info("→ Starting Probe monitor")
+		probe.Start(ctx, cfg, host)
+	}
+	if cfg.Exec.Enable {
+		utils.Log.Info("→ Starting Exec monitor")
+		exec.Start(ctx, cfg, host)
+	}
+	if cfg.Scrape.Enable {
+		utils.Log.Info("→ Starting Scrape monitor")
+		scrape.Start(ctx, cfg, host)
+	}
+	if cfg.Statsd.Enable {
+		utils.Log.Info("→ Starting Statsd monitor")
+		statsd.Start(ctx, cfg, host)
+	}
 	utils.Log.Info("Monitor started")
 }
@@ -0,0 +1,380 @@
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "PACKAGE"
+
+// 事件種類
+const (
+	EventInventory = "PACKAGE_INVENTORY"
+	EventInstalled = "PACKAGE_INSTALLED"
+	EventRemoved   = "PACKAGE_REMOVED"
+	EventUpgraded  = "PACKAGE_UPGRADED"
+)
+
+// Package 單一已安裝套件
+type Package struct {
+	Name    string `json:"Name"`
+	Version string `json:"Version"`
+	Arch    string `json:"Arch"`
+	Source  string `json:"Source"` // dpkg / rpm
+}
+
+// InventoryJSON 每日一次的完整套件清單
+type InventoryJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Count     int              `json:"Count"`
+	Packages  []Package        `json:"Packages"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// ChangeJSON 兩次掃描之間的套件異動
+type ChangeJSON struct {
+	Host        service.HostInfo `json:"Host"`
+	Category    string           `json:"Category"`
+	Event       string           `json:"Event"`
+	Name        string           `json:"Name"`
+	Arch        string           `json:"Arch"`
+	Source      string           `json:"Source"`
+	Version     string           `json:"Version"`
+	PrevVersion string           `json:"PrevVersion"`
+	Timestamp   string           `json:"Timestamp"`
+}
+
+// packageState 持久化於 monitor.data，用來比對上一輪結果
+type packageState struct {
+	LastInventory string             `json:"lastInventory"` // YYYY-MM-DD
+	Packages      map[string]Package `json:"packages"`      // source:name:arch:version → 套件
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Package] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		statePath := cfg.Data + "/package.json"
+		ticker := time.NewTicker(time.Duration(cfg.Package.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, line := range monitorPackage(cfg.Package, statePath, host) {
+					logger.Write(line)
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Package] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+func monitorPackage(cfg config.PackageModule, statePath string, host *service.HostUpdater) [][]byte {
+	current := make(map[string]Package)
+
+	// 讀取失敗時結果可能不完整，這一輪不比對也不覆蓋上次的清單，否則會誤報大量移除 / 安裝
+	if cfg.DpkgStatus != "" {
+		pkgs, err := readDpkgStatus(cfg.DpkgStatus)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			utils.Log.Error("[Package] 無法讀取 dpkg status，略過這一輪: %v", err)
+			return nil
+		}
+		for _, p := range pkgs {
+			current[packageKey(p)+":"+p.Version] = p
+		}
+	}
+
+	if cfg.RpmCommand != "" {
+		pkgs, err := readRpm(cfg.RpmCommand)
+		if err != nil && !errors.Is(err, exec.ErrNotFound) {
+			utils.Log.Error("[Package] 無法執行 rpm 指令，略過這一輪: %v", err)
+			return nil
+		}
+		for _, p := range pkgs {
+			current[packageKey(p)+":"+p.Version] = p
+		}
+	}
+
+	state := loadState(statePath)
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	hostInfo := host.Get()
+
+	var out [][]byte
+
+	// 1️⃣ 與上一輪比對（第一次執行沒有基準，只輸出完整清單）
+	if state.Packages != nil {
+		for _, c := range diffPackages(state.Packages, current) {
+			c.Host = hostInfo
+			c.Category = Category
+			c.Timestamp = now.Format(time.RFC3339)
+			b, _ := json.Marshal(c)
+			utils.Log.Debug("%s", string(b))
+			out = append(out, b)
+		}
+	}
+
+	// 2️⃣ 每天輸出一次完整清單
+	if state.LastInventory != today {
+		pkgs := make([]Package, 0, len(current))
+		for _, p := range current {
+			pkgs = append(pkgs, p)
+		}
+		sort.Slice(pkgs, func(i, j int) bool {
+			if ki, kj := packageKey(pkgs[i]), packageKey(pkgs[j]); ki != kj {
+				return ki < kj
+			}
+			return pkgs[i].Version < pkgs[j].Version
+		})
+
+		data := InventoryJSON{
+			Host:      hostInfo,
+			Category:  Category,
+			Event:     EventInventory,
+			Count:     len(pkgs),
+			Packages:  pkgs,
+			Timestamp: now.Format(time.RFC3339),
+		}
+		b, _ := json.Marshal(data)
+		utils.Log.Debug("[Package] inventory %d packages", len(pkgs))
+		out = append(out, b)
+		state.LastInventory = today
+	}
+
+	state.Packages = current
+	if err := saveState(statePath, state); err != nil {
+		utils.Log.Error("[Package] 無法儲存狀態: %v", err)
+	}
+
+	return out
+}
+
+// diffPackages 比對前後兩次清單，產生安裝 / 移除 / 版本變更事件
+// 同一個套件可以同時安裝多個版本（例如 kernel），所以依 source:name:arch 分組後比對版本集合：
+// 剛好一個版本換成另一個版本時為升級，其他情況每個版本各自是安裝或移除
+func diffPackages(prev, current map[string]Package) []ChangeJSON {
+	before, after := groupPackages(prev), groupPackages(current)
+	keys := make(map[string]bool, len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	var changes []ChangeJSON
+	for key := range keys {
+		var added, removed []Package
+		for v, p := range after[key] {
+			if _, ok := before[key][v]; !ok {
+				added = append(added, p)
+			}
+		}
+		for v, p := range before[key] {
+			if _, ok := after[key][v]; !ok {
+				removed = append(removed, p)
+			}
+		}
+
+		if len(added) == 1 && len(removed) == 1 {
+			p, old := added[0], removed[0]
+			changes = append(changes, ChangeJSON{
+				Event: EventUpgraded, Name: p.Name, Arch: p.Arch, Source: p.Source, Version: p.Version, PrevVersion: old.Version,
+			})
+			continue
+		}
+		for _, p := range added {
+			changes = append(changes, ChangeJSON{
+				Event: EventInstalled, Name: p.Name, Arch: p.Arch, Source: p.Source, Version: p.Version,
+			})
+		}
+		for _, old := range removed {
+			changes = append(changes, ChangeJSON{
+				Event: EventRemoved, Name: old.Name, Arch: old.Arch, Source: old.Source, PrevVersion: old.Version,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		return a.Version+a.PrevVersion < b.Version+b.PrevVersion
+	})
+	return changes
+}
+
+// groupPackages source:name:arch → 版本 → 套件；舊版狀態檔的 key 不含版本，所以依內容重新分組
+func groupPackages(pkgs map[string]Package) map[string]map[string]Package {
+	groups := make(map[string]map[string]Package)
+	for _, p := range pkgs {
+		key := packageKey(p)
+		if groups[key] == nil {
+			groups[key] = make(map[string]Package)
+		}
+		groups[key][p.Version] = p
+	}
+	return groups
+}
+
+func packageKey(p Package) string {
+	return p.Source + ":" + p.Name + ":" + p.Arch
+}
+
+// ------------------------- dpkg -------------------------
+
+// readDpkgStatus 解析 /var/lib/dpkg/status，只保留狀態為 installed 的套件
+func readDpkgStatus(path string) ([]Package, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []Package
+	fields := make(map[string]string)
+
+	flush := func() {
+		status := strings.Fields(fields["Status"])
+		if fields["Package"] != "" && len(status) == 3 && status[2] == "installed" {
+			pkgs = append(pkgs, Package{
+				Name:    fields["Package"],
+				Version: fields["Version"],
+				Arch:    fields["Architecture"],
+				Source:  "dpkg",
+			})
+		}
+		fields = make(map[string]string)
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// 續行（例如 Description 的多行內容）不需要
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fields[k] = strings.TrimSpace(v)
+		}
+	}
+	flush()
+
+	return pkgs, scanner.Err()
+}
+
+// ------------------------- rpm -------------------------
+
+// readRpm 執行設定的指令並解析輸出。
+// 支援 "NAME\tVERSION\tARCH" 格式（--queryformat），或 rpm -qa 預設的 name-version-release.arch。
+func readRpm(command string) ([]Package, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []Package
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if p, ok := parseRpmLine(line); ok {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs, scanner.Err()
+}
+
+func parseRpmLine(line string) (Package, bool) {
+	if parts := strings.Split(line, "\t"); len(parts) >= 2 {
+		p := Package{Name: parts[0], Version: parts[1], Source: "rpm"}
+		if len(parts) >= 3 {
+			p.Arch = parts[2]
+		}
+		return p, true
+	}
+
+	// name-version-release.arch
+	nvr := line
+	arch := ""
+	if i := strings.LastIndex(line, "."); i > 0 && !strings.Contains(line[i+1:], "-") {
+		nvr, arch = line[:i], line[i+1:]
+	}
+	rel := strings.LastIndex(nvr, "-")
+	if rel <= 0 {
+		return Package{}, false
+	}
+	ver := strings.LastIndex(nvr[:rel], "-")
+	if ver <= 0 {
+		return Package{}, false
+	}
+	return Package{
+		Name:    nvr[:ver],
+		Version: nvr[ver+1:],
+		Arch:    arch,
+		Source:  "rpm",
+	}, true
+}
+
+// ------------------------- State -------------------------
+func loadState(path string) packageState {
+	var s packageState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return packageState{}
+	}
+	return s
+}
+
+func saveState(path string, s packageState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,151 @@
+package packages
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestReadDpkgStatus(t *testing.T) {
+	pkgs, err := readDpkgStatus("testdata/dpkg_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// vim 只剩設定檔，不算已安裝
+	want := []Package{
+		{Name: "bash", Version: "5.2.21-2ubuntu4", Arch: "amd64", Source: "dpkg"},
+		{Name: "curl", Version: "8.5.0-2ubuntu10.6", Arch: "amd64", Source: "dpkg"},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("got %d packages, want %d: %+v", len(pkgs), len(want), pkgs)
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Errorf("package %d = %+v, want %+v", i, pkgs[i], want[i])
+		}
+	}
+}
+
+func TestMonitorPackageReadError(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "package.json")
+	host := &service.HostUpdater{}
+
+	cfg := config.PackageModule{DpkgStatus: "testdata/dpkg_status"}
+	if out := monitorPackage(cfg, statePath, host); len(out) != 1 {
+		t.Fatalf("first run: got %d lines, want the inventory only", len(out))
+	}
+	saved, _ := os.ReadFile(statePath)
+
+	// 讀取失敗的一輪不產生事件，也不覆蓋上次的清單
+	for name, cfg := range map[string]config.PackageModule{
+		"rpm fails":      {DpkgStatus: "testdata/dpkg_status", RpmCommand: "false"},
+		"dpkg read fail": {DpkgStatus: "testdata"},
+	} {
+		if out := monitorPackage(cfg, statePath, host); len(out) != 0 {
+			t.Errorf("%s: got %d lines, want none: %s", name, len(out), out)
+		}
+		if now, _ := os.ReadFile(statePath); string(now) != string(saved) {
+			t.Errorf("%s: state overwritten", name)
+		}
+	}
+
+	// 沒有安裝 rpm 不是錯誤
+	cfg.RpmCommand = "sysprobe-no-such-rpm -qa"
+	if out := monitorPackage(cfg, statePath, host); len(out) != 0 {
+		t.Errorf("missing rpm: got %d lines, want none", len(out))
+	}
+	var state packageState
+	data, _ := os.ReadFile(statePath)
+	if err := json.Unmarshal(data, &state); err != nil || len(state.Packages) != 2 {
+		t.Errorf("state = %+v, err = %v, want 2 packages", state, err)
+	}
+}
+
+func TestDiffPackages(t *testing.T) {
+	pkg := func(name, version string) Package {
+		return Package{Name: name, Version: version, Arch: "x86_64", Source: "rpm"}
+	}
+	set := func(pkgs ...Package) map[string]Package {
+		m := make(map[string]Package)
+		for _, p := range pkgs {
+			m[packageKey(p)+":"+p.Version] = p
+		}
+		return m
+	}
+	type change struct{ event, name, version, prev string }
+
+	tests := []struct {
+		name    string
+		prev    map[string]Package
+		current map[string]Package
+		want    []change
+	}{
+		{
+			name:    "upgrade",
+			prev:    set(pkg("bash", "5.1"), pkg("curl", "8.0")),
+			current: set(pkg("bash", "5.2"), pkg("curl", "8.0")),
+			want:    []change{{EventUpgraded, "bash", "5.2", "5.1"}},
+		},
+		{
+			name:    "multi-version unchanged in any order",
+			prev:    set(pkg("kernel", "6.1"), pkg("kernel", "6.2"), pkg("gpg-pubkey", "a"), pkg("gpg-pubkey", "b")),
+			current: set(pkg("gpg-pubkey", "b"), pkg("kernel", "6.2"), pkg("gpg-pubkey", "a"), pkg("kernel", "6.1")),
+		},
+		{
+			name:    "new kernel installed alongside",
+			prev:    set(pkg("kernel", "6.1")),
+			current: set(pkg("kernel", "6.1"), pkg("kernel", "6.2")),
+			want:    []change{{EventInstalled, "kernel", "6.2", ""}},
+		},
+		{
+			name:    "kernel rotated",
+			prev:    set(pkg("kernel", "6.1"), pkg("kernel", "6.2")),
+			current: set(pkg("kernel", "6.2"), pkg("kernel", "6.3")),
+			want:    []change{{EventUpgraded, "kernel", "6.3", "6.1"}},
+		},
+		{
+			name:    "two removed one installed",
+			prev:    set(pkg("kernel", "6.1"), pkg("kernel", "6.2")),
+			current: set(pkg("kernel", "6.3")),
+			want: []change{
+				{EventInstalled, "kernel", "6.3", ""},
+				{EventRemoved, "kernel", "", "6.1"},
+				{EventRemoved, "kernel", "", "6.2"},
+			},
+		},
+		{
+			name:    "state saved with keys without version",
+			prev:    map[string]Package{"rpm:kernel:x86_64": pkg("kernel", "6.1")},
+			current: set(pkg("kernel", "6.1"), pkg("kernel", "6.2")),
+			want:    []change{{EventInstalled, "kernel", "6.2", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []change
+			for _, c := range diffPackages(tt.prev, tt.current) {
+				got = append(got, change{c.Event, c.Name, c.Version, c.PrevVersion})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("change %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 沒有 EDNS 時 UDP 回應最大 512 bytes，保留空間給不照規定的 server
+const maxUDPResponse = 4096
+
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"NS":    dnsmessage.TypeNS,
+}
+
+// errOtherResponse 收到的封包不是這次查詢的回應
+var errOtherResponse = errors.New("response does not match query")
+
+type dnsProber struct {
+	cfg     config.DNSProbe
+	timeout time.Duration
+	qtype   dnsmessage.Type
+}
+
+func newDNSProber(cfg config.DNSProbe, timeout time.Duration) (*dnsProber, error) {
+	if cfg.Query == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	cfg.Type = strings.ToUpper(cfg.Type)
+	if cfg.Type == "" {
+		cfg.Type = "A"
+	}
+	qtype, ok := dnsTypes[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %s", cfg.Type)
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Query
+	}
+	if cfg.Resolver != "" {
+		if _, _, err := net.SplitHostPort(cfg.Resolver); err != nil {
+			cfg.Resolver = net.JoinHostPort(cfg.Resolver, "53")
+		}
+	}
+	return &dnsProber{cfg: cfg, timeout: timeout, qtype: qtype}, nil
+}
+
+func (p *dnsProber) name() string {
+	return p.cfg.Name
+}
+
+func (p *dnsProber) run(ctx context.Context, host *service.HostUpdater) any {
+	res := DNSResultJSON{
+		Host:      host.Get(),
+		Category:  Category,
+		Event:     EventResult,
+		Type:      TypeDNS,
+		Name:      p.cfg.Name,
+		Target:    p.cfg.Query,
+		QueryType: p.cfg.Type,
+		Resolver:  p.cfg.Resolver,
+		Answers:   []string{},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := p.lookup(ctx)
+	res.Duration = ms(time.Since(start))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	sort.Strings(answers)
+	res.Answers = append(res.Answers, answers...) // 沒有結果時維持空陣列
+
+	switch {
+	case len(answers) == 0:
+		res.Error = "no answer"
+	case !containsAll(answers, p.cfg.Expect):
+		res.Error = fmt.Sprintf("expected %v", p.cfg.Expect)
+	default:
+		res.Success = true
+	}
+	return res
+}
+
+func (p *dnsProber) lookup(ctx context.Context) ([]string, error) {
+	if p.cfg.Resolver != "" {
+		return p.exchange(ctx)
+	}
+
+	r := net.DefaultResolver
+	var out []string
+	switch p.cfg.Type {
+	case "A", "AAAA":
+		network := "ip4"
+		if p.cfg.Type == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, p.cfg.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			out = append(out, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, p.cfg.Query)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, p.cfg.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			out = append(out, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "TXT":
+		return r.LookupTXT(ctx, p.cfg.Query)
+	case "NS":
+		nss, err := r.LookupNS(ctx, p.cfg.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			out = append(out, ns.Host)
+		}
+	}
+	return out, nil
+}
+
+// exchange 直接送出查詢到指定的 resolver，不經過 /etc/hosts 與 resolv.conf 的 search domain
+// UDP 回應被截斷時改用 TCP 重新查詢
+func (p *dnsProber) exchange(ctx context.Context) ([]string, error) {
+	query := p.cfg.Query
+	if !strings.HasSuffix(query, ".") {
+		query += "."
+	}
+	name, err := dnsmessage.NewName(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", p.cfg.Query, err)
+	}
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: p.qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.roundTrip(ctx, "udp", packed, id)
+	if err == nil && resp.Truncated {
+		resp, err = p.roundTrip(ctx, "tcp", packed, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, fmt.Errorf("lookup %s on %s: no such host", p.cfg.Query, p.cfg.Resolver)
+	default:
+		return nil, fmt.Errorf("lookup %s on %s: %s", p.cfg.Query, p.cfg.Resolver, resp.RCode)
+	}
+
+	var out []string
+	for _, rr := range resp.Answers {
+		// A / AAAA 查詢的回應可能先有 CNAME，只取查詢的類型
+		if rr.Header.Type != p.qtype {
+			continue
+		}
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			out = append(out, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			out = append(out, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			out = append(out, body.CNAME.String())
+		case *dnsmessage.MXResource:
+			out = append(out, fmt.Sprintf("%d %s", body.Pref, body.MX.String()))
+		case *dnsmessage.TXTResource:
+			out = append(out, strings.Join(body.TXT, ""))
+		case *dnsmessage.NSResource:
+			out = append(out, body.NS.String())
+		}
+	}
+	return out, nil
+}
+
+// roundTrip 送出一次查詢並等待 ID 相符的回應；TCP 以兩個位元組的長度前綴分隔訊息
+func (p *dnsProber) roundTrip(ctx context.Context, network string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, p.cfg.Resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		buf := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(buf, uint16(len(query)))
+		copy(buf[2:], query)
+		if _, err := conn.Write(buf); err != nil {
+			return nil, err
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return nil, err
+		}
+		b := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return nil, err
+		}
+		return parseDNSResponse(b, id)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	b := make([]byte, maxUDPResponse)
+	for {
+		n, err := conn.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		// 前一次逾時查詢的遲到回應等，略過繼續等待
+		resp, err := parseDNSResponse(b[:n], id)
+		if errors.Is(err, errOtherResponse) {
+			continue
+		}
+		return resp, err
+	}
+}
+
+// parseDNSResponse 被截斷的回應只解析 header，其餘部分可能不完整
+func parseDNSResponse(b []byte, id uint16) (*dnsmessage.Message, error) {
+	var parser dnsmessage.Parser
+	h, err := parser.Start(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if !h.Response || h.ID != id {
+		return nil, errOtherResponse
+	}
+	if h.Truncated {
+		return &dnsmessage.Message{Header: h}, nil
+	}
+	var m dnsmessage.Message
+	if err := m.Unpack(b); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &m, nil
+}
+
+// containsAll 設定的每個預期值都要出現在結果中（忽略大小寫與結尾的 .）
+func containsAll(answers, expect []string) bool {
+	norm := func(s string) string {
+		return strings.TrimSuffix(strings.ToLower(s), ".")
+	}
+	have := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		have[norm(a)] = true
+	}
+	for _, e := range expect {
+		if !have[norm(e)] {
+			return false
+		}
+	}
+	return true
+}
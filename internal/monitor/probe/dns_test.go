@@ -0,0 +1,231 @@
+package probe
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNSServer 以 UDP 與 TCP 在同一個 port 回應固定的紀錄
+type fakeDNSServer struct {
+	addr string
+
+	mu      sync.Mutex
+	queries []string // 收到的 "network name type"
+}
+
+func mustName(s string) dnsmessage.Name { return dnsmessage.MustNewName(s) }
+
+func startFakeDNS(t *testing.T) *fakeDNSServer {
+	t.Helper()
+	var pc net.PacketConn
+	var ln net.Listener
+	// UDP 隨機取得的 port 在 TCP 上可能已被佔用，重試幾次
+	for i := 0; i < 10 && ln == nil; i++ {
+		var err error
+		if pc, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		if ln, err = net.Listen("tcp", pc.LocalAddr().String()); err != nil {
+			pc.Close()
+		}
+	}
+	if ln == nil {
+		t.Fatal("no free port for UDP and TCP")
+	}
+	s := &fakeDNSServer{addr: pc.LocalAddr().String()}
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := s.answer("udp", buf[:n]); resp != nil {
+				pc.WriteTo(resp, from)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var size [2]byte
+			if _, err := io.ReadFull(conn, size[:]); err == nil {
+				b := make([]byte, binary.BigEndian.Uint16(size[:]))
+				if _, err := io.ReadFull(conn, b); err == nil {
+					resp := s.answer("tcp", b)
+					out := binary.BigEndian.AppendUint16(nil, uint16(len(resp)))
+					conn.Write(append(out, resp...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return s
+}
+
+func (s *fakeDNSServer) answer(network string, b []byte) []byte {
+	var q dnsmessage.Message
+	if err := q.Unpack(b); err != nil || len(q.Questions) != 1 {
+		return nil
+	}
+	question := q.Questions[0]
+	s.mu.Lock()
+	s.queries = append(s.queries, network+" "+question.Name.String()+" "+question.Type.String())
+	s.mu.Unlock()
+
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: q.ID, Response: true, RecursionDesired: q.RecursionDesired, RecursionAvailable: true},
+		Questions: q.Questions,
+	}
+	// Pack 會依 body 填入 header 的類型
+	rr := func(body dnsmessage.ResourceBody) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   body,
+		}
+	}
+
+	switch question.Name.String() + " " + question.Type.String() {
+	case "localhost. TypeA":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})}
+	case "www.example.test. TypeA":
+		resp.Answers = []dnsmessage.Resource{
+			rr(&dnsmessage.CNAMEResource{CNAME: mustName("edge.example.test.")}),
+			rr(&dnsmessage.AResource{A: [4]byte{192, 0, 2, 11}}),
+			rr(&dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}}),
+		}
+	case "www.example.test. TypeAAAA":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})}
+	case "www.example.test. TypeCNAME":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.CNAMEResource{CNAME: mustName("edge.example.test.")})}
+	case "example.test. TypeMX":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.MXResource{Pref: 10, MX: mustName("mail.example.test.")})}
+	case "example.test. TypeTXT":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}})}
+	case "example.test. TypeNS":
+		resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.NSResource{NS: mustName("ns1.example.test.")})}
+	case "big.example.test. TypeA":
+		if network == "udp" {
+			resp.Truncated = true
+		} else {
+			resp.Answers = []dnsmessage.Resource{rr(&dnsmessage.AResource{A: [4]byte{192, 0, 2, 99}})}
+		}
+	case "broken.example.test. TypeA":
+		resp.RCode = dnsmessage.RCodeServerFailure
+	case "empty.example.test. TypeA":
+	default:
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+	out, _ := resp.Pack()
+	return out
+}
+
+func (s *fakeDNSServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func TestDNSProbe(t *testing.T) {
+	srv := startFakeDNS(t)
+
+	tests := []struct {
+		name    string
+		cfg     config.DNSProbe
+		success bool
+		answers []string
+		err     string
+	}{
+		// 指定 resolver 時不讀 /etc/hosts
+		{"hosts file bypassed", config.DNSProbe{Query: "localhost"}, true, []string{"192.0.2.1"}, ""},
+		{"A after CNAME", config.DNSProbe{Query: "www.example.test", Expect: []string{"192.0.2.10"}}, true, []string{"192.0.2.10", "192.0.2.11"}, ""},
+		{"AAAA", config.DNSProbe{Query: "www.example.test", Type: "aaaa"}, true, []string{"2001:db8::1"}, ""},
+		{"CNAME", config.DNSProbe{Query: "www.example.test.", Type: "CNAME", Expect: []string{"EDGE.example.test"}}, true, []string{"edge.example.test."}, ""},
+		{"MX", config.DNSProbe{Query: "example.test", Type: "MX"}, true, []string{"10 mail.example.test."}, ""},
+		{"TXT", config.DNSProbe{Query: "example.test", Type: "TXT", Expect: []string{"v=spf1 -all"}}, true, []string{"v=spf1 -all"}, ""},
+		{"NS", config.DNSProbe{Query: "example.test", Type: "NS"}, true, []string{"ns1.example.test."}, ""},
+		{"truncated falls back to TCP", config.DNSProbe{Query: "big.example.test"}, true, []string{"192.0.2.99"}, ""},
+		{"unexpected answer", config.DNSProbe{Query: "www.example.test", Expect: []string{"192.0.2.12"}}, false, []string{"192.0.2.10", "192.0.2.11"}, "expected [192.0.2.12]"},
+		{"no answer", config.DNSProbe{Query: "empty.example.test"}, false, []string{}, "no answer"},
+		{"nxdomain", config.DNSProbe{Query: "missing.example.test"}, false, []string{}, "lookup missing.example.test on " + srv.addr + ": no such host"},
+		{"servfail", config.DNSProbe{Query: "broken.example.test"}, false, []string{}, "lookup broken.example.test on " + srv.addr + ": RCodeServerFailure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Resolver = srv.addr
+			p, err := newDNSProber(tt.cfg, 2*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := p.run(t.Context(), &service.HostUpdater{}).(DNSResultJSON)
+			if res.Success != tt.success || !reflect.DeepEqual(res.Answers, tt.answers) || res.Error != tt.err {
+				t.Errorf("result = success %v answers %q error %q, want %v %q %q", res.Success, res.Answers, res.Error, tt.success, tt.answers, tt.err)
+			}
+		})
+	}
+
+	// 查詢名稱原樣送出，不加上 search domain
+	p, _ := newDNSProber(config.DNSProbe{Query: "intranet", Resolver: srv.addr}, 2*time.Second)
+	p.run(t.Context(), &service.HostUpdater{})
+	got := srv.received()
+	if last := got[len(got)-1]; last != "udp intranet. TypeA" {
+		t.Errorf("last query = %q, want %q", last, "udp intranet. TypeA")
+	}
+	for _, q := range got {
+		if q == "tcp big.example.test. TypeA" {
+			return
+		}
+	}
+	t.Errorf("no TCP retry in %q", got)
+}
+
+func TestNewDNSProber(t *testing.T) {
+	p, err := newDNSProber(config.DNSProbe{Query: "example.test", Resolver: "192.0.2.53"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.cfg.Resolver != "192.0.2.53:53" || p.cfg.Type != "A" || p.qtype != dnsmessage.TypeA || p.name() != "example.test" {
+		t.Errorf("prober = %+v", p.cfg)
+	}
+	if p, _ := newDNSProber(config.DNSProbe{Query: "example.test", Resolver: "[2001:db8::53]:5353"}, time.Second); p.cfg.Resolver != "[2001:db8::53]:5353" {
+		t.Errorf("resolver = %q", p.cfg.Resolver)
+	}
+
+	for _, cfg := range []config.DNSProbe{{}, {Query: "example.test", Type: "SRV"}} {
+		if _, err := newDNSProber(cfg, time.Second); err == nil {
+			t.Errorf("config %+v accepted", cfg)
+		}
+	}
+}
+
+// 收到其他查詢的回應（ID 不符）時繼續等待
+func TestParseDNSResponse(t *testing.T) {
+	msg := dnsmessage.Message{Header: dnsmessage.Header{ID: 7, Response: true}}
+	b, _ := msg.Pack()
+	if _, err := parseDNSResponse(b, 8); err != errOtherResponse {
+		t.Errorf("mismatched ID: %v", err)
+	}
+	if m, err := parseDNSResponse(b, 7); err != nil || m.ID != 7 {
+		t.Errorf("matching ID: %+v, %v", m, err)
+	}
+	if _, err := parseDNSResponse([]byte{0, 7}, 7); err == nil || err == errOtherResponse {
+		t.Errorf("short packet: %v", err)
+	}
+}
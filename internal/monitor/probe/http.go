@@ -0,0 +1,209 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"time"
+)
+
+// 比對 body_regex 時最多讀取的回應內容
+const maxBodyBytes = 1 << 20
+
+type httpProber struct {
+	cfg     config.HTTPProbe
+	timeout time.Duration
+	body    *regexp.Regexp
+	client  *http.Client
+}
+
+func newHTTPProber(cfg config.HTTPProbe, timeout time.Duration) (*httpProber, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is empty")
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.URL
+	}
+
+	p := &httpProber{cfg: cfg, timeout: timeout}
+	if cfg.BodyRegex != "" {
+		re, err := regexp.Compile(cfg.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("body_regex: %w", err)
+		}
+		p.body = re
+	}
+
+	// 不重用連線，每次都量測完整的 DNS / 連線 / TLS 時間
+	p.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			// 不跟隨轉址，直接回報 3xx 狀態碼
+			return http.ErrUseLastResponse
+		},
+	}
+	return p, nil
+}
+
+func (p *httpProber) name() string {
+	return p.cfg.Name
+}
+
+func (p *httpProber) run(ctx context.Context, host *service.HostUpdater) any {
+	res := HTTPResultJSON{
+		Host:      host.Get(),
+		Category:  Category,
+		Event:     EventResult,
+		Type:      TypeHTTP,
+		Name:      p.cfg.Name,
+		Target:    p.cfg.URL,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	tr := &httpTrace{connStart: make(map[string]time.Time)}
+	// 離開前取出量測結果；之後才結束的連線嘗試不會再寫入 res
+	finish := func() HTTPResultJSON {
+		tr.fill(&res)
+		return res
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, tr.clientTrace()), p.cfg.Method, p.cfg.URL, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if h, ok := p.cfg.Headers["Host"]; ok {
+		req.Host = h
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		res.Timing.Total = ms(time.Since(start))
+		res.Error = err.Error()
+		return finish()
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	res.Timing.Total = ms(time.Since(start))
+	res.StatusCode = resp.StatusCode
+	if err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+
+	res.BodyMatched = p.body == nil || p.body.Match(body)
+	statusOK := p.statusOK(resp.StatusCode)
+	res.Success = statusOK && res.BodyMatched
+	switch {
+	case !statusOK:
+		res.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	case !res.BodyMatched:
+		res.Error = "body does not match " + p.cfg.BodyRegex
+	}
+	return finish()
+}
+
+func (p *httpProber) statusOK(code int) bool {
+	if len(p.cfg.ExpectStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range p.cfg.ExpectStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// httpTrace 收集各階段耗時。Happy Eyeballs 會同時連線多個位址，callback 可能並行，
+// 落後的連線嘗試甚至在請求結束後才回呼，因此一律在鎖內存取
+type httpTrace struct {
+	mu         sync.Mutex
+	dnsStart   time.Time
+	connStart  map[string]time.Time // 位址 → 開始連線的時間
+	tlsStart   time.Time
+	wrote      time.Time
+	timing     HTTPTiming
+	remoteAddr string // 第一個連線成功的位址
+}
+
+func (t *httpTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.DNS = ms(time.Since(t.dnsStart))
+		},
+		ConnectStart: func(_, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connStart[addr] = time.Now()
+		},
+		ConnectDone: func(_, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// 只記錄第一個成功的連線，其他位址的嘗試會被取消
+			if err == nil && t.remoteAddr == "" {
+				t.timing.Connect = ms(time.Since(t.connStart[addr]))
+				t.remoteAddr = addr
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.TLS = ms(time.Since(t.tlsStart))
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wrote = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.TTFB = ms(time.Since(t.wrote))
+		},
+	}
+}
+
+// fill 把目前的量測結果寫入 res，Total 由呼叫端計算
+func (t *httpTrace) fill(res *HTTPResultJSON) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := res.Timing.Total
+	res.Timing = t.timing
+	res.Timing.Total = total
+	res.RemoteAddr = t.remoteAddr
+}
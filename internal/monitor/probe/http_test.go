@@ -0,0 +1,89 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"testing"
+	"time"
+)
+
+func TestHTTPProbe(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		cfg     config.HTTPProbe
+		success bool
+		status  int
+	}{
+		{"ok", config.HTTPProbe{URL: srv.URL, BodyRegex: `"status":"ok"`}, true, 200},
+		{"body mismatch", config.HTTPProbe{URL: srv.URL, BodyRegex: `degraded`}, false, 200},
+		{"unexpected status", config.HTTPProbe{URL: srv.URL + "/missing"}, false, 404},
+		{"expected status", config.HTTPProbe{URL: srv.URL + "/missing", ExpectStatus: []int{404}}, true, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.InsecureSkipVerify = true
+			p, err := newHTTPProber(tt.cfg, 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := p.run(context.Background(), &service.HostUpdater{}).(HTTPResultJSON)
+			if res.Success != tt.success || res.StatusCode != tt.status {
+				t.Fatalf("result = %+v", res)
+			}
+			if res.RemoteAddr != srv.Listener.Addr().String() || res.Timing.Connect <= 0 || res.Timing.TLS <= 0 || res.Timing.Total < res.Timing.TTFB {
+				t.Errorf("trace = %s %+v", res.RemoteAddr, res.Timing)
+			}
+		})
+	}
+}
+
+// Happy Eyeballs 同時連線多個位址時，只記錄第一個成功的連線
+func TestHTTPTraceConcurrentConnect(t *testing.T) {
+	tr := &httpTrace{connStart: make(map[string]time.Time)}
+	ct := tr.clientTrace()
+
+	// IPv6 失敗，兩個 IPv4 先後連上
+	addrs := []string{"[2001:db8::1]:443", "192.0.2.1:443", "192.0.2.2:443"}
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ct.ConnectStart("tcp", addr)
+			time.Sleep(time.Duration(i) * 20 * time.Millisecond)
+			if i == 0 {
+				ct.ConnectDone("tcp", addr, context.Canceled)
+				return
+			}
+			ct.ConnectDone("tcp", addr, nil)
+		}()
+	}
+
+	// 請求結束後落後的連線仍可能回呼，讀取結果不能與之競爭
+	var res HTTPResultJSON
+	for range 10 {
+		tr.fill(&res)
+	}
+	wg.Wait()
+	tr.fill(&res)
+
+	if res.RemoteAddr != addrs[1] {
+		t.Errorf("remote addr = %s, want the first successful %s", res.RemoteAddr, addrs[1])
+	}
+	if res.Timing.Connect < 15 {
+		t.Errorf("connect = %vms, want the duration of %s", res.Timing.Connect, addrs[1])
+	}
+}
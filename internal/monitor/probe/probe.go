@@ -0,0 +1,168 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "PROBE"
+
+// 探測種類
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypeDNS  = "dns"
+)
+
+const EventResult = "PROBE_RESULT"
+
+const (
+	defaultInterval = 60 // 秒
+	defaultTimeout  = 10 // 秒
+)
+
+// HTTPTiming 各階段耗時（毫秒），連線重用或沒有該階段時為 0
+type HTTPTiming struct {
+	DNS     float64 `json:"DNS"`
+	Connect float64 `json:"Connect"`
+	TLS     float64 `json:"TLS"`
+	TTFB    float64 `json:"TTFB"` // 送出請求到收到第一個 byte
+	Total   float64 `json:"Total"`
+}
+
+// HTTPResultJSON HTTP 探測結果
+type HTTPResultJSON struct {
+	Host        service.HostInfo `json:"Host"`
+	Category    string           `json:"Category"`
+	Event       string           `json:"Event"`
+	Type        string           `json:"Type"`
+	Name        string           `json:"Name"`
+	Target      string           `json:"Target"` // URL
+	Success     bool             `json:"Success"`
+	Error       string           `json:"Error"`
+	StatusCode  int              `json:"StatusCode"`
+	BodyMatched bool             `json:"BodyMatched"` // 未設定 body_regex 時為 true
+	RemoteAddr  string           `json:"RemoteAddr"`
+	Timing      HTTPTiming       `json:"Timing"`
+	Timestamp   string           `json:"Timestamp"`
+}
+
+// TCPResultJSON TCP 連線探測結果
+type TCPResultJSON struct {
+	Host       service.HostInfo `json:"Host"`
+	Category   string           `json:"Category"`
+	Event      string           `json:"Event"`
+	Type       string           `json:"Type"`
+	Name       string           `json:"Name"`
+	Target     string           `json:"Target"` // host:port
+	Success    bool             `json:"Success"`
+	Error      string           `json:"Error"`
+	RemoteAddr string           `json:"RemoteAddr"`
+	Duration   float64          `json:"Duration"` // 毫秒
+	Timestamp  string           `json:"Timestamp"`
+}
+
+// DNSResultJSON DNS 解析探測結果
+type DNSResultJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Type      string           `json:"Type"`
+	Name      string           `json:"Name"`
+	Target    string           `json:"Target"` // 查詢的名稱
+	QueryType string           `json:"QueryType"`
+	Resolver  string           `json:"Resolver"` // 空字串表示系統設定
+	Success   bool             `json:"Success"`
+	Error     string           `json:"Error"`
+	Answers   []string         `json:"Answers"`
+	Duration  float64          `json:"Duration"` // 毫秒
+	Timestamp string           `json:"Timestamp"`
+}
+
+// prober 執行一次探測並回傳結果
+type prober interface {
+	name() string
+	run(ctx context.Context, host *service.HostUpdater) any
+}
+
+// Start 每個探測各自一個 goroutine，依自己的 interval 執行
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+
+	timeoutOf := func(sec int) time.Duration {
+		if sec <= 0 {
+			sec = cfg.Probe.Timeout
+		}
+		if sec <= 0 {
+			sec = defaultTimeout
+		}
+		return time.Duration(sec) * time.Second
+	}
+	intervalOf := func(sec int) time.Duration {
+		if sec <= 0 {
+			sec = cfg.Probe.Interval
+		}
+		if sec <= 0 {
+			sec = defaultInterval
+		}
+		return time.Duration(sec) * time.Second
+	}
+
+	for _, p := range cfg.Probe.HTTP {
+		hp, err := newHTTPProber(p, timeoutOf(p.Timeout))
+		if err != nil {
+			utils.Log.Error("[Probe] http %s 設定錯誤: %v", p.Name, err)
+			continue
+		}
+		startProbe(ctx, hp, intervalOf(p.Interval), host, logger)
+	}
+	for _, p := range cfg.Probe.TCP {
+		startProbe(ctx, &tcpProber{cfg: p, timeout: timeoutOf(p.Timeout)}, intervalOf(p.Interval), host, logger)
+	}
+	for _, p := range cfg.Probe.DNS {
+		dp, err := newDNSProber(p, timeoutOf(p.Timeout))
+		if err != nil {
+			utils.Log.Error("[Probe] dns %s 設定錯誤: %v", p.Name, err)
+			continue
+		}
+		startProbe(ctx, dp, intervalOf(p.Interval), host, logger)
+	}
+}
+
+func startProbe(ctx context.Context, p prober, interval time.Duration, host *service.HostUpdater, logger interface {
+	Write(data any) error
+}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Probe] %s goroutine panic: %v", p.name(), r)
+				startProbe(ctx, p, interval, host, logger)
+			}
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			b, _ := json.Marshal(p.run(ctx, host))
+			utils.Log.Debug("%s", string(b))
+			logger.Write(b)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				utils.Log.Info("[Probe] %s 已停止", p.name())
+				return
+			}
+		}
+	}()
+}
+
+// ms 轉成毫秒
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,47 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"time"
+)
+
+type tcpProber struct {
+	cfg     config.TCPProbe
+	timeout time.Duration
+}
+
+func (p *tcpProber) name() string {
+	if p.cfg.Name == "" {
+		return p.cfg.Address
+	}
+	return p.cfg.Name
+}
+
+func (p *tcpProber) run(ctx context.Context, host *service.HostUpdater) any {
+	res := TCPResultJSON{
+		Host:      host.Get(),
+		Category:  Category,
+		Event:     EventResult,
+		Type:      TypeTCP,
+		Name:      p.name(),
+		Target:    p.cfg.Address,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	d := net.Dialer{Timeout: p.timeout}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", p.cfg.Address)
+	res.Duration = ms(time.Since(start))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.RemoteAddr = conn.RemoteAddr().String()
+	conn.Close()
+
+	res.Success = true
+	return res
+}
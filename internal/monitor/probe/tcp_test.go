@@ -0,0 +1,79 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"testing"
+	"time"
+)
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// 先取得一個沒有在監聽的 port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		cfg     config.TCPProbe
+		success bool
+		want    string // 結果的 Name
+	}{
+		{"open", config.TCPProbe{Address: ln.Addr().String()}, true, ln.Addr().String()},
+		{"named", config.TCPProbe{Name: "collector", Address: ln.Addr().String()}, true, "collector"},
+		{"refused", config.TCPProbe{Address: closedAddr}, false, closedAddr},
+		{"invalid address", config.TCPProbe{Address: "no-port"}, false, "no-port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &tcpProber{cfg: tt.cfg, timeout: 2 * time.Second}
+			res := p.run(t.Context(), &service.HostUpdater{}).(TCPResultJSON)
+			if res.Success != tt.success || res.Name != tt.want || res.Target != tt.cfg.Address || res.Type != TypeTCP {
+				t.Errorf("result = %+v", res)
+			}
+			if tt.success && (res.RemoteAddr != ln.Addr().String() || res.Error != "") {
+				t.Errorf("remote %q error %q", res.RemoteAddr, res.Error)
+			}
+			if !tt.success && res.Error == "" {
+				t.Error("failure without error")
+			}
+		})
+	}
+}
+
+// 探測週期結束（context 取消）時連線失敗
+func TestTCPProbeCanceled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	p := &tcpProber{cfg: config.TCPProbe{Address: ln.Addr().String()}, timeout: 2 * time.Second}
+	res := p.run(ctx, &service.HostUpdater{}).(TCPResultJSON)
+	if res.Success || res.Error == "" {
+		t.Errorf("canceled probe = %+v", res)
+	}
+}
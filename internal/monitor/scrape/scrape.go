@@ -0,0 +1,222 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "SCRAPE"
+
+// 事件種類
+const (
+	EventMetric = "METRIC"        // 單一樣本
+	EventStatus = "SCRAPE_STATUS" // 每次抓取的結果，相當於 Prometheus 的 up
+)
+
+const (
+	defaultInterval = 60 // 秒
+	defaultTimeout  = 10 // 秒
+
+	maxBodyBytes = 16 << 20
+	acceptHeader = "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1"
+)
+
+// MetricJSON 單一樣本
+type MetricJSON struct {
+	Host      service.HostInfo  `json:"Host"`
+	Category  string            `json:"Category"`
+	Event     string            `json:"Event"`
+	Job       string            `json:"Job"`    // 目標名稱
+	Target    string            `json:"Target"` // URL
+	Name      string            `json:"Name"`
+	Type      string            `json:"Type"`
+	Labels    map[string]string `json:"Labels"`
+	Value     float64           `json:"Value"`
+	Timestamp string            `json:"Timestamp"` // 樣本本身有時間戳記時使用它，否則為抓取時間
+}
+
+// StatusJSON 單次抓取的結果
+type StatusJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Job       string           `json:"Job"`
+	Target    string           `json:"Target"`
+	Up        bool             `json:"Up"`
+	Error     string           `json:"Error"`
+	Duration  float64          `json:"Duration"` // 毫秒
+	Samples   int              `json:"Samples"`  // 解析出的樣本數
+	Kept      int              `json:"Kept"`     // 經過 allow / deny 後輸出的樣本數
+	Timestamp string           `json:"Timestamp"`
+}
+
+// Start 每個目標各自一個 goroutine，依自己的 interval 抓取
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+
+	for _, t := range cfg.Scrape.Targets {
+		if t.URL == "" {
+			utils.Log.Error("[Scrape] %s 設定錯誤: url is empty", t.Name)
+			continue
+		}
+		if t.Name == "" {
+			t.Name = t.URL
+		}
+		if t.Interval <= 0 {
+			t.Interval = cfg.Scrape.Interval
+		}
+		if t.Interval <= 0 {
+			t.Interval = defaultInterval
+		}
+		if t.Timeout <= 0 {
+			t.Timeout = cfg.Scrape.Timeout
+		}
+		if t.Timeout <= 0 {
+			t.Timeout = defaultTimeout
+		}
+		startTarget(ctx, t, host, logger)
+	}
+}
+
+func startTarget(ctx context.Context, t config.ScrapeTarget, host *service.HostUpdater, logger interface {
+	Write(data any) error
+}) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Scrape] %s goroutine panic: %v", t.Name, r)
+				startTarget(ctx, t, host, logger)
+			}
+		}()
+
+		client := &http.Client{Timeout: time.Duration(t.Timeout) * time.Second}
+		ticker := time.NewTicker(time.Duration(t.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			for _, line := range scrapeOnce(ctx, client, t, host) {
+				logger.Write(line)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				utils.Log.Info("[Scrape] %s 已停止", t.Name)
+				return
+			}
+		}
+	}()
+}
+
+// scrapeOnce 抓取一次，回傳所有樣本與一筆抓取結果
+func scrapeOnce(ctx context.Context, client *http.Client, t config.ScrapeTarget, host *service.HostUpdater) [][]byte {
+	now := time.Now()
+	info := host.Get()
+	status := StatusJSON{
+		Host:      info,
+		Category:  Category,
+		Event:     EventStatus,
+		Job:       t.Name,
+		Target:    t.URL,
+		Timestamp: now.Format(time.RFC3339),
+	}
+
+	var out [][]byte
+	samples, err := fetch(ctx, client, t)
+	status.Duration = float64(time.Since(now).Microseconds()) / 1000
+	status.Samples = len(samples)
+	if err != nil {
+		status.Error = err.Error()
+		utils.Log.Error("[Scrape] %s fail: %v", t.Name, err)
+	}
+	// 解析到一半出錯時，已解析的樣本仍然輸出
+	status.Up = err == nil
+
+	for _, s := range samples {
+		if !keep(s.Name, t.Allow, t.Deny) {
+			continue
+		}
+		labels := make(map[string]string, len(t.Labels)+len(s.Labels))
+		for k, v := range t.Labels {
+			labels[k] = v
+		}
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+
+		ts := now
+		if s.Timestamp > 0 {
+			ts = time.UnixMilli(s.Timestamp)
+		}
+		data := MetricJSON{
+			Host:      info,
+			Category:  Category,
+			Event:     EventMetric,
+			Job:       t.Name,
+			Target:    t.URL,
+			Name:      s.Name,
+			Type:      s.Type,
+			Labels:    labels,
+			Value:     s.Value,
+			Timestamp: ts.Format(time.RFC3339),
+		}
+		b, _ := json.Marshal(data)
+		out = append(out, b)
+	}
+	status.Kept = len(out)
+
+	b, _ := json.Marshal(status)
+	utils.Log.Debug("%s", string(b))
+	return append(out, b)
+}
+
+func fetch(ctx context.Context, client *http.Client, t config.ScrapeTarget) ([]utils.PromSample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	openMetrics := strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text")
+	return utils.ParsePromText(io.LimitReader(resp.Body, maxBodyBytes), openMetrics)
+}
+
+// keep deny 優先；allow 為空表示全部允許
+func keep(name string, allow, deny []string) bool {
+	for _, p := range deny {
+		if ok, _ := path.Match(p, name); ok {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, p := range allow {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
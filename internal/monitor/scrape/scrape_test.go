@@ -0,0 +1,44 @@
+package scrape
+
+import (
+	"os"
+	"sysprobe/internal/config"
+	"sysprobe/internal/utils"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestKeep(t *testing.T) {
+	allow := []string{"http_*", "process_cpu_seconds_total"}
+	deny := []string{"http_*_bucket", "go_*"}
+	tests := []struct {
+		name        string
+		allow, deny []string
+		want        bool
+	}{
+		{"anything", nil, nil, true},
+		{"go_goroutines", nil, deny, false},
+		{"node_load1", nil, deny, true},
+		{"http_requests_total", allow, deny, true},
+		{"process_cpu_seconds_total", allow, deny, true},
+		{"node_load1", allow, deny, false},
+		// deny 優先於 allow
+		{"http_request_duration_seconds_bucket", allow, deny, false},
+		{"http_request_duration_seconds_sum", allow, deny, true},
+		// 格式錯誤的 pattern 不符合任何名稱
+		{"http_requests_total", []string{"["}, nil, false},
+		{"http_requests_total", nil, []string{"["}, true},
+	}
+	for _, tt := range tests {
+		if got := keep(tt.name, tt.allow, tt.deny); got != tt.want {
+			t.Errorf("keep(%q, %q, %q) = %v, want %v", tt.name, tt.allow, tt.deny, got, tt.want)
+		}
+	}
+}
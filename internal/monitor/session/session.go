@@ -0,0 +1,282 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "SESSION"
+
+const (
+	defaultUtmp = "/var/run/utmp"
+	defaultWtmp = "/var/log/wtmp"
+)
+
+// 事件種類
+const (
+	EventActive = "SESSION_ACTIVE" // 目前登入中的使用者
+	EventLogin  = "SESSION_LOGIN"
+	EventLogout = "SESSION_LOGOUT"
+	EventBoot   = "SYSTEM_BOOT"
+)
+
+// Session 單一登入連線
+type Session struct {
+	User       string `json:"User"`
+	TTY        string `json:"TTY"`
+	RemoteHost string `json:"RemoteHost"`
+	RemoteAddr string `json:"RemoteAddr"`
+	PID        int32  `json:"PID"`
+	LoginTime  string `json:"LoginTime"`
+	Duration   int64  `json:"Duration"` // 秒
+}
+
+// ActiveJSON 目前登入中的使用者
+type ActiveJSON struct {
+	Host      service.HostInfo `json:"Host"`
+	Category  string           `json:"Category"`
+	Event     string           `json:"Event"`
+	Count     int              `json:"Count"`
+	Sessions  []Session        `json:"Sessions"`
+	Timestamp string           `json:"Timestamp"`
+}
+
+// EventJSON 登入、登出或開機事件（來自 wtmp）
+type EventJSON struct {
+	Host       service.HostInfo `json:"Host"`
+	Category   string           `json:"Category"`
+	Event      string           `json:"Event"`
+	User       string           `json:"User"`
+	TTY        string           `json:"TTY"`
+	RemoteHost string           `json:"RemoteHost"`
+	RemoteAddr string           `json:"RemoteAddr"`
+	PID        int32            `json:"PID"`
+	LoginTime  string           `json:"LoginTime"`
+	LogoutTime string           `json:"LogoutTime"`
+	Duration   int64            `json:"Duration"` // 秒，只有登出事件有值
+	Timestamp  string           `json:"Timestamp"`
+}
+
+// sessionState 持久化於 monitor.data
+type sessionState struct {
+	WtmpOffset int64             `json:"wtmpOffset"`
+	Open       map[string]record `json:"open"` // TTY → 登入紀錄，用來計算登出時的連線時間
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Session] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		utmp, wtmp := cfg.Session.Utmp, cfg.Session.Wtmp
+		if utmp == "" {
+			utmp = defaultUtmp
+		}
+		if wtmp == "" {
+			wtmp = defaultWtmp
+		}
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		statePath := cfg.Data + "/session.json"
+
+		state, ok := loadState(statePath)
+		if !ok {
+			// 第一次啟動：不回放 wtmp 歷史，以 utmp 目前的登入作為基準
+			state = initState(utmp, wtmp)
+		}
+
+		ticker := time.NewTicker(time.Duration(cfg.Session.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, line := range readWtmp(wtmp, &state, host) {
+					logger.Write(line)
+				}
+				if err := saveState(statePath, state); err != nil {
+					utils.Log.Error("[Session] 無法儲存狀態: %v", err)
+				}
+
+				if b := activeSessions(utmp, host); len(b) > 0 {
+					logger.Write(b)
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Session] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+func initState(utmp, wtmp string) sessionState {
+	state := sessionState{Open: make(map[string]record)}
+
+	if fi, err := os.Stat(wtmp); err == nil {
+		state.WtmpOffset = fi.Size() - fi.Size()%recordSize
+	}
+
+	records, _, err := readRecords(utmp, 0)
+	if err == nil {
+		for _, r := range records {
+			if r.Type == utUserProcess {
+				state.Open[r.Line] = r
+			}
+		}
+	}
+	return state
+}
+
+// readWtmp 讀取新增的 wtmp 紀錄，轉成登入 / 登出事件
+func readWtmp(path string, state *sessionState, host *service.HostUpdater) [][]byte {
+	records, offset, err := readRecords(path, state.WtmpOffset)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Log.Error("[Session] read %s fail: %v", path, err)
+		}
+		return nil
+	}
+	state.WtmpOffset = offset
+
+	var out [][]byte
+	emit := func(data EventJSON) {
+		data.Host = host.Get()
+		data.Category = Category
+		data.Timestamp = time.Now().Format(time.RFC3339)
+		b, _ := json.Marshal(data)
+		utils.Log.Debug("%s", string(b))
+		out = append(out, b)
+	}
+
+	logout := func(login record, at time.Time) {
+		emit(EventJSON{
+			Event:      EventLogout,
+			User:       login.User,
+			TTY:        login.Line,
+			RemoteHost: login.Host,
+			RemoteAddr: login.Addr,
+			PID:        login.PID,
+			LoginTime:  login.Time.Format(time.RFC3339),
+			LogoutTime: at.Format(time.RFC3339),
+			Duration:   int64(at.Sub(login.Time).Seconds()),
+		})
+	}
+
+	for _, r := range records {
+		switch r.Type {
+		case utUserProcess:
+			// 同一個 TTY 沒有登出紀錄又再次登入，視為前一筆已結束
+			if prev, ok := state.Open[r.Line]; ok {
+				logout(prev, r.Time)
+			}
+			state.Open[r.Line] = r
+			emit(EventJSON{
+				Event:      EventLogin,
+				User:       r.User,
+				TTY:        r.Line,
+				RemoteHost: r.Host,
+				RemoteAddr: r.Addr,
+				PID:        r.PID,
+				LoginTime:  r.Time.Format(time.RFC3339),
+			})
+		case utDeadProcess:
+			if login, ok := state.Open[r.Line]; ok {
+				logout(login, r.Time)
+				delete(state.Open, r.Line)
+			}
+		case utBootTime:
+			// 重新開機：所有未登出的連線都已結束
+			for line, login := range state.Open {
+				logout(login, r.Time)
+				delete(state.Open, line)
+			}
+			emit(EventJSON{
+				Event:     EventBoot,
+				LoginTime: r.Time.Format(time.RFC3339),
+			})
+		}
+	}
+	return out
+}
+
+// activeSessions 解析 utmp，回報目前登入中的使用者
+func activeSessions(path string, host *service.HostUpdater) []byte {
+	records, _, err := readRecords(path, 0)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.Log.Error("[Session] read %s fail: %v", path, err)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	sessions := []Session{}
+	for _, r := range records {
+		if r.Type != utUserProcess {
+			continue
+		}
+		sessions = append(sessions, Session{
+			User:       r.User,
+			TTY:        r.Line,
+			RemoteHost: r.Host,
+			RemoteAddr: r.Addr,
+			PID:        r.PID,
+			LoginTime:  r.Time.Format(time.RFC3339),
+			Duration:   int64(now.Sub(r.Time).Seconds()),
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].TTY < sessions[j].TTY
+	})
+
+	data := ActiveJSON{
+		Host:      host.Get(),
+		Category:  Category,
+		Event:     EventActive,
+		Count:     len(sessions),
+		Sessions:  sessions,
+		Timestamp: now.Format(time.RFC3339),
+	}
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("%s", string(b))
+	return b
+}
+
+// ------------------------- State -------------------------
+func loadState(path string) (sessionState, bool) {
+	var s sessionState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return sessionState{}, false
+	}
+	if s.Open == nil {
+		s.Open = make(map[string]record)
+	}
+	return s, true
+}
+
+func saveState(path string, s sessionState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
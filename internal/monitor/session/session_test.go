@@ -0,0 +1,177 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testdata/utmp、testdata/wtmp 的時間以 t0 為基準
+var t0 = time.Unix(1760860800, 0)
+
+func TestReadUtmpFixture(t *testing.T) {
+	records, offset, err := readRecords("testdata/utmp", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 6 || offset != 6*recordSize {
+		t.Fatalf("got %d records, offset %d", len(records), offset)
+	}
+
+	tests := []record{
+		{Type: utBootTime, Line: "~", User: "reboot", Host: "6.8.0-45-generic", Time: t0},
+		{Type: 1, Line: "~", User: "runlevel", Host: "6.8.0-45-generic", Time: t0.Add(5 * time.Second)},
+		{Type: 6, PID: 812, Line: "tty1", User: "LOGIN", Time: t0.Add(6 * time.Second)},
+		{Type: utUserProcess, PID: 2101, Line: "pts/0", User: "alice", Host: "192.168.1.20", Addr: "192.168.1.20", Time: t0.Add(60*time.Second + 250*time.Millisecond)},
+		{Type: utUserProcess, PID: 2202, Line: "pts/1", User: "bob", Host: "laptop.example.com", Addr: "2001:db8::5", Time: t0.Add(120 * time.Second)},
+		{Type: utDeadProcess, PID: 1990, Line: "pts/2", Time: t0.Add(30 * time.Second)},
+	}
+	for i, want := range tests {
+		got := records[i]
+		if got.Type != want.Type || got.PID != want.PID || got.Line != want.Line || got.User != want.User ||
+			got.Host != want.Host || got.Addr != want.Addr || !got.Time.Equal(want.Time) {
+			t.Errorf("record %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestReadRecordsPartial(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/wtmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "wtmp")
+
+	// 不完整的最後一筆留到下一輪
+	os.WriteFile(path, fixture[:2*recordSize+100], 0644)
+	records, offset, err := readRecords(path, 0)
+	if err != nil || len(records) != 2 || offset != 2*recordSize {
+		t.Fatalf("partial: %d records, offset %d, err %v", len(records), offset, err)
+	}
+
+	os.WriteFile(path, fixture, 0644)
+	records, offset, _ = readRecords(path, offset)
+	if len(records) != 5 || records[0].User != "bob" || offset != int64(len(fixture)) {
+		t.Fatalf("resume: %d records, first %+v, offset %d", len(records), records[0], offset)
+	}
+
+	// 檔案被截斷，從頭開始
+	os.WriteFile(path, fixture[:recordSize], 0644)
+	records, offset, _ = readRecords(path, offset)
+	if len(records) != 1 || records[0].Type != utBootTime || offset != recordSize {
+		t.Fatalf("truncated: %d records, offset %d", len(records), offset)
+	}
+}
+
+func TestReadWtmp(t *testing.T) {
+	state := sessionState{Open: make(map[string]record)}
+	lines := readWtmp("testdata/wtmp", &state, &service.HostUpdater{})
+
+	var events []EventJSON
+	for _, line := range lines {
+		var e EventJSON
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	type want struct {
+		event    string
+		user     string
+		addr     string
+		duration int64
+	}
+	wants := []want{
+		{EventBoot, "", "", 0},
+		{EventLogin, "alice", "192.168.1.20", 0},
+		{EventLogin, "bob", "2001:db8::5", 0},
+		{EventLogout, "alice", "192.168.1.20", 600},
+		{EventLogin, "carol", "10.0.0.7", 0},
+		{EventLogout, "carol", "10.0.0.7", 100}, // 同一個 TTY 再次登入
+		{EventLogin, "dave", "10.0.0.8", 0},
+		{EventLogout, "", "", 0}, // 開機時結束的連線，順序不固定，另外檢查
+		{EventLogout, "", "", 0},
+		{EventBoot, "", "", 0},
+	}
+	if len(events) != len(wants) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(wants), events)
+	}
+	for i, w := range wants {
+		e := events[i]
+		if e.Event != w.event || e.Category != Category {
+			t.Errorf("event %d = %s, want %s", i, e.Event, w.event)
+		}
+		if w.user != "" && (e.User != w.user || e.RemoteAddr != w.addr || e.Duration != w.duration) {
+			t.Errorf("event %d = %s addr %s duration %d, want %+v", i, e.User, e.RemoteAddr, e.Duration, w)
+		}
+	}
+
+	closed := map[string]int64{events[7].User: events[7].Duration, events[8].User: events[8].Duration}
+	if closed["bob"] != 880 || closed["dave"] != 200 {
+		t.Errorf("sessions closed by reboot = %v", closed)
+	}
+	if events[9].LoginTime != t0.Add(1000*time.Second).Format(time.RFC3339) {
+		t.Errorf("boot time = %s", events[9].LoginTime)
+	}
+
+	if len(state.Open) != 0 || state.WtmpOffset != 7*recordSize {
+		t.Errorf("state = %+v", state)
+	}
+	if lines := readWtmp("testdata/wtmp", &state, &service.HostUpdater{}); len(lines) != 0 {
+		t.Errorf("second read returned %d events", len(lines))
+	}
+}
+
+func TestInitState(t *testing.T) {
+	state := initState("testdata/utmp", "testdata/wtmp")
+	if state.WtmpOffset != 7*recordSize {
+		t.Errorf("wtmp offset = %d", state.WtmpOffset)
+	}
+	if len(state.Open) != 2 || state.Open["pts/0"].User != "alice" || state.Open["pts/1"].User != "bob" {
+		t.Errorf("open = %+v", state.Open)
+	}
+
+	// 保存後重新載入，登入紀錄仍在
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := saveState(path, state); err != nil {
+		t.Fatal(err)
+	}
+	loaded, ok := loadState(path)
+	if !ok || loaded.WtmpOffset != state.WtmpOffset || !loaded.Open["pts/0"].Time.Equal(state.Open["pts/0"].Time) {
+		t.Errorf("loaded = %+v, %v", loaded, ok)
+	}
+}
+
+func TestActiveSessions(t *testing.T) {
+	var data ActiveJSON
+	if err := json.Unmarshal(activeSessions("testdata/utmp", &service.HostUpdater{}), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Event != EventActive || data.Count != 2 || len(data.Sessions) != 2 {
+		t.Fatalf("active = %+v", data)
+	}
+	if s := data.Sessions[0]; s.TTY != "pts/0" || s.User != "alice" || s.PID != 2101 || s.RemoteAddr != "192.168.1.20" {
+		t.Errorf("sessions[0] = %+v", s)
+	}
+	if s := data.Sessions[1]; s.TTY != "pts/1" || s.RemoteHost != "laptop.example.com" || s.RemoteAddr != "2001:db8::5" {
+		t.Errorf("sessions[1] = %+v", s)
+	}
+
+	if b := activeSessions(filepath.Join(t.TempDir(), "missing"), &service.HostUpdater{}); b != nil {
+		t.Errorf("missing utmp returned %s", b)
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// glibc struct utmp（x86_64 / arm64，little endian），每筆 384 bytes
+const recordSize = 384
+
+// ut_type
+const (
+	utBootTime    = 2
+	utUserProcess = 7
+	utDeadProcess = 8
+)
+
+// record 解析後的單筆 utmp / wtmp 紀錄
+type record struct {
+	Type int16
+	PID  int32
+	Line string // TTY，例如 pts/0
+	User string
+	Host string // 遠端主機
+	Addr string // 遠端 IP
+	Time time.Time
+}
+
+func parseRecord(b []byte) record {
+	le := binary.LittleEndian
+	r := record{
+		Type: int16(le.Uint16(b[0:2])),
+		PID:  int32(le.Uint32(b[4:8])),
+		Line: cString(b[8:40]),
+		User: cString(b[44:76]),
+		Host: cString(b[76:332]),
+		Time: time.Unix(int64(int32(le.Uint32(b[340:344]))), int64(int32(le.Uint32(b[344:348])))*1000),
+	}
+
+	// ut_addr_v6：IPv4 只用第一個 word
+	addr := b[348:364]
+	switch {
+	case bytes.Equal(addr[4:], make([]byte, 12)) && !bytes.Equal(addr[:4], make([]byte, 4)):
+		r.Addr = net.IP(addr[:4]).String()
+	case !bytes.Equal(addr, make([]byte, 16)):
+		r.Addr = net.IP(addr).String()
+	}
+	return r
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// readRecords 從 offset 開始讀取完整的紀錄，回傳下一次讀取的 offset
+func readRecords(path string, offset int64) ([]record, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	// 檔案被輪替或截斷，從頭開始
+	if fi.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	var out []record
+	buf := make([]byte, recordSize)
+	for {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			// 不完整的最後一筆留到下一輪
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return out, offset, nil
+			}
+			return out, offset, err
+		}
+		out = append(out, parseRecord(buf))
+		offset += recordSize
+	}
+}
@@ -0,0 +1,170 @@
+package statsd
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// series 單一 metric 在本週期的累計值
+type series struct {
+	Name string
+	Type string
+	Tags map[string]string
+
+	count   float64 // 依取樣率還原後的次數
+	value   float64 // counter 總和 / gauge 目前值
+	sum     float64
+	min     float64
+	max     float64
+	seen    int       // 實際收到的數值個數，用於 reservoir sampling
+	samples []float64 // timer / histogram 保留的樣本
+	set     map[string]struct{}
+}
+
+// gauge 跨週期保留的 gauge
+type gauge struct {
+	Name  string
+	Tags  map[string]string
+	value float64
+	idle  int // 連續沒有更新的週期數
+}
+
+// aggregator 由接收的 goroutine 寫入，flush 時整批交換
+type aggregator struct {
+	mu         sync.Mutex
+	maxKeys    int
+	maxSamples int
+	gaugeTTL   int // gauge 連續沒有更新超過這麼多週期就移除
+
+	series  map[string]*series
+	gauges  map[string]gauge // 上一次的 gauge 值，跨週期保留以支援 +/- 增減，沒有更新時也繼續輸出
+	keys    int              // series 與保留中 gauge 的聯集數量，受 max_keys 限制
+	dropped int              // 超過 max_keys 被丟棄的數值
+	invalid int              // 格式錯誤的行
+	packets int
+}
+
+func newAggregator(maxKeys, maxSamples, gaugeTTL int) *aggregator {
+	return &aggregator{
+		maxKeys:    maxKeys,
+		maxSamples: maxSamples,
+		gaugeTTL:   gaugeTTL,
+		series:     make(map[string]*series),
+		gauges:     make(map[string]gauge),
+	}
+}
+
+func (a *aggregator) add(s sample) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := s.key()
+	sr, ok := a.series[key]
+	if !ok {
+		// 保留中的 gauge 已佔用名額，swap 輸出的數量因此不超過 max_keys
+		g, retained := a.gauges[key]
+		if !retained {
+			if a.keys >= a.maxKeys {
+				a.dropped++
+				return
+			}
+			a.keys++
+		}
+		sr = &series{Name: s.Name, Type: s.Type, Tags: s.Tags, min: math.Inf(1), max: math.Inf(-1)}
+		if s.Type == TypeGauge {
+			sr.value = g.value
+		}
+		a.series[key] = sr
+	}
+
+	switch s.Type {
+	case TypeCounter:
+		sr.count += 1 / s.Rate
+		sr.value += s.Value / s.Rate
+	case TypeGauge:
+		sr.count++
+		if s.Delta {
+			sr.value += s.Value
+		} else {
+			sr.value = s.Value
+		}
+		a.gauges[key] = gauge{Name: sr.Name, Tags: sr.Tags, value: sr.value}
+	case TypeTimer, TypeHistogram, TypeDistribution:
+		sr.count += 1 / s.Rate
+		sr.sum += s.Value / s.Rate
+		sr.min = math.Min(sr.min, s.Value)
+		sr.max = math.Max(sr.max, s.Value)
+		sr.seen++
+		// reservoir sampling：樣本數固定，記憶體不隨流量成長
+		if len(sr.samples) < a.maxSamples {
+			sr.samples = append(sr.samples, s.Value)
+		} else if i := rand.IntN(sr.seen); i < a.maxSamples {
+			sr.samples[i] = s.Value
+		}
+	case TypeSet:
+		sr.count++
+		if sr.set == nil {
+			sr.set = make(map[string]struct{})
+		}
+		if len(sr.set) < a.maxSamples {
+			sr.set[s.Raw] = struct{}{}
+		}
+	}
+}
+
+func (a *aggregator) malformed() {
+	a.mu.Lock()
+	a.invalid++
+	a.mu.Unlock()
+}
+
+func (a *aggregator) packet() {
+	a.mu.Lock()
+	a.packets++
+	a.mu.Unlock()
+}
+
+// swap 取出本週期的資料並重新開始
+func (a *aggregator) swap() (map[string]*series, int, int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out, dropped, invalid, packets := a.series, a.dropped, a.invalid, a.packets
+	a.series = make(map[string]*series)
+
+	// 本週期沒有更新的 gauge 輸出上一次的值，count 為 0；太久沒有更新的移除
+	for key, g := range a.gauges {
+		if _, ok := out[key]; ok {
+			continue
+		}
+		g.idle++
+		if g.idle > a.gaugeTTL {
+			delete(a.gauges, key)
+			continue
+		}
+		a.gauges[key] = g
+		out[key] = &series{Name: g.Name, Type: TypeGauge, Tags: g.Tags, value: g.value}
+	}
+	a.keys = len(a.gauges)
+	a.dropped, a.invalid, a.packets = 0, 0, 0
+	return out, dropped, invalid, packets
+}
+
+// percentiles nearest-rank
+func percentiles(samples []float64, ps []float64) map[string]float64 {
+	out := make(map[string]float64, len(ps))
+	if len(samples) == 0 {
+		return out
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	for _, p := range ps {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		rank = min(max(rank, 1), len(sorted))
+		out["p"+strconv.FormatFloat(p, 'f', -1, 64)] = sorted[rank-1]
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package statsd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metric 類型
+const (
+	TypeCounter      = "counter"
+	TypeGauge        = "gauge"
+	TypeTimer        = "timer"
+	TypeHistogram    = "histogram"
+	TypeDistribution = "distribution"
+	TypeSet          = "set"
+)
+
+var typeCodes = map[string]string{
+	"c":  TypeCounter,
+	"g":  TypeGauge,
+	"ms": TypeTimer,
+	"h":  TypeHistogram,
+	"d":  TypeDistribution,
+	"s":  TypeSet,
+}
+
+// sample 解析後的單一數值
+type sample struct {
+	Name  string
+	Type  string
+	Value float64
+	Raw   string // set 的原始值；gauge 以 +/- 開頭時表示增減
+	Delta bool   // gauge 增減
+	Rate  float64
+	Tags  map[string]string
+}
+
+// parseLine 解析 name:value[:value...]|type[|@rate][|#tag:v,tag2]
+// DogStatsD 的 event（_e{）與 service check（_sc|）不處理
+func parseLine(line string) ([]sample, error) {
+	if strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|") {
+		return nil, nil
+	}
+
+	parts := strings.Split(line, "|")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("missing type")
+	}
+	name, values, ok := strings.Cut(parts[0], ":")
+	if !ok || name == "" || values == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	typ, ok := typeCodes[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", parts[1])
+	}
+
+	rate := 1.0
+	tags := map[string]string{}
+	for _, p := range parts[2:] {
+		switch {
+		case strings.HasPrefix(p, "@"):
+			r, err := strconv.ParseFloat(p[1:], 64)
+			// NaN 的比較永遠為 false，以 !(…) 一併排除
+			if err != nil || !(r > 0 && r <= 1) {
+				return nil, fmt.Errorf("invalid sample rate %q", p)
+			}
+			rate = r
+		case strings.HasPrefix(p, "#"):
+			for _, t := range strings.Split(p[1:], ",") {
+				if t == "" {
+					continue
+				}
+				k, v, _ := strings.Cut(t, ":")
+				tags[k] = v
+			}
+		}
+		// 其他欄位（例如 DogStatsD 的 c: container ID、T timestamp）略過
+	}
+
+	var out []sample
+	for _, raw := range strings.Split(values, ":") {
+		s := sample{Name: name, Type: typ, Raw: raw, Rate: rate, Tags: tags}
+		if typ != TypeSet {
+			// ParseFloat 接受 NaN / Inf，這些值無法輸出成 JSON
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("invalid value %q", raw)
+			}
+			s.Value = v
+			s.Delta = typ == TypeGauge && (raw[0] == '+' || raw[0] == '-')
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// key 名稱 + 類型 + 排序後的 tags，用來區分不同的 metric
+func (s sample) key() string {
+	var b strings.Builder
+	b.WriteString(s.Name)
+	b.WriteByte('|')
+	b.WriteString(s.Type)
+
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte('|')
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(s.Tags[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,257 @@
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "STATSD"
+
+// 事件種類
+const (
+	EventMetric = "STATSD_METRIC"
+	EventStats  = "STATSD_STATS" // 接收端本身的統計
+)
+
+const (
+	defaultMaxKeys    = 10000
+	defaultMaxSamples = 1000
+	defaultGaugeTTL   = 10 // 週期數
+	maxPacket         = 64 * 1024
+	readRetryTime     = time.Second // 讀取錯誤後的等待時間，避免錯誤持續時空轉並塞滿日誌
+)
+
+var defaultPercentiles = []float64{50, 90, 95, 99}
+
+// MetricJSON 單一 metric 在一個週期內的彙總
+type MetricJSON struct {
+	Host        service.HostInfo   `json:"Host"`
+	Category    string             `json:"Category"`
+	Event       string             `json:"Event"`
+	Name        string             `json:"Name"`
+	Type        string             `json:"Type"`
+	Tags        map[string]string  `json:"Tags"`
+	Interval    int                `json:"Interval"` // 秒
+	Count       float64            `json:"Count"`    // 依取樣率還原
+	Rate        float64            `json:"Rate"`     // 每秒；counter 為數值總和，其他為次數
+	Value       float64            `json:"Value"`    // counter 總和 / gauge 目前值 / set 不重複數
+	Sum         float64            `json:"Sum"`
+	Min         float64            `json:"Min"`
+	Max         float64            `json:"Max"`
+	Mean        float64            `json:"Mean"`
+	Percentiles map[string]float64 `json:"Percentiles"` // 例如 p99
+	Timestamp   string             `json:"Timestamp"`
+}
+
+// StatsJSON 接收端的統計，有丟棄或格式錯誤時才需要注意
+type StatsJSON struct {
+	Host        service.HostInfo `json:"Host"`
+	Category    string           `json:"Category"`
+	Event       string           `json:"Event"`
+	Interval    int              `json:"Interval"`
+	Packets     int              `json:"Packets"`
+	Metrics     int              `json:"Metrics"`
+	DroppedKeys int              `json:"DroppedKeys"` // 超過 max_keys 而丟棄的數值
+	Malformed   int              `json:"Malformed"`
+	Timestamp   string           `json:"Timestamp"`
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	maxKeys := cfg.Statsd.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	maxSamples := cfg.Statsd.MaxSamples
+	if maxSamples <= 0 {
+		maxSamples = defaultMaxSamples
+	}
+	gaugeTTL := cfg.Statsd.GaugeExpiry
+	if gaugeTTL <= 0 {
+		gaugeTTL = defaultGaugeTTL
+	}
+	agg := newAggregator(maxKeys, maxSamples, gaugeTTL)
+
+	if cfg.Statsd.UDP != "" {
+		conn, err := net.ListenPacket("udp", cfg.Statsd.UDP)
+		if err != nil {
+			utils.Log.Error("[Statsd] listen udp %s fail: %v", cfg.Statsd.UDP, err)
+		} else {
+			utils.Log.Info("[Statsd] listening on udp %s", cfg.Statsd.UDP)
+			go listen(ctx, conn, agg)
+		}
+	}
+	if cfg.Statsd.Unix != "" {
+		// 前一次執行留下的 socket 檔
+		os.Remove(cfg.Statsd.Unix)
+		conn, err := net.ListenPacket("unixgram", cfg.Statsd.Unix)
+		if err != nil {
+			utils.Log.Error("[Statsd] listen unix %s fail: %v", cfg.Statsd.Unix, err)
+		} else {
+			utils.Log.Info("[Statsd] listening on unix %s", cfg.Statsd.Unix)
+			go listen(ctx, conn, agg)
+		}
+	}
+
+	startFlush(ctx, cfg, agg, host)
+}
+
+// listen 讀取封包並交給 aggregator，ctx 結束時關閉
+func listen(ctx context.Context, conn net.PacketConn, agg *aggregator) {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxPacket)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			utils.Log.Error("[Statsd] read fail: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(readRetryTime):
+			}
+			continue
+		}
+		agg.packet()
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			samples, err := parseLine(string(line))
+			if err != nil {
+				utils.Log.Debug("[Statsd] malformed %q: %v", line, err)
+				agg.malformed()
+				continue
+			}
+			for _, s := range samples {
+				agg.add(s)
+			}
+		}
+	}
+}
+
+func startFlush(ctx context.Context, cfg config.MonitorConfig, agg *aggregator, host *service.HostUpdater) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.Log.Error("[Statsd] goroutine panic: %v", r)
+				startFlush(ctx, cfg, agg, host)
+			}
+		}()
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		percents := cfg.Statsd.Percentiles
+		if len(percents) == 0 {
+			percents = defaultPercentiles
+		}
+
+		ticker := time.NewTicker(time.Duration(cfg.Statsd.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, line := range flush(agg, cfg.Statsd.Interval, percents, host) {
+					logger.Write(line)
+				}
+			case <-ctx.Done():
+				if cfg.Statsd.Unix != "" {
+					os.Remove(cfg.Statsd.Unix)
+				}
+				utils.Log.Info("[Statsd] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// flush 輸出本週期每個 metric 的彙總與接收端統計
+func flush(agg *aggregator, interval int, percents []float64, host *service.HostUpdater) [][]byte {
+	all, dropped, invalid, packets := agg.swap()
+	now := time.Now().Format(time.RFC3339)
+	info := host.Get()
+	secs := float64(interval)
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out [][]byte
+	for _, k := range keys {
+		sr := all[k]
+		data := MetricJSON{
+			Host:        info,
+			Category:    Category,
+			Event:       EventMetric,
+			Name:        sr.Name,
+			Type:        sr.Type,
+			Tags:        sr.Tags,
+			Interval:    interval,
+			Count:       sr.count,
+			Rate:        sr.count / secs,
+			Percentiles: map[string]float64{},
+			Timestamp:   now,
+		}
+		switch sr.Type {
+		case TypeCounter:
+			data.Value = sr.value
+			data.Sum = sr.value
+			data.Rate = sr.value / secs
+		case TypeGauge:
+			data.Value = sr.value
+		case TypeTimer, TypeHistogram, TypeDistribution:
+			data.Sum = sr.sum
+			data.Min = sr.min
+			data.Max = sr.max
+			if sr.count > 0 {
+				data.Mean = sr.sum / sr.count
+			}
+			data.Percentiles = percentiles(sr.samples, percents)
+		case TypeSet:
+			data.Value = float64(len(sr.set))
+		}
+		// 累加後仍可能溢位成 Inf，無法輸出的 metric 略過，不寫出空行
+		b, err := json.Marshal(data)
+		if err != nil {
+			utils.Log.Error("[Statsd] %s marshal fail: %v", sr.Name, err)
+			continue
+		}
+		out = append(out, b)
+	}
+
+	if dropped > 0 {
+		utils.Log.Warn("[Statsd] %d values dropped: too many distinct keys", dropped)
+	}
+	stats := StatsJSON{
+		Host:        info,
+		Category:    Category,
+		Event:       EventStats,
+		Interval:    interval,
+		Packets:     packets,
+		Metrics:     len(all),
+		DroppedKeys: dropped,
+		Malformed:   invalid,
+		Timestamp:   now,
+	}
+	b, _ := json.Marshal(stats)
+	utils.Log.Debug("%s", string(b))
+	return append(out, b)
+}
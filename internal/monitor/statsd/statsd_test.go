@@ -0,0 +1,195 @@
+package statsd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func feed(t *testing.T, agg *aggregator, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		samples, err := parseLine(line)
+		if err != nil {
+			t.Fatalf("parse %q: %v", line, err)
+		}
+		for _, s := range samples {
+			agg.add(s)
+		}
+	}
+}
+
+// flushMetrics 以名稱索引本週期輸出的 metric
+func flushMetrics(t *testing.T, agg *aggregator) map[string]MetricJSON {
+	t.Helper()
+	out := make(map[string]MetricJSON)
+	for _, line := range flush(agg, 10, defaultPercentiles, &service.HostUpdater{}) {
+		var m MetricJSON
+		json.Unmarshal(line, &m)
+		if m.Event == EventMetric {
+			out[m.Name] = m
+		}
+	}
+	return out
+}
+
+func TestGaugeKeptAcrossIntervals(t *testing.T) {
+	agg := newAggregator(100, 100, 10)
+	feed(t, agg, "queue.depth:42|g|#queue:jobs", "requests:3|c")
+
+	first := flushMetrics(t, agg)
+	if g := first["queue.depth"]; g.Value != 42 || g.Count != 1 || g.Tags["queue"] != "jobs" {
+		t.Fatalf("first gauge = %+v", g)
+	}
+
+	// 沒有更新的 gauge 繼續輸出上一次的值，counter 不輸出
+	second := flushMetrics(t, agg)
+	if g, ok := second["queue.depth"]; !ok || g.Value != 42 || g.Count != 0 || g.Type != TypeGauge || g.Tags["queue"] != "jobs" {
+		t.Fatalf("second gauge = %+v, %v", g, ok)
+	}
+	if _, ok := second["requests"]; ok {
+		t.Error("counter re-emitted without updates")
+	}
+
+	// 增減以保留的值為基準
+	feed(t, agg, "queue.depth:-2|g|#queue:jobs")
+	if g := flushMetrics(t, agg)["queue.depth"]; g.Value != 40 || g.Count != 1 {
+		t.Fatalf("delta gauge = %+v", g)
+	}
+}
+
+func TestGaugeRetentionLimit(t *testing.T) {
+	agg := newAggregator(2, 100, 10)
+	feed(t, agg, "a:1|g", "b:2|g")
+	flushMetrics(t, agg)
+
+	// 保留中的 gauge 計入 max_keys，新的 metric 被丟棄，已保留的仍可更新
+	feed(t, agg, "c:3|g", "requests:1|c", "a:5|g")
+	series, dropped, _, _ := agg.swap()
+	a, b := sample{Name: "a", Type: TypeGauge}.key(), sample{Name: "b", Type: TypeGauge}.key()
+	if len(series) != 2 || series[a].value != 5 || series[b].value != 2 {
+		t.Fatalf("series = %v", series)
+	}
+	if dropped != 2 {
+		t.Errorf("dropped = %d, want 2", dropped)
+	}
+}
+
+func TestGaugeExpiry(t *testing.T) {
+	agg := newAggregator(2, 100, 3)
+	feed(t, agg, "a:1|g", "b:2|g")
+	flushMetrics(t, agg)
+
+	// a 持續更新，b 連續 3 個週期沒有更新後移除
+	for i := 0; i < 3; i++ {
+		feed(t, agg, "a:1|g")
+		if got := flushMetrics(t, agg); len(got) != 2 || got["b"].Count != 0 {
+			t.Fatalf("interval %d: metrics = %+v", i, got)
+		}
+	}
+	feed(t, agg, "a:1|g")
+	got := flushMetrics(t, agg)
+	if _, ok := got["b"]; ok || len(got) != 1 {
+		t.Fatalf("expired gauge still emitted: %+v", got)
+	}
+
+	// 移除後釋出名額，重新出現時從頭開始
+	feed(t, agg, "c:7|g", "b:+1|g")
+	got = flushMetrics(t, agg)
+	if len(got) != 2 || got["c"].Value != 7 {
+		t.Fatalf("metrics after expiry = %+v", got)
+	}
+}
+
+// errConn 每次讀取都失敗，關閉後回傳 net.ErrClosed
+type errConn struct {
+	net.PacketConn
+	reads  atomic.Int32
+	closed atomic.Bool
+}
+
+func (c *errConn) ReadFrom([]byte) (int, net.Addr, error) {
+	if c.closed.Load() {
+		return 0, nil, net.ErrClosed
+	}
+	c.reads.Add(1)
+	return 0, nil, errors.New("recvfrom: connection refused")
+}
+
+func (c *errConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestListenReadErrorBackoff(t *testing.T) {
+	conn := &errConn{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		listen(ctx, conn, newAggregator(10, 10, 10))
+		close(done)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("listen did not return after cancel")
+	}
+	if n := conn.reads.Load(); n != 1 {
+		t.Errorf("%d reads during backoff, want 1", n)
+	}
+}
+
+func TestParseLineRejectsNonFinite(t *testing.T) {
+	for _, line := range []string{
+		"queue.depth:NaN|g",
+		"queue.depth:Inf|g",
+		"queue.depth:+Inf|g",
+		"latency:-inf|ms",
+		"requests:1|c|@nan",
+		"requests:1|c|@inf",
+		"requests:1|c|@0",
+		"requests:1|c|@1.5",
+	} {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("parseLine(%q) accepted", line)
+		}
+	}
+	if s, err := parseLine("requests:2|c|@0.5"); err != nil || s[0].Value != 2 || s[0].Rate != 0.5 {
+		t.Errorf("valid line = %+v, %v", s, err)
+	}
+}
+
+func TestFlushSkipsOverflow(t *testing.T) {
+	agg := newAggregator(100, 100, 10)
+	feed(t, agg, "huge:1e308|c", "huge:1e308|c", "ok:1|c")
+
+	for _, line := range flush(agg, 10, defaultPercentiles, &service.HostUpdater{}) {
+		if len(line) == 0 {
+			t.Fatal("flush produced an empty record")
+		}
+		var m MetricJSON
+		json.Unmarshal(line, &m)
+		if m.Name == "huge" {
+			t.Errorf("overflowed counter emitted: %s", line)
+		}
+	}
+}
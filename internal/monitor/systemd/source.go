@@ -0,0 +1,208 @@
+package systemd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sysprobe/internal/utils"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusDest        = "org.freedesktop.systemd1"
+	dbusPath        = dbus.ObjectPath("/org/freedesktop/systemd1")
+	dbusListUnits   = "org.freedesktop.systemd1.Manager.ListUnits"
+	dbusNRestarts   = "org.freedesktop.systemd1.Service.NRestarts"
+	sourceDBus      = "dbus"
+	sourceSystemctl = "systemctl"
+)
+
+// unitLister 優先透過 D-Bus 查詢，失敗時改用 systemctl
+type unitLister struct {
+	conn *dbus.Conn
+}
+
+// dbusUnit 對應 ListUnits 回傳的 (ssssssouso)
+type dbusUnit struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+func (l *unitLister) list(globs []string) (map[string]Unit, string, error) {
+	units, err := l.listDBus(globs)
+	if err == nil {
+		return units, sourceDBus, nil
+	}
+	utils.Log.Debug("[Systemd] D-Bus 查詢失敗，改用 systemctl: %v", err)
+	l.close()
+
+	units, err = listSystemctl(globs)
+	if err != nil {
+		return nil, "", err
+	}
+	return units, sourceSystemctl, nil
+}
+
+func (l *unitLister) close() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
+	}
+}
+
+// ------------------------- D-Bus -------------------------
+func (l *unitLister) listDBus(globs []string) (map[string]Unit, error) {
+	if l.conn == nil {
+		conn, err := dbus.ConnectSystemBus()
+		if err != nil {
+			return nil, err
+		}
+		l.conn = conn
+	}
+
+	var raw []dbusUnit
+	if err := l.conn.Object(dbusDest, dbusPath).Call(dbusListUnits, 0).Store(&raw); err != nil {
+		return nil, err
+	}
+
+	units := make(map[string]Unit)
+	for _, r := range raw {
+		if !matchUnit(r.Name, globs) {
+			continue
+		}
+		u := Unit{
+			Name:        r.Name,
+			Description: r.Description,
+			LoadState:   r.LoadState,
+			ActiveState: r.ActiveState,
+			SubState:    r.SubState,
+		}
+		if strings.HasSuffix(r.Name, ".service") {
+			v, err := l.conn.Object(dbusDest, r.Path).GetProperty(dbusNRestarts)
+			if err == nil {
+				if n, ok := v.Value().(uint32); ok {
+					u.NRestarts = n
+				}
+			}
+		}
+		units[r.Name] = u
+	}
+	return units, nil
+}
+
+// ------------------------- systemctl -------------------------
+
+// systemctlUnit 對應 systemctl list-units --output=json 的欄位
+type systemctlUnit struct {
+	Unit        string `json:"unit"`
+	Load        string `json:"load"`
+	Active      string `json:"active"`
+	Sub         string `json:"sub"`
+	Description string `json:"description"`
+}
+
+func listSystemctl(globs []string) (map[string]Unit, error) {
+	out, err := exec.Command("systemctl", "list-units", "--all", "--output=json", "--no-pager").Output()
+	if err != nil {
+		return nil, fmt.Errorf("systemctl list-units: %w", err)
+	}
+	units, services, err := parseListUnits(out, globs)
+	if err != nil {
+		return nil, err
+	}
+
+	// list-units 沒有重啟次數，另外用 systemctl show 取得
+	if len(services) > 0 {
+		restarts, err := showNRestarts(services)
+		if err != nil {
+			utils.Log.Debug("[Systemd] 無法取得 NRestarts: %v", err)
+		}
+		for name, n := range restarts {
+			if u, ok := units[name]; ok {
+				u.NRestarts = n
+				units[name] = u
+			}
+		}
+	}
+	return units, nil
+}
+
+// parseListUnits 解析 systemctl list-units --output=json，另外回傳符合的 .service 名稱
+func parseListUnits(out []byte, globs []string) (map[string]Unit, []string, error) {
+	var raw []systemctlUnit
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, nil, fmt.Errorf("parse systemctl output: %w", err)
+	}
+
+	units := make(map[string]Unit)
+	var services []string
+	for _, r := range raw {
+		if !matchUnit(r.Unit, globs) {
+			continue
+		}
+		units[r.Unit] = Unit{
+			Name:        r.Unit,
+			Description: r.Description,
+			LoadState:   r.Load,
+			ActiveState: r.Active,
+			SubState:    r.Sub,
+		}
+		if strings.HasSuffix(r.Unit, ".service") {
+			services = append(services, r.Unit)
+		}
+	}
+	return units, services, nil
+}
+
+// showNRestarts 以 systemctl show 取得各 service 的重新啟動次數
+func showNRestarts(services []string) (map[string]uint32, error) {
+	args := append([]string{"show", "--property=Id,NRestarts", "--no-pager"}, services...)
+	out, err := exec.Command("systemctl", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseShow(out)
+}
+
+// parseShow 解析 systemctl show 的 Id= / NRestarts= 區塊
+func parseShow(out []byte) (map[string]uint32, error) {
+	// 每個單元一個區塊，以空行分隔；屬性順序不固定
+	restarts := make(map[string]uint32)
+	var id, count string
+	flush := func() {
+		if n, err := strconv.ParseUint(count, 10, 32); err == nil && id != "" {
+			restarts[id] = uint32(n)
+		}
+		id, count = "", ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			flush()
+			continue
+		}
+		switch k {
+		case "Id":
+			id = v
+		case "NRestarts":
+			count = v
+		}
+	}
+	flush()
+	return restarts, scanner.Err()
+}
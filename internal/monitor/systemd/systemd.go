@@ -0,0 +1,219 @@
+package systemd
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const Category = "SYSTEMD"
+
+// 事件種類
+const (
+	EventStateChanged = "UNIT_STATE_CHANGED"
+	EventRestarted    = "UNIT_RESTARTED"
+	EventSummary      = "UNIT_SUMMARY"
+)
+
+// Unit 單一 systemd 單元狀態
+type Unit struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	LoadState   string `json:"LoadState"`
+	ActiveState string `json:"ActiveState"`
+	SubState    string `json:"SubState"`
+	NRestarts   uint32 `json:"NRestarts"` // 只有 .service 有值
+}
+
+// UnitEventJSON 單元狀態變化或重新啟動
+type UnitEventJSON struct {
+	Host            service.HostInfo `json:"Host"`
+	Category        string           `json:"Category"`
+	Event           string           `json:"Event"`
+	Unit            string           `json:"Unit"`
+	Description     string           `json:"Description"`
+	PrevActiveState string           `json:"PrevActiveState"`
+	PrevSubState    string           `json:"PrevSubState"`
+	ActiveState     string           `json:"ActiveState"`
+	SubState        string           `json:"SubState"`
+	PrevNRestarts   uint32           `json:"PrevNRestarts"`
+	NRestarts       uint32           `json:"NRestarts"`
+	Timestamp       string           `json:"Timestamp"`
+}
+
+// SummaryJSON 週期性的單元狀態摘要
+type SummaryJSON struct {
+	Host        service.HostInfo `json:"Host"`
+	Category    string           `json:"Category"`
+	Event       string           `json:"Event"`
+	Total       int              `json:"Total"`
+	Active      int              `json:"Active"`
+	Failed      int              `json:"Failed"`
+	FailedUnits []string         `json:"FailedUnits"`
+	Units       []Unit           `json:"Units"`
+	Source      string           `json:"Source"` // dbus / systemctl
+	Timestamp   string           `json:"Timestamp"`
+}
+
+func Start(ctx context.Context, cfg config.MonitorConfig, host *service.HostUpdater) {
+	go func() {
+		lister := &unitLister{}
+
+		defer func() {
+			lister.close()
+			if r := recover(); r != nil {
+				utils.Log.Error("[Systemd] goroutine panic: %v", r)
+				Start(ctx, cfg, host)
+			}
+		}()
+
+		logger := utils.GetLogger(cfg.Data+"/"+Category, Category, cfg.Days)
+		ticker := time.NewTicker(time.Duration(cfg.Systemd.Interval) * time.Second)
+		defer ticker.Stop()
+
+		summaryInterval := time.Duration(cfg.Systemd.SummaryInterval) * time.Second
+		var prev map[string]Unit
+		var lastSummary time.Time
+
+		for {
+			select {
+			case <-ticker.C:
+				units, source, err := lister.list(cfg.Systemd.Units)
+				if err != nil {
+					utils.Log.Error("[Systemd] 無法取得單元狀態: %v", err)
+					continue
+				}
+
+				for _, line := range diffUnits(prev, units, host) {
+					logger.Write(line)
+				}
+				prev = units
+
+				if time.Since(lastSummary) >= summaryInterval {
+					logger.Write(summarize(units, source, host))
+					lastSummary = time.Now()
+				}
+			case <-ctx.Done():
+				utils.Log.Info("[Systemd] 收集器已停止")
+				return
+			}
+		}
+	}()
+}
+
+// diffUnits 與上一輪比對，輸出狀態變化及重新啟動事件
+func diffUnits(prev, current map[string]Unit, host *service.HostUpdater) [][]byte {
+	// 第一次執行沒有基準
+	if prev == nil {
+		return nil
+	}
+
+	// 新出現或消失（已卸載）的單元都視為 inactive/dead
+	unloaded := func(name string) Unit {
+		return Unit{Name: name, ActiveState: "inactive", SubState: "dead"}
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range []map[string]Unit{prev, current} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var out [][]byte
+	for _, name := range names {
+		u, ok := current[name]
+		if !ok {
+			u = unloaded(name)
+			u.Description = prev[name].Description
+		}
+		old, ok := prev[name]
+		if !ok {
+			old = unloaded(name)
+		}
+
+		event := ""
+		switch {
+		case old.ActiveState != u.ActiveState || old.SubState != u.SubState:
+			event = EventStateChanged
+		case u.NRestarts > old.NRestarts:
+			event = EventRestarted
+		default:
+			continue
+		}
+
+		data := UnitEventJSON{
+			Host:            host.Get(),
+			Category:        Category,
+			Event:           event,
+			Unit:            u.Name,
+			Description:     u.Description,
+			PrevActiveState: old.ActiveState,
+			PrevSubState:    old.SubState,
+			ActiveState:     u.ActiveState,
+			SubState:        u.SubState,
+			PrevNRestarts:   old.NRestarts,
+			NRestarts:       u.NRestarts,
+			Timestamp:       time.Now().Format(time.RFC3339),
+		}
+		b, _ := json.Marshal(data)
+		utils.Log.Debug("%s", string(b))
+		out = append(out, b)
+	}
+	return out
+}
+
+func summarize(units map[string]Unit, source string, host *service.HostUpdater) []byte {
+	data := SummaryJSON{
+		Host:        host.Get(),
+		Category:    Category,
+		Event:       EventSummary,
+		Total:       len(units),
+		FailedUnits: []string{},
+		Units:       make([]Unit, 0, len(units)),
+		Source:      source,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	for _, u := range units {
+		switch u.ActiveState {
+		case "active":
+			data.Active++
+		case "failed":
+			data.Failed++
+			data.FailedUnits = append(data.FailedUnits, u.Name)
+		}
+		data.Units = append(data.Units, u)
+	}
+	sort.Strings(data.FailedUnits)
+	sort.Slice(data.Units, func(i, j int) bool {
+		return data.Units[i].Name < data.Units[j].Name
+	})
+
+	b, _ := json.Marshal(data)
+	utils.Log.Debug("[Systemd] summary total=%d active=%d failed=%d", data.Total, data.Active, data.Failed)
+	return b
+}
+
+// matchUnit 檢查單元名稱是否符合任一 glob，沒有設定 glob 表示全部
+func matchUnit(name string, globs []string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
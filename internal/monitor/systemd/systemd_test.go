@@ -0,0 +1,165 @@
+package systemd
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"sysprobe/internal/config"
+	"sysprobe/internal/service"
+	"sysprobe/internal/utils"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, _ := os.MkdirTemp("", "sysprobe-test")
+	utils.InitLogger(config.LogConfig{Path: dir})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseListUnits(t *testing.T) {
+	out := readFixture(t, "list-units.json")
+
+	units, services, err := parseListUnits(out, nil)
+	if err != nil {
+		t.Fatalf("parseListUnits: %v", err)
+	}
+	if len(units) != 7 {
+		t.Errorf("got %d units, want 7", len(units))
+	}
+	want := Unit{
+		Name:        "nginx.service",
+		Description: "A high performance web server and a reverse proxy server",
+		LoadState:   "loaded",
+		ActiveState: "failed",
+		SubState:    "failed",
+	}
+	if got := units["nginx.service"]; got != want {
+		t.Errorf("nginx.service = %+v", got)
+	}
+	sort.Strings(services)
+	if want := []string{"cron.service", "nginx.service", "ssh.service", "systemd-journald.service"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("services = %v, want %v", services, want)
+	}
+
+	units, services, err = parseListUnits(out, []string{"ssh.*", "*.timer"})
+	if err != nil {
+		t.Fatalf("parseListUnits: %v", err)
+	}
+	if len(units) != 3 || units["ssh.socket"].SubState != "dead" || units["apt-daily.timer"].SubState != "waiting" {
+		t.Errorf("filtered units = %+v", units)
+	}
+	if len(services) != 1 || services[0] != "ssh.service" {
+		t.Errorf("filtered services = %v", services)
+	}
+
+	if _, _, err := parseListUnits([]byte("UNIT LOAD ACTIVE SUB"), nil); err == nil {
+		t.Error("plain text output accepted")
+	}
+}
+
+func TestParseShow(t *testing.T) {
+	got, err := parseShow(readFixture(t, "show.txt"))
+	if err != nil {
+		t.Fatalf("parseShow: %v", err)
+	}
+	// 屬性順序不固定；無法解析的數值略過
+	want := map[string]uint32{"cron.service": 0, "nginx.service": 4, "ssh.service": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseShow = %v, want %v", got, want)
+	}
+}
+
+func TestMatchUnit(t *testing.T) {
+	tests := []struct {
+		name  string
+		globs []string
+		want  bool
+	}{
+		{"nginx.service", nil, true},
+		{"nginx.service", []string{"nginx.service"}, true},
+		{"nginx.service", []string{"*.socket", "ngin?.*"}, true},
+		{"ssh.socket", []string{"*.service"}, false},
+		{"user@1000.service", []string{"user@*.service"}, true},
+		{"nginx.service", []string{"["}, false},
+	}
+	for _, tt := range tests {
+		if got := matchUnit(tt.name, tt.globs); got != tt.want {
+			t.Errorf("matchUnit(%q, %q) = %v, want %v", tt.name, tt.globs, got, tt.want)
+		}
+	}
+}
+
+func TestDiffUnits(t *testing.T) {
+	host := &service.HostUpdater{}
+	prev, _, err := parseListUnits(readFixture(t, "list-units.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次執行沒有基準
+	if out := diffUnits(nil, prev, host); out != nil {
+		t.Fatalf("first run emitted %d events", len(out))
+	}
+
+	current := make(map[string]Unit, len(prev))
+	for k, v := range prev {
+		current[k] = v
+	}
+	nginx := current["nginx.service"]
+	nginx.ActiveState, nginx.SubState = "active", "running"
+	current["nginx.service"] = nginx
+	ssh := current["ssh.service"]
+	ssh.NRestarts = 2
+	current["ssh.service"] = ssh
+	delete(current, "cron.service")
+	current["backup.service"] = Unit{Name: "backup.service", LoadState: "loaded", ActiveState: "activating", SubState: "start"}
+
+	var got []UnitEventJSON
+	for _, b := range diffUnits(prev, current, host) {
+		var ev UnitEventJSON
+		if err := json.Unmarshal(b, &ev); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev)
+	}
+
+	// 依名稱排序；新出現與消失的單元以 inactive/dead 為另一端
+	want := []struct {
+		unit, event, prevActive, active string
+		prevRestarts, restarts          uint32
+	}{
+		{"backup.service", EventStateChanged, "inactive", "activating", 0, 0},
+		{"cron.service", EventStateChanged, "active", "inactive", 0, 0},
+		{"nginx.service", EventStateChanged, "failed", "active", 0, 0},
+		{"ssh.service", EventRestarted, "active", "active", 0, 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		ev := got[i]
+		if ev.Unit != w.unit || ev.Event != w.event || ev.PrevActiveState != w.prevActive || ev.ActiveState != w.active ||
+			ev.PrevNRestarts != w.prevRestarts || ev.NRestarts != w.restarts {
+			t.Errorf("event %d = %+v, want %+v", i, ev, w)
+		}
+	}
+	if got[1].Description != "Regular background program processing daemon" || got[1].SubState != "dead" {
+		t.Errorf("removed unit event = %+v", got[1])
+	}
+
+	if out := diffUnits(current, current, host); len(out) != 0 {
+		t.Errorf("unchanged units emitted %d events", len(out))
+	}
+}
@@ -0,0 +1,322 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+// 尚未確認的事件上限預設值，達到時暫停送出直到收到 Ack
+const defaultAckWindow = 1000
+
+// 批次預設值
+const (
+	defaultBatchEvents = 500
+	defaultBatchBytes  = 1 << 20
+	defaultBatchLinger = 1000 // 毫秒
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// ackTransport 單筆（StreamLogsAck）或批次（StreamBatches）串流
+type ackTransport interface {
+	send(seq uint64, events []*logstream.LogEvent) error
+	recv() (uint64, error)
+	CloseSend() error
+}
+
+type eventTransport struct {
+	logstream.LogStreamer_StreamLogsAckClient
+}
+
+func (t eventTransport) send(seq uint64, events []*logstream.LogEvent) error {
+	// 單筆模式每批只有一筆
+	events[0].Seq = seq
+	return t.Send(events[0])
+}
+
+func (t eventTransport) recv() (uint64, error) {
+	ack, err := t.Recv()
+	if err != nil {
+		return 0, err
+	}
+	return ack.Seq, nil
+}
+
+type batchTransport struct {
+	logstream.LogStreamer_StreamBatchesClient
+}
+
+func (t batchTransport) send(seq uint64, events []*logstream.LogEvent) error {
+	return t.Send(&logstream.LogBatch{Seq: seq, Events: events})
+}
+
+func (t batchTransport) recv() (uint64, error) {
+	ack, err := t.Recv()
+	if err != nil {
+		return 0, err
+	}
+	return ack.Seq, nil
+}
+
+// pendingBatch 已送出、等待確認的批次
+type pendingBatch struct {
+	seq     uint64
+	events  int
+	offsets map[string]int64 // offset 檔 → 這批最後一行結尾的位置，確認後才寫入
+}
+
+// ackStream 累積事件成批送出、接收 Ack 並只持久化已確認的位置
+// 串流中斷後整個丟棄（包含尚未送出的批次），重新連線時從已確認的 offset 重送
+type ackStream struct {
+	t        ackTransport
+	cancel   context.CancelFunc // 結束串流
+	priority int                // 連到的 collector 的優先順序（failover），0 為最優先
+
+	window    int
+	maxEvents int
+	maxBytes  int
+	linger    time.Duration
+
+	sendMu sync.Mutex // 確保批次依 seq 順序送出
+
+	mu         sync.Mutex
+	seq        uint64
+	pending    []pendingBatch
+	inflight   int // 未確認的事件數
+	buf        []*logstream.LogEvent
+	bufBytes   int
+	bufOffsets map[string]int64
+	timer      *time.Timer
+	sent       map[string]int64 // offset 檔 → 已交給串流（未必確認）的位置
+	space      chan struct{}    // 確認後通知
+	done       chan struct{}    // 串流中斷
+	err        error
+}
+
+func newAckStream(t ackTransport, batch config.BatchConfig, window int, cancel context.CancelFunc) *ackStream {
+	if window <= 0 {
+		window = defaultAckWindow
+	}
+	s := &ackStream{
+		t:          t,
+		cancel:     cancel,
+		window:     window,
+		maxEvents:  1,
+		bufOffsets: make(map[string]int64),
+		sent:       make(map[string]int64),
+		space:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	if batch.Enable {
+		s.maxEvents, s.maxBytes = batch.MaxEvents, batch.MaxBytes
+		s.linger = time.Duration(batch.Linger) * time.Millisecond
+		if s.maxEvents <= 0 {
+			s.maxEvents = defaultBatchEvents
+		}
+		if s.maxBytes <= 0 {
+			s.maxBytes = defaultBatchBytes
+		}
+		if s.linger <= 0 {
+			s.linger = defaultBatchLinger * time.Millisecond
+		}
+	}
+	go s.recvLoop()
+	return s
+}
+
+// resumeOffset 從哪裡繼續讀：同一條串流上已送出的位置，否則為已確認的位置
+func (s *ackStream) resumeOffset(offsetFile string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if off, ok := s.sent[offsetFile]; ok {
+		return off
+	}
+	return loadOffsetState(offsetFile).Offset
+}
+
+// send 加入目前的批次，達到筆數或大小上限時送出；window 滿時等待
+func (s *ackStream) send(ctx context.Context, offsetFile string, offset int64, event *logstream.LogEvent) error {
+	for {
+		s.mu.Lock()
+		if s.err != nil {
+			s.mu.Unlock()
+			return s.err
+		}
+		if s.inflight+len(s.buf) < s.window {
+			break
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.space:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.buf = append(s.buf, event)
+	s.bufBytes += len(event.Payload)
+	s.bufOffsets[offsetFile] = offset
+	s.sent[offsetFile] = offset
+	full := len(s.buf) >= s.maxEvents || (s.maxBytes > 0 && s.bufBytes >= s.maxBytes)
+	if !full && len(s.buf) == 1 && s.linger > 0 {
+		s.timer = time.AfterFunc(s.linger, func() {
+			// 失敗時串流已標記中斷，讀檔端下一次 send 或等待 done 時就會重新連線
+			if err := s.flush(); err != nil {
+				utils.Log.Debug("[Network] linger flush failed: %v", err)
+			}
+		})
+	}
+	s.mu.Unlock()
+
+	if full {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush 送出目前累積的批次
+func (s *ackStream) flush() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	if len(s.buf) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.seq++
+	seq, events := s.seq, s.buf
+	s.pending = append(s.pending, pendingBatch{seq: seq, events: len(events), offsets: s.bufOffsets})
+	s.inflight += len(events)
+	s.buf, s.bufBytes, s.bufOffsets = nil, 0, make(map[string]int64)
+	s.mu.Unlock()
+
+	if err := s.t.send(seq, events); err != nil {
+		s.fail(err)
+		return err
+	}
+	return nil
+}
+
+// recvLoop 接收 Ack（累積確認），每個確認的批次只寫一次 offset 檔
+func (s *ackStream) recvLoop() {
+	for {
+		seq, err := s.t.recv()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.mu.Lock()
+		committed := make(map[string]int64)
+		n, events := 0, 0
+		for n < len(s.pending) && s.pending[n].seq <= seq {
+			for file, off := range s.pending[n].offsets {
+				committed[file] = off
+			}
+			events += s.pending[n].events
+			n++
+		}
+		s.pending = s.pending[n:]
+		s.mu.Unlock()
+
+		for file, off := range committed {
+			saveOffsetState(file, off)
+		}
+		// offset 寫入後才減少 inflight，drain 回傳時確認過的 offset 都已落地
+		s.mu.Lock()
+		s.inflight -= events
+		s.mu.Unlock()
+		if n > 0 {
+			select {
+			case s.space <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (s *ackStream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if err == nil {
+		err = errStreamClosed
+	}
+	s.err = err
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	close(s.done)
+	if s.inflight > 0 || len(s.buf) > 0 {
+		utils.Log.Debug("[Network] stream closed with %d unacked events, will resend: %v", s.inflight+len(s.buf), err)
+	}
+}
+
+// failure 串流中斷的原因
+func (s *ackStream) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// broken 串流是否已中斷
+func (s *ackStream) broken() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err != nil
+}
+
+// drain 送出剩下的批次並等待確認，用於關閉前；送出本身也算在 timeout 內，
+// 逾時就結束串流，讓卡住的 gRPC Send 或 HTTP 重試隨之返回
+func (s *ackStream) drain(timeout time.Duration) {
+	expire := time.AfterFunc(timeout, s.cancel)
+	defer expire.Stop()
+	deadline := time.After(timeout)
+
+	if err := s.flush(); err != nil {
+		utils.Log.Warn("[Network] flush before close failed, unacked events will be resent: %v", err)
+		return
+	}
+	for {
+		s.mu.Lock()
+		n := s.inflight
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		select {
+		case <-s.space:
+		case <-s.done:
+			return
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// close 串流正常時先送完剩下的批次並等待確認，再結束串流
+func (s *ackStream) close(timeout time.Duration) {
+	if timeout > 0 && !s.broken() {
+		s.drain(timeout)
+		s.t.CloseSend()
+	}
+	s.cancel()
+}
@@ -0,0 +1,96 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"testing"
+	"time"
+)
+
+// blockingTransport send / recv 一直阻塞到串流的 context 結束，模擬沒有回應的 collector
+type blockingTransport struct {
+	ctx context.Context
+}
+
+func (t blockingTransport) send(uint64, []*logstream.LogEvent) error {
+	<-t.ctx.Done()
+	return t.ctx.Err()
+}
+
+func (t blockingTransport) recv() (uint64, error) {
+	<-t.ctx.Done()
+	return 0, t.ctx.Err()
+}
+
+func (t blockingTransport) CloseSend() error { return nil }
+
+// failingTransport 每次送出都失敗
+type failingTransport struct {
+	blockingTransport
+	err error
+}
+
+func (t failingTransport) send(uint64, []*logstream.LogEvent) error { return t.err }
+
+var lingerBatch = config.BatchConfig{Enable: true, MaxEvents: 100, Linger: 20}
+
+func TestAckStreamCloseBoundedWhenSendBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newAckStream(blockingTransport{ctx}, config.BatchConfig{Enable: true, MaxEvents: 100, Linger: 60000}, 10, cancel)
+	if err := s.send(context.Background(), "f.offset", 10, testEvents("a")[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	s.close(200 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("close took %s with a blocked send", elapsed)
+	}
+	if ctx.Err() == nil {
+		t.Error("stream context not canceled")
+	}
+}
+
+func TestAckStreamLingerFlushFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sendErr := errors.New("transport is closing")
+	s := newAckStream(failingTransport{blockingTransport{ctx}, sendErr}, lingerBatch, 10, cancel)
+	if err := s.send(context.Background(), "f.offset", 10, testEvents("a")[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+		t.Fatal("linger flush failure did not break the stream")
+	}
+	if !errors.Is(s.failure(), sendErr) {
+		t.Errorf("failure = %v, want %v", s.failure(), sendErr)
+	}
+	if err := s.send(context.Background(), "f.offset", 20, testEvents("b")[0]); !errors.Is(err, sendErr) {
+		t.Errorf("send after failure = %v", err)
+	}
+}
+
+func TestAckStreamCloseDrains(t *testing.T) {
+	offsetFile := filepath.Join(t.TempDir(), "f.offset")
+	tr := newAckingTransport()
+	s := newAckStream(tr, lingerBatch, 10, func() {})
+	for i, line := range []string{"a", "b"} {
+		if err := s.send(context.Background(), offsetFile, int64(i+1)*10, testEvents(line)[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.close(time.Second)
+	if got := tr.payloads(); len(got) != 2 {
+		t.Fatalf("sent %d events, want 2", len(got))
+	}
+	if off := loadOffsetState(offsetFile).Offset; off != 20 {
+		t.Errorf("offset = %d, want 20", off)
+	}
+}
@@ -0,0 +1,194 @@
+package network
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sysprobe/internal/config"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// metadata 欄位，collector 依此辨識 agent
+const (
+	mdAgent     = "x-sysprobe-agent"
+	mdTimestamp = "x-sysprobe-timestamp"
+	mdNonce     = "x-sysprobe-nonce"
+	mdSignature = "x-sysprobe-signature"
+)
+
+// perRPCCredentials 依 network.auth 建立每個 RPC 的驗證資訊，type 為 none 時回傳 nil
+// requireTLS 為 false 時（明文模式）token 也會以明文送出
+func perRPCCredentials(cfg config.AuthConfig, agentUUID string, requireTLS bool) (credentials.PerRPCCredentials, error) {
+	switch cfg.Type {
+	case "", "none":
+		return nil, nil
+	case "token":
+		token, err := readSecret(cfg.TokenEnv, cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth token: %w", err)
+		}
+		return &staticToken{agent: agentUUID, token: token, requireTLS: requireTLS}, nil
+	case "token_file":
+		if cfg.TokenFile == "" {
+			return nil, errors.New("auth token_file: token_file is required")
+		}
+		t := &fileToken{agent: agentUUID, path: cfg.TokenFile, requireTLS: requireTLS}
+		if _, err := t.current(); err != nil {
+			return nil, fmt.Errorf("auth token_file: %w", err)
+		}
+		return t, nil
+	case "hmac":
+		secret, err := readSecret(cfg.SecretEnv, cfg.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth hmac secret: %w", err)
+		}
+		if agentUUID == "" {
+			return nil, errors.New("auth hmac: agent UUID is not available")
+		}
+		return &hmacSigner{agent: agentUUID, secret: []byte(secret), requireTLS: requireTLS}, nil
+	}
+	return nil, fmt.Errorf("unsupported auth type %q", cfg.Type)
+}
+
+// readSecret 先讀環境變數，沒有設定再讀檔案
+func readSecret(env, file string) (string, error) {
+	if env != "" {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			return v, nil
+		}
+		if file == "" {
+			return "", fmt.Errorf("environment variable %s is empty", env)
+		}
+	}
+	if file == "" {
+		return "", errors.New("neither env nor file is set")
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	v := strings.TrimSpace(string(b))
+	if v == "" {
+		return "", fmt.Errorf("%s is empty", file)
+	}
+	return v, nil
+}
+
+// ------------------------- token -------------------------
+
+// staticToken 啟動時讀取一次的 bearer token
+type staticToken struct {
+	agent      string
+	token      string
+	requireTLS bool
+}
+
+func (t *staticToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + t.token,
+		mdAgent:         t.agent,
+	}, nil
+}
+
+func (t *staticToken) RequireTransportSecurity() bool { return t.requireTLS }
+
+// fileToken 由其他程式輪替的 token 檔，修改時間變動時重新讀取
+// 只在建立串流時送出，輪替後的 token 於下次重新連線生效
+type fileToken struct {
+	agent      string
+	path       string
+	requireTLS bool
+
+	mu    sync.Mutex
+	mod   time.Time
+	token string
+}
+
+func (t *fileToken) current() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// 輪替過程中檔案可能暫時不存在，先沿用舊的
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", err
+	}
+	if t.token != "" && info.ModTime().Equal(t.mod) {
+		return t.token, nil
+	}
+
+	token, err := readSecret("", t.path)
+	if err != nil {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", err
+	}
+	t.token, t.mod = token, info.ModTime()
+	return token, nil
+}
+
+func (t *fileToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"authorization": "Bearer " + token,
+		mdAgent:         t.agent,
+	}, nil
+}
+
+func (t *fileToken) RequireTransportSecurity() bool { return t.requireTLS }
+
+// ------------------------- HMAC -------------------------
+
+// hmacSigner 以 agent 專屬的 secret 簽章：
+// HMAC-SHA256(secret, uuid + "\n" + timestamp + "\n" + nonce + "\n" + method)，hex 編碼
+// collector 依 x-sysprobe-agent 找到對應的 secret 驗證，並以 timestamp / nonce 防止重放
+type hmacSigner struct {
+	agent      string
+	secret     []byte
+	requireTLS bool
+}
+
+func (h *hmacSigner) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	var method string
+	if ri, ok := credentials.RequestInfoFromContext(ctx); ok {
+		method = ri.Method
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	n := hex.EncodeToString(nonce)
+
+	return map[string]string{
+		mdAgent:     h.agent,
+		mdTimestamp: ts,
+		mdNonce:     n,
+		mdSignature: signHMAC(h.secret, h.agent, ts, n, method),
+	}, nil
+}
+
+func (h *hmacSigner) RequireTransportSecurity() bool { return h.requireTLS }
+
+func signHMAC(secret []byte, agent, ts, nonce, method string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(agent + "\n" + ts + "\n" + nonce + "\n" + method))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,144 @@
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"testing"
+	"time"
+)
+
+func TestReadSecret(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	os.WriteFile(file, []byte("  from-file\n"), 0600)
+	empty := filepath.Join(dir, "empty")
+	os.WriteFile(empty, []byte("\n"), 0600)
+	t.Setenv("SYSPROBE_TEST_TOKEN", " from-env ")
+	t.Setenv("SYSPROBE_TEST_EMPTY", "")
+
+	tests := []struct {
+		env, file string
+		want      string
+		wantErr   bool
+	}{
+		{"SYSPROBE_TEST_TOKEN", file, "from-env", false}, // 環境變數優先
+		{"SYSPROBE_TEST_TOKEN", "", "from-env", false},
+		{"SYSPROBE_TEST_EMPTY", file, "from-file", false}, // 環境變數為空時改讀檔案
+		{"SYSPROBE_TEST_UNSET", file, "from-file", false},
+		{"", file, "from-file", false},
+		{"SYSPROBE_TEST_EMPTY", "", "", true},
+		{"", "", "", true},
+		{"", filepath.Join(dir, "missing"), "", true},
+		{"", empty, "", true},
+	}
+	for _, tt := range tests {
+		got, err := readSecret(tt.env, tt.file)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("readSecret(%q, %q) = %q, %v, want %q (error %v)", tt.env, tt.file, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFileTokenRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	mod := time.Now().Add(-time.Hour)
+	rotate := func(token string) {
+		t.Helper()
+		// 輪替程式常見的做法：寫入暫存檔再 rename
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(token+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mod = mod.Add(time.Minute)
+		os.Chtimes(tmp, mod, mod)
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := (&fileToken{path: path}).current(); err == nil {
+		t.Fatal("missing token file accepted")
+	}
+
+	rotate("token-1")
+	ft := &fileToken{agent: "uuid-1", path: path}
+	if got, err := ft.current(); err != nil || got != "token-1" {
+		t.Fatalf("current = %q, %v", got, err)
+	}
+
+	rotate("token-2")
+	md, err := ft.GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer token-2" || md[mdAgent] != "uuid-1" {
+		t.Errorf("metadata after rotation = %v, %v", md, err)
+	}
+
+	// 輪替過程中檔案暫時不存在或是空的，沿用舊的 token
+	os.Remove(path)
+	if got, err := ft.current(); err != nil || got != "token-2" {
+		t.Errorf("current while missing = %q, %v", got, err)
+	}
+	os.WriteFile(path, nil, 0600)
+	if got, err := ft.current(); err != nil || got != "token-2" {
+		t.Errorf("current while empty = %q, %v", got, err)
+	}
+
+	rotate("token-3")
+	if got, err := ft.current(); err != nil || got != "token-3" {
+		t.Errorf("current after rotation = %q, %v", got, err)
+	}
+}
+
+func TestSignHMAC(t *testing.T) {
+	// 以 python hmac.new(secret, msg, hashlib.sha256).hexdigest() 產生
+	got := signHMAC([]byte("s3cret"), "5b3f7c2e-1d4a-4e8b-9c6f-2a1b3c4d5e6f", "1760860800",
+		"00112233445566778899aabbccddeeff", "/logstream.LogStreamer/StreamBatches")
+	if want := "3844c336399042a7fce2bbda04107eacfc693ea883f477a1b3461da9c06903ca"; got != want {
+		t.Errorf("signHMAC = %s, want %s", got, want)
+	}
+
+	h := &hmacSigner{agent: "uuid-1", secret: []byte("s3cret")}
+	md, err := h.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md[mdNonce]) != 32 || md[mdAgent] != "uuid-1" {
+		t.Errorf("metadata = %v", md)
+	}
+	if want := signHMAC(h.secret, "uuid-1", md[mdTimestamp], md[mdNonce], ""); md[mdSignature] != want {
+		t.Errorf("signature = %s, want %s", md[mdSignature], want)
+	}
+	if again, _ := h.GetRequestMetadata(context.Background()); again[mdNonce] == md[mdNonce] {
+		t.Error("nonce reused")
+	}
+}
+
+func TestPerRPCCredentials(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secret")
+	os.WriteFile(file, []byte("s3cret"), 0600)
+
+	for _, tt := range []struct {
+		cfg     config.AuthConfig
+		agent   string
+		wantNil bool
+		wantErr bool
+	}{
+		{config.AuthConfig{}, "uuid-1", true, false},
+		{config.AuthConfig{Type: "none"}, "uuid-1", true, false},
+		{config.AuthConfig{Type: "token", TokenFile: file}, "uuid-1", false, false},
+		{config.AuthConfig{Type: "token"}, "uuid-1", false, true},
+		{config.AuthConfig{Type: "token_file"}, "uuid-1", false, true},
+		{config.AuthConfig{Type: "token_file", TokenFile: filepath.Join(dir, "missing")}, "uuid-1", false, true},
+		{config.AuthConfig{Type: "hmac", SecretFile: file}, "uuid-1", false, false},
+		{config.AuthConfig{Type: "hmac", SecretFile: file}, "", false, true},
+		{config.AuthConfig{Type: "basic"}, "uuid-1", false, true},
+	} {
+		creds, err := perRPCCredentials(tt.cfg, tt.agent, true)
+		if (err != nil) != tt.wantErr || (err == nil && (creds == nil) != tt.wantNil) {
+			t.Errorf("perRPCCredentials(%+v, %q) = %v, %v", tt.cfg, tt.agent, creds, err)
+		}
+	}
+}
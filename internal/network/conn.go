@@ -0,0 +1,227 @@
+package network
+
+import (
+	"context"
+	"math/rand/v2"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"sysprobe/internal/utils"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// 重新連線的預設值（秒）
+const (
+	defaultBackoffInitial = 1
+	defaultBackoffMax     = 60
+)
+
+// 串流維持超過這段時間才視為正常，退避次數歸零
+const stableStream = time.Minute
+
+// failover 時使用備援 collector 的串流每隔這段時間重建一次，優先的 collector 恢復後切回
+const failbackInterval = 5 * time.Minute
+
+// connManager 所有 category 共用的一個 gRPC ClientConn，每個 category 在上面開自己的串流
+// 斷線重連由 gRPC 處理（同樣的退避設定），串流建立時等待連線 READY
+// 有多個 collector 時每條串流依 endpoints.strategy 選擇其中一台
+type connManager struct {
+	cfg      config.NetworkConfig
+	sink     config.SinkConfig
+	agent    string // hash 策略依此選擇 collector
+	target   string
+	conn     *grpc.ClientConn
+	client   logstream.LogStreamerClient
+	callOpts []grpc.CallOption
+	retry    backoffPolicy
+	batch    config.BatchConfig
+}
+
+func newConnManager(ctx context.Context, cfg config.NetworkConfig, sink config.SinkConfig, agent string, dialOpts []grpc.DialOption) (*connManager, error) {
+	target, endpoints, err := endpointTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m := &connManager{
+		cfg:    cfg,
+		sink:   sink,
+		agent:  agent,
+		target: target,
+		retry:  newBackoffPolicy(sink.Retry, cfg.Backoff),
+		batch:  cfg.Batch,
+	}
+	// 與其他 sink 相同使用 sinks[].batch，沒有設定時沿用 network.batch
+	if sink.Batch != (config.BatchConfig{}) {
+		m.batch = sink.Batch
+	}
+
+	opts := append([]grpc.DialOption{}, dialOpts...)
+	opts = append(opts, grpc.WithResolvers(endpoints), grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  m.retry.initial,
+			Multiplier: 2,
+			Jitter:     0.2,
+			MaxDelay:   m.retry.max,
+		},
+		MinConnectTimeout: 20 * time.Second,
+	}))
+	if cfg.Keepalive > 0 {
+		// 偵測沒有回應的連線（例如中間的 NAT / 防火牆丟掉連線）
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    time.Duration(cfg.Keepalive) * time.Second,
+			Timeout: 10 * time.Second,
+		}))
+	}
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = conn
+	m.client = logstream.NewLogStreamerClient(conn)
+
+	// 壓縮透過 gRPC 的 grpc-encoding 協商；連線尚未 READY 時等待而不是直接失敗
+	m.callOpts = []grpc.CallOption{grpc.WaitForReady(true)}
+	switch cfg.Compression {
+	case "", "none":
+	case "gzip", "zstd":
+		m.callOpts = append(m.callOpts, grpc.UseCompressor(cfg.Compression))
+	default:
+		utils.Log.Error("[Network] unsupported compression %s, sending uncompressed", cfg.Compression)
+	}
+
+	conn.Connect()
+	go m.watchState(ctx)
+	return m, nil
+}
+
+// watchState 記錄連線狀態變化
+func (m *connManager) watchState(ctx context.Context) {
+	state := m.conn.GetState()
+	for m.conn.WaitForStateChange(ctx, state) {
+		state = m.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			utils.Log.Info("[Network] connected to %s", m.target)
+		case connectivity.TransientFailure:
+			utils.Log.Warn("[Network] connection to %s failed, reconnecting", m.target)
+		case connectivity.Idle:
+			// 閒置或被 server 關閉（GOAWAY）後立即重新連線
+			m.conn.Connect()
+		}
+	}
+}
+
+func (m *connManager) name() string { return m.sink.Name }
+
+// open 建立一條串流，失敗時依退避時間重試；ctx 結束時回傳 nil
+// 串流使用獨立的 context，關閉時才能先把剩下的批次送完
+func (m *connManager) open(ctx context.Context) *ackStream {
+	for attempt := 0; ; attempt++ {
+		if !sleepCtx(ctx, m.retry.delay(attempt)) {
+			return nil
+		}
+
+		p := &picked{}
+		streamCtx, cancel := context.WithCancel(withPicked(withHashKey(context.Background(), m.agent), p))
+		stop := context.AfterFunc(ctx, cancel)
+		t, err := m.newTransport(streamCtx)
+		stop()
+		if err != nil {
+			cancel()
+			if ctx.Err() != nil {
+				return nil
+			}
+			utils.Log.Error("[Network] open stream failed, retrying: %v", err)
+			continue
+		}
+		s := newAckStream(t, m.batch, m.sink.Buffer, cancel)
+		s.priority = int(p.priority.Load())
+		return s
+	}
+}
+
+// failback 串流是否連到備援的 collector 且已經使用一段時間，應該重建以切回優先的 collector
+func (m *connManager) failback(s *ackStream, opened time.Time) bool {
+	return s.priority > 0 && time.Since(opened) >= failbackInterval
+}
+
+func (m *connManager) newTransport(ctx context.Context) (ackTransport, error) {
+	if m.batch.Enable {
+		stream, err := m.client.StreamBatches(ctx, m.callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		return batchTransport{stream}, nil
+	}
+	stream, err := m.client.StreamLogsAck(ctx, m.callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return eventTransport{stream}, nil
+}
+
+func (m *connManager) backoff(n int) time.Duration { return m.retry.delay(n) }
+
+func (m *connManager) close() {
+	m.conn.Close()
+}
+
+// backoffPolicy 指數退避，每次等待時間另加隨機抖動
+type backoffPolicy struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+// newBackoffPolicy 使用 cfg，沒有設定的欄位沿用 fallback，再沒有則為預設值
+func newBackoffPolicy(cfg, fallback config.BackoffConfig) backoffPolicy {
+	initial, maxDelay := cfg.Initial, cfg.Max
+	if initial <= 0 {
+		initial = fallback.Initial
+	}
+	if maxDelay <= 0 {
+		maxDelay = fallback.Max
+	}
+	if initial <= 0 {
+		initial = defaultBackoffInitial
+	}
+	if maxDelay < initial {
+		maxDelay = max(defaultBackoffMax, initial)
+	}
+	return backoffPolicy{
+		initial: time.Duration(initial) * time.Second,
+		max:     time.Duration(maxDelay) * time.Second,
+	}
+}
+
+// delay 第 n 次重試前的等待時間：initial * 2^(n-1)，上限 max，取其中 50%~100% 的隨機值
+func (b backoffPolicy) delay(n int) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	d := b.initial
+	for i := 1; i < n && d < b.max; i++ {
+		d *= 2
+	}
+	d = min(d, b.max)
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleepCtx 等待 d，ctx 結束時回傳 false
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
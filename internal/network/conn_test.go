@@ -0,0 +1,65 @@
+package network
+
+import (
+	"sysprobe/internal/config"
+	"testing"
+	"time"
+)
+
+func TestNewBackoffPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		sink, network config.BackoffConfig
+		initial, max  time.Duration
+	}{
+		{"defaults", config.BackoffConfig{}, config.BackoffConfig{}, defaultBackoffInitial * time.Second, defaultBackoffMax * time.Second},
+		{"network", config.BackoffConfig{}, config.BackoffConfig{Initial: 2, Max: 30}, 2 * time.Second, 30 * time.Second},
+		{"sink", config.BackoffConfig{Initial: 5, Max: 120}, config.BackoffConfig{Initial: 2, Max: 30}, 5 * time.Second, 120 * time.Second},
+		// 每個欄位各自依序 sink → network → 預設值
+		{"mixed", config.BackoffConfig{Max: 10}, config.BackoffConfig{Initial: 3}, 3 * time.Second, 10 * time.Second},
+		{"sink initial only", config.BackoffConfig{Initial: 4}, config.BackoffConfig{}, 4 * time.Second, defaultBackoffMax * time.Second},
+		// max 小於 initial 時改用預設上限
+		{"max below initial", config.BackoffConfig{Initial: 90, Max: 10}, config.BackoffConfig{}, 90 * time.Second, 90 * time.Second},
+		{"negative max", config.BackoffConfig{}, config.BackoffConfig{Max: -1}, defaultBackoffInitial * time.Second, defaultBackoffMax * time.Second},
+	}
+	for _, tt := range tests {
+		b := newBackoffPolicy(tt.sink, tt.network)
+		if b.initial != tt.initial || b.max != tt.max {
+			t.Errorf("%s: initial %s max %s, want %s %s", tt.name, b.initial, b.max, tt.initial, tt.max)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := backoffPolicy{initial: time.Second, max: 20 * time.Second}
+	if d := b.delay(0); d != 0 {
+		t.Errorf("delay(0) = %s, want 0", d)
+	}
+
+	// 1s, 2s, 4s, 8s, 16s 之後固定為 20s；每次取 50%~100%
+	tests := []struct {
+		n    int
+		base time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 20 * time.Second},
+		{100, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		lo, hi := tt.base, time.Duration(0)
+		for i := 0; i < 500; i++ {
+			d := b.delay(tt.n)
+			if d < tt.base/2 || d > tt.base {
+				t.Fatalf("delay(%d) = %s, want within [%s, %s]", tt.n, d, tt.base/2, tt.base)
+			}
+			lo, hi = min(lo, d), max(hi, d)
+		}
+		// 抖動要分布在整個範圍，而不是固定值
+		if hi-lo < tt.base/4 {
+			t.Errorf("delay(%d) ranged %s..%s, jitter too narrow", tt.n, lo, hi)
+		}
+	}
+}
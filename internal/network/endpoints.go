@@ -0,0 +1,390 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"sysprobe/internal/config"
+	"sysprobe/internal/utils"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	_ "google.golang.org/grpc/health" // client 端 health check
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+const (
+	endpointScheme  = "sysprobe"
+	defaultResolve  = 30 // 秒
+	minResolveNow   = 5 * time.Second
+	defaultStrategy = "failover"
+)
+
+// 每種策略一個 balancer，由 resolver 的 service config 選擇
+var strategies = map[string]string{
+	"failover":    "sysprobe_failover",
+	"round_robin": "sysprobe_round_robin",
+	"hash":        "sysprobe_hash",
+}
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(strategies["failover"], pickerBuilder(newFailoverPicker), base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(strategies["round_robin"], pickerBuilder(newRoundRobinPicker), base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(strategies["hash"], pickerBuilder(newHashPicker), base.Config{HealthCheck: true}))
+}
+
+// endpointTarget 連線目標與對應的 resolver，host / hosts / srv 都沒有設定時回傳錯誤
+func endpointTarget(cfg config.NetworkConfig) (string, resolver.Builder, error) {
+	hosts := cfg.Endpoints.Hosts
+	if cfg.Host != "" {
+		hosts = append([]string{cfg.Host}, hosts...)
+	}
+	if len(hosts) == 0 && cfg.Endpoints.SRV == "" {
+		return "", nil, errors.New("no collector endpoint: set network.host, network.endpoints.hosts or network.endpoints.srv")
+	}
+	for _, h := range hosts {
+		if _, _, err := net.SplitHostPort(h); err != nil {
+			return "", nil, fmt.Errorf("endpoint %q: %w", h, err)
+		}
+	}
+
+	strategy := cfg.Endpoints.Strategy
+	if strategy == "" {
+		strategy = defaultStrategy
+	}
+	lb, ok := strategies[strategy]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported endpoints strategy %q", strategy)
+	}
+	sc := map[string]any{
+		"loadBalancingConfig": []any{map[string]any{lb: map[string]any{}}},
+	}
+	if cfg.Endpoints.HealthCheck {
+		sc["healthCheckConfig"] = map[string]any{"serviceName": cfg.Endpoints.HealthService}
+	}
+	scJSON, _ := json.Marshal(sc)
+
+	interval := time.Duration(cfg.Endpoints.Resolve) * time.Second
+	if interval <= 0 {
+		interval = defaultResolve * time.Second
+	}
+
+	name := cfg.Endpoints.SRV
+	if len(hosts) > 0 {
+		name = hosts[0]
+	}
+	b := &endpointBuilder{hosts: hosts, srv: cfg.Endpoints.SRV, serviceConfig: string(scJSON), interval: interval, dns: net.DefaultResolver}
+	return endpointScheme + ":///" + name, b, nil
+}
+
+// ------------------------- resolver -------------------------
+
+// priorityKey address 的優先順序，數字越小越優先
+type priorityKey struct{}
+
+// dnsResolver net.Resolver 中解析 collector 用到的部分
+type dnsResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type endpointBuilder struct {
+	hosts         []string
+	srv           string
+	serviceConfig string
+	interval      time.Duration
+	dns           dnsResolver
+}
+
+func (b *endpointBuilder) Scheme() string { return endpointScheme }
+
+func (b *endpointBuilder) Build(_ resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &endpointResolver{
+		b:      b,
+		cc:     cc,
+		sc:     cc.ParseServiceConfig(b.serviceConfig),
+		cancel: cancel,
+		now:    make(chan struct{}, 1),
+	}
+	if r.sc.Err != nil {
+		cancel()
+		return nil, r.sc.Err
+	}
+	r.wg.Add(1)
+	go r.watch(ctx)
+	return r, nil
+}
+
+// endpointResolver 定期解析 hosts 與 SRV，gRPC 要求時（連線失敗）也會提早解析
+type endpointResolver struct {
+	b      *endpointBuilder
+	cc     resolver.ClientConn
+	sc     *serviceconfig.ParseResult
+	cancel context.CancelFunc
+	now    chan struct{}
+	wg     sync.WaitGroup
+
+	last []resolver.Address
+}
+
+func (r *endpointResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *endpointResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *endpointResolver) watch(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.b.interval)
+	defer ticker.Stop()
+
+	var lastResolve time.Time
+	for {
+		if time.Since(lastResolve) >= minResolveNow {
+			lastResolve = time.Now()
+			r.update(ctx)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.now:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *endpointResolver) update(ctx context.Context) {
+	addrs, err := r.resolve(ctx)
+	if len(addrs) == 0 {
+		if err == nil {
+			err = errors.New("no collector address resolved")
+		}
+		// DNS 暫時失敗時沿用上次的結果
+		if r.last != nil {
+			utils.Log.Warn("[Network] resolve endpoints fail, keep %d previous addresses: %v", len(r.last), err)
+			return
+		}
+		r.cc.ReportError(err)
+		return
+	}
+	if err != nil {
+		utils.Log.Warn("[Network] resolve endpoints: %v", err)
+	}
+	if sameAddresses(addrs, r.last) {
+		return
+	}
+	r.last = addrs
+	utils.Log.Info("[Network] collector endpoints: %s", formatAddresses(addrs))
+	r.cc.UpdateState(resolver.State{Addresses: addrs, ServiceConfig: r.sc})
+}
+
+// resolve hosts 依序為優先順序 0, 1, 2...，SRV 接在後面並依記錄的 priority 分組
+func (r *endpointResolver) resolve(ctx context.Context) ([]resolver.Address, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var addrs []resolver.Address
+	var errs []error
+	seen := make(map[string]bool)
+	add := func(host, port string, priority int) {
+		ips := []string{host}
+		if net.ParseIP(host) == nil {
+			var err error
+			ips, err = r.b.dns.LookupHost(lookupCtx, host)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+		}
+		for _, ip := range ips {
+			addr := net.JoinHostPort(ip, port)
+			if seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			addrs = append(addrs, resolver.Address{
+				Addr:       addr,
+				ServerName: host, // TLS 以主機名稱驗證
+				Attributes: attributes.New(priorityKey{}, priority),
+			})
+		}
+	}
+
+	for i, h := range r.b.hosts {
+		host, port, _ := net.SplitHostPort(h)
+		add(host, port, i)
+	}
+
+	if r.b.srv != "" {
+		_, records, err := r.b.dns.LookupSRV(lookupCtx, "", "", r.b.srv)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, rec := range records {
+			host := strings.TrimSuffix(rec.Target, ".")
+			add(host, fmt.Sprint(rec.Port), len(r.b.hosts)+int(rec.Priority))
+		}
+	}
+	return addrs, errors.Join(errs...)
+}
+
+func sameAddresses(a, b []resolver.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatAddresses(addrs []resolver.Address) string {
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = a.Addr
+		if host, _, _ := net.SplitHostPort(a.Addr); a.ServerName != "" && a.ServerName != host {
+			out[i] = a.ServerName + "(" + a.Addr + ")"
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// ------------------------- picker -------------------------
+
+// 串流的 context 帶著 agent UUID，hash 與 failover 同優先順序時依此選擇
+type hashKey struct{}
+
+func withHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+// pickedEndpoint 記錄串流建立時選到的 collector 優先順序
+type pickedEndpoint struct{}
+
+type picked struct {
+	priority atomic.Int64
+}
+
+func withPicked(ctx context.Context, p *picked) context.Context {
+	return context.WithValue(ctx, pickedEndpoint{}, p)
+}
+
+func recordPick(ctx context.Context, c readyConn) {
+	if p, ok := ctx.Value(pickedEndpoint{}).(*picked); ok {
+		p.priority.Store(int64(c.priority))
+	}
+}
+
+type readyConn struct {
+	sc       balancer.SubConn
+	addr     string
+	priority int
+}
+
+type pickerBuilder func(conns []readyConn) balancer.Picker
+
+// Build 只會收到 READY（且 health check 通過）的 SubConn
+func (f pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	conns := make([]readyConn, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		priority, _ := sci.Address.Attributes.Value(priorityKey{}).(int)
+		conns = append(conns, readyConn{sc: sc, addr: sci.Address.Addr, priority: priority})
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		if conns[i].priority != conns[j].priority {
+			return conns[i].priority < conns[j].priority
+		}
+		return conns[i].addr < conns[j].addr
+	})
+	return f(conns)
+}
+
+// failoverPicker 使用優先順序最高的 collector；同一個優先順序有多台時依 agent UUID 固定一台
+type failoverPicker struct {
+	conns []readyConn // 只保留最優先的一組
+}
+
+func newFailoverPicker(conns []readyConn) balancer.Picker {
+	n := 1
+	for n < len(conns) && conns[n].priority == conns[0].priority {
+		n++
+	}
+	return &failoverPicker{conns: conns[:n]}
+}
+
+func (p *failoverPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	c := rendezvous(p.conns, info.Ctx)
+	recordPick(info.Ctx, c)
+	return balancer.PickResult{SubConn: c.sc}, nil
+}
+
+type roundRobinPicker struct {
+	conns []readyConn
+	next  atomic.Uint32
+}
+
+func newRoundRobinPicker(conns []readyConn) balancer.Picker {
+	p := &roundRobinPicker{conns: conns}
+	p.next.Store(rand.Uint32())
+	return p
+}
+
+func (p *roundRobinPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	i := p.next.Add(1) % uint32(len(p.conns))
+	return balancer.PickResult{SubConn: p.conns[i].sc}, nil
+}
+
+// hashPicker 依 agent UUID 做 rendezvous hashing：collector 增減時只有受影響的 agent 會換
+type hashPicker struct {
+	conns []readyConn
+}
+
+func newHashPicker(conns []readyConn) balancer.Picker {
+	return &hashPicker{conns: conns}
+}
+
+func (p *hashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	return balancer.PickResult{SubConn: rendezvous(p.conns, info.Ctx).sc}, nil
+}
+
+// rendezvous 分數最高的 collector；沒有 key 時為第一台
+func rendezvous(conns []readyConn, ctx context.Context) readyConn {
+	key, _ := ctx.Value(hashKey{}).(string)
+	if key == "" || len(conns) == 1 {
+		return conns[0]
+	}
+	best, bestScore := conns[0], uint64(0)
+	for _, c := range conns {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(c.addr))
+		if s := h.Sum64(); s > bestScore {
+			best, bestScore = c, s
+		}
+	}
+	return best
+}
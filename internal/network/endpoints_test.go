@@ -0,0 +1,218 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeDNS 固定的解析結果，err 不為 nil 時所有查詢都失敗
+type fakeDNS struct {
+	hosts map[string][]string
+	srv   []*net.SRV
+	err   error
+}
+
+func (d *fakeDNS) LookupHost(_ context.Context, host string) ([]string, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	ips, ok := d.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+func (d *fakeDNS) LookupSRV(context.Context, string, string, string) (string, []*net.SRV, error) {
+	if d.err != nil {
+		return "", nil, d.err
+	}
+	return "", d.srv, nil
+}
+
+// fakeClientConn 記錄 resolver 回報的狀態與錯誤
+type fakeClientConn struct {
+	resolver.ClientConn
+	states []resolver.State
+	errs   []error
+}
+
+func (c *fakeClientConn) UpdateState(s resolver.State) error {
+	c.states = append(c.states, s)
+	return nil
+}
+
+func (c *fakeClientConn) ReportError(err error) { c.errs = append(c.errs, err) }
+
+func testConns(addrs ...string) []readyConn {
+	conns := make([]readyConn, len(addrs))
+	for i, a := range addrs {
+		conns[i] = readyConn{addr: a}
+	}
+	return conns
+}
+
+func TestResolvePriorities(t *testing.T) {
+	dns := &fakeDNS{
+		hosts: map[string][]string{
+			"collector-b":    {"10.0.0.2"},
+			"c1.example.com": {"10.0.1.1"},
+			"c2.example.com": {"10.0.1.2", "10.0.0.1"},
+		},
+		srv: []*net.SRV{
+			{Target: "c1.example.com.", Port: 6000, Priority: 0},
+			{Target: "c2.example.com.", Port: 50051, Priority: 1},
+		},
+	}
+	r := &endpointResolver{b: &endpointBuilder{
+		hosts: []string{"10.0.0.1:50051", "collector-b:50051"},
+		srv:   "_sysprobe._tcp.example.com",
+		dns:   dns,
+	}}
+	addrs, err := r.resolve(context.Background())
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	// hosts 依序為 0、1，SRV 的 priority 接在 hosts 之後；重複的位址只保留最優先的一筆
+	want := []struct {
+		addr, server string
+		priority     int
+	}{
+		{"10.0.0.1:50051", "10.0.0.1", 0},
+		{"10.0.0.2:50051", "collector-b", 1},
+		{"10.0.1.1:6000", "c1.example.com", 2},
+		{"10.0.1.2:50051", "c2.example.com", 3},
+	}
+	if len(addrs) != len(want) {
+		t.Fatalf("got %d addresses (%s), want %d", len(addrs), formatAddresses(addrs), len(want))
+	}
+	for i, w := range want {
+		priority, _ := addrs[i].Attributes.Value(priorityKey{}).(int)
+		if addrs[i].Addr != w.addr || addrs[i].ServerName != w.server || priority != w.priority {
+			t.Errorf("address %d = %s %s priority %d, want %s %s priority %d",
+				i, addrs[i].Addr, addrs[i].ServerName, priority, w.addr, w.server, w.priority)
+		}
+	}
+}
+
+func TestResolveKeepsLastOnFailure(t *testing.T) {
+	dns := &fakeDNS{hosts: map[string][]string{"collector-a": {"10.0.0.1"}}}
+	cc := &fakeClientConn{}
+	r := &endpointResolver{b: &endpointBuilder{hosts: []string{"collector-a:50051"}, dns: dns}, cc: cc}
+
+	r.update(context.Background())
+	r.update(context.Background())
+	if len(cc.states) != 1 || len(cc.states[0].Addresses) != 1 {
+		t.Fatalf("states = %+v, want one update with one address", cc.states)
+	}
+
+	// DNS 失敗時沿用上次的結果，不回報錯誤也不清空位址
+	dns.err = errors.New("i/o timeout")
+	r.update(context.Background())
+	if len(cc.states) != 1 || len(cc.errs) != 0 {
+		t.Errorf("after failure: %d states, errors %v", len(cc.states), cc.errs)
+	}
+	if len(r.last) != 1 || r.last[0].Addr != "10.0.0.1:50051" {
+		t.Errorf("last = %s", formatAddresses(r.last))
+	}
+
+	// 恢復後位址改變才更新
+	dns.err = nil
+	dns.hosts["collector-a"] = []string{"10.0.0.9"}
+	r.update(context.Background())
+	if len(cc.states) != 2 || cc.states[1].Addresses[0].Addr != "10.0.0.9:50051" {
+		t.Errorf("states after recovery = %+v", cc.states)
+	}
+
+	// 從來沒有解析成功過時回報錯誤
+	cc = &fakeClientConn{}
+	r = &endpointResolver{b: &endpointBuilder{hosts: []string{"collector-a:50051"}, dns: &fakeDNS{err: errors.New("i/o timeout")}}, cc: cc}
+	r.update(context.Background())
+	if len(cc.states) != 0 || len(cc.errs) != 1 {
+		t.Errorf("first failure: %d states, errors %v", len(cc.states), cc.errs)
+	}
+}
+
+func TestSameAddresses(t *testing.T) {
+	addr := func(a string, priority int) resolver.Address {
+		return resolver.Address{Addr: a, ServerName: "collector", Attributes: attributes.New(priorityKey{}, priority)}
+	}
+	base := []resolver.Address{addr("10.0.0.1:50051", 0), addr("10.0.0.2:50051", 1)}
+	tests := []struct {
+		name string
+		b    []resolver.Address
+		want bool
+	}{
+		{"equal", []resolver.Address{addr("10.0.0.1:50051", 0), addr("10.0.0.2:50051", 1)}, true},
+		{"shorter", base[:1], false},
+		{"nil", nil, false},
+		{"reordered", []resolver.Address{base[1], base[0]}, false},
+		{"priority", []resolver.Address{addr("10.0.0.1:50051", 0), addr("10.0.0.2:50051", 2)}, false},
+		{"server name", []resolver.Address{base[0], {Addr: "10.0.0.2:50051", ServerName: "other", Attributes: base[1].Attributes}}, false},
+	}
+	for _, tt := range tests {
+		if got := sameAddresses(base, tt.b); got != tt.want {
+			t.Errorf("%s: sameAddresses = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFailoverPickerTopPriority(t *testing.T) {
+	conns := []readyConn{
+		{addr: "10.0.0.1:50051", priority: 2},
+		{addr: "10.0.0.2:50051", priority: 2},
+		{addr: "10.0.0.3:50051", priority: 3},
+		{addr: "10.0.0.4:50051", priority: 5},
+	}
+	p := newFailoverPicker(conns).(*failoverPicker)
+	if len(p.conns) != 2 || p.conns[0].addr != "10.0.0.1:50051" || p.conns[1].addr != "10.0.0.2:50051" {
+		t.Errorf("failover group = %+v", p.conns)
+	}
+
+	p = newFailoverPicker(conns[2:]).(*failoverPicker)
+	if len(p.conns) != 1 || p.conns[0].addr != "10.0.0.3:50051" {
+		t.Errorf("failover group = %+v", p.conns)
+	}
+}
+
+func TestRendezvous(t *testing.T) {
+	conns := testConns("10.0.0.1:50051", "10.0.0.2:50051", "10.0.0.3:50051", "10.0.0.4:50051")
+	removed := conns[2].addr
+	remaining := append(append([]readyConn(nil), conns[:2]...), conns[3:]...)
+
+	// 沒有 key 時為第一台
+	if got := rendezvous(conns, context.Background()); got.addr != conns[0].addr {
+		t.Errorf("no key picked %s", got.addr)
+	}
+
+	perConn := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		ctx := withHashKey(context.Background(), fmt.Sprintf("agent-%04d", i))
+		got := rendezvous(conns, ctx).addr
+		if again := rendezvous(conns, ctx).addr; again != got {
+			t.Fatalf("agent %d: picked %s then %s", i, got, again)
+		}
+		perConn[got]++
+
+		// 移除一台時只有原本在那一台的 agent 會換
+		after := rendezvous(remaining, ctx).addr
+		if got != removed && after != got {
+			t.Errorf("agent %d moved from %s to %s", i, got, after)
+		}
+		if after == removed {
+			t.Errorf("agent %d still on removed %s", i, removed)
+		}
+	}
+	for _, c := range conns {
+		if perConn[c.addr] == 0 {
+			t.Errorf("no agent picked %s: %v", c.addr, perConn)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"sysprobe/internal/monitor/cpu"
+	"sysprobe/internal/monitor/disk"
+	"sysprobe/internal/monitor/memory"
+	"sysprobe/internal/monitor/network"
+	logstream "sysprobe/internal/network/logstream"
+	"time"
+)
+
+// payload 與 LogEvent 欄位的格式版本，欄位有不相容的變動時遞增
+const schemaVersion = 1
+
+// envelope 所有收集器共有的欄位；DISK / NETWORK 的時間在每個項目上
+type envelope struct {
+	Timestamp string `json:"Timestamp"`
+	Host      struct {
+		Hostname string `json:"Hostname"`
+	} `json:"Host"`
+	Partitions []struct {
+		Timestamp string `json:"Timestamp"`
+	} `json:"Partitions"`
+	Interfaces []struct {
+		Timestamp string `json:"Timestamp"`
+	} `json:"Interfaces"`
+}
+
+// eventBuilder 把一行 JSON 轉成 LogEvent
+type eventBuilder struct {
+	agent  string
+	labels map[string]string
+	typed  bool
+}
+
+func (b *eventBuilder) build(category, name string, offset int64, line []byte) *logstream.LogEvent {
+	event := &logstream.LogEvent{
+		Timestamp:      time.Now().Format(time.RFC3339Nano),
+		Source:         "SysProbe",
+		Payload:        line,
+		IdempotencyKey: fmt.Sprintf("%s:%s:%d", b.agent, name, offset),
+		AgentUuid:      b.agent,
+		Category:       category,
+		SchemaVersion:  schemaVersion,
+	}
+
+	labels := make(map[string]string, len(b.labels)+1)
+	for k, v := range b.labels {
+		labels[k] = v
+	}
+
+	// 格式錯誤的行仍照原樣送出，只是少了這些欄位
+	var env envelope
+	if json.Unmarshal(line, &env) == nil {
+		event.SampleTimestamp = env.Timestamp
+		if event.SampleTimestamp == "" && len(env.Partitions) > 0 {
+			event.SampleTimestamp = env.Partitions[0].Timestamp
+		}
+		if event.SampleTimestamp == "" && len(env.Interfaces) > 0 {
+			event.SampleTimestamp = env.Interfaces[0].Timestamp
+		}
+		if env.Host.Hostname != "" {
+			labels["hostname"] = env.Host.Hostname
+		}
+	}
+	event.Labels = labels
+
+	if b.typed {
+		setTypedSample(event, category, line)
+	}
+	return event
+}
+
+// setTypedSample 附上基本收集器的 protobuf 樣本，其他 category 不處理
+func setTypedSample(event *logstream.LogEvent, category string, line []byte) {
+	switch category {
+	case cpu.Category:
+		var v cpu.CPUInfo
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		s := &logstream.CPUSample{
+			CoreCount:        uint32(v.CoreCount),
+			Model:            v.CpuModel,
+			Mhz:              v.CpuMHz,
+			UsagePercent:     v.CpuUsage,
+			CoreUsagePercent: v.CoreUsage,
+			Time: &logstream.CPUTime{
+				User:   v.CpuTime.User,
+				System: v.CpuTime.System,
+				Idle:   v.CpuTime.Idle,
+				Nice:   v.CpuTime.Nice,
+				Iowait: v.CpuTime.IOWait,
+				Irq:    v.CpuTime.IRQ,
+			},
+		}
+		// LoadAverage 在 Windows 上是字串
+		if load, ok := v.LoadAverage.([]any); ok {
+			for _, l := range load {
+				if f, ok := l.(float64); ok {
+					s.LoadAverage = append(s.LoadAverage, f)
+				}
+			}
+		}
+		event.Sample = &logstream.LogEvent_Cpu{Cpu: s}
+
+	case memory.Category:
+		var v memory.MemoryInfo
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		event.Sample = &logstream.LogEvent_Memory{Memory: &logstream.MemorySample{
+			TotalBytes:  v.Total,
+			UsedBytes:   v.Used,
+			FreeBytes:   v.Free,
+			UsedPercent: v.UsedPct,
+		}}
+
+	case disk.Category:
+		var v disk.DiskInfoJSON
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		s := &logstream.DiskSample{}
+		for _, p := range v.Partitions {
+			s.Partitions = append(s.Partitions, &logstream.DiskPartition{
+				Name:                p.Name,
+				Mount:               p.Mount,
+				Fs:                  p.Fs,
+				TotalGib:            p.Total,
+				UsedGib:             p.Used,
+				FreeGib:             p.Free,
+				UsagePercent:        p.Usage,
+				ReadBytesPerSecond:  p.ReadRate,
+				WriteBytesPerSecond: p.WriteRate,
+				BusyPercent:         p.Busy,
+			})
+		}
+		event.Sample = &logstream.LogEvent_Disk{Disk: s}
+
+	case network.Category:
+		var v network.NetworkJSON
+		if json.Unmarshal(line, &v) != nil {
+			return
+		}
+		s := &logstream.NetworkSample{}
+		for _, iface := range v.Interfaces {
+			tcp := make(map[string]uint32, len(iface.TCP))
+			for state, n := range iface.TCP {
+				tcp[state] = uint32(n)
+			}
+			s.Interfaces = append(s.Interfaces, &logstream.NetworkInterface{
+				Name:               iface.Name,
+				Ip:                 iface.IP,
+				Mac:                iface.MAC,
+				TxBytesPerSecond:   iface.Tx,
+				RxBytesPerSecond:   iface.Rx,
+				TxPacketsPerSecond: iface.TxPPS,
+				RxPacketsPerSecond: iface.RxPPS,
+				TcpStates:          tcp,
+			})
+		}
+		event.Sample = &logstream.LogEvent_Network{Network: s}
+	}
+}
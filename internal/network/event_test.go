@@ -0,0 +1,130 @@
+package network
+
+import (
+	"context"
+	"reflect"
+	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/cpu"
+	"sysprobe/internal/monitor/disk"
+	"sysprobe/internal/monitor/memory"
+	"sysprobe/internal/monitor/network"
+	"sysprobe/internal/monitor/probe"
+	"testing"
+)
+
+func TestBuildEvent(t *testing.T) {
+	b := &eventBuilder{agent: "uuid-1", labels: map[string]string{"env": "prod"}}
+	line := []byte(`{"Host":{"Hostname":"web1"},"Category":"CPU","CpuUsage":12.5,"Timestamp":"2026-10-19T08:00:00+08:00"}`)
+
+	ev := b.build(cpu.Category, "CPU_2026-10-19.log", 4096, line)
+	if ev.IdempotencyKey != "uuid-1:CPU_2026-10-19.log:4096" || ev.AgentUuid != "uuid-1" || ev.Category != cpu.Category {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.SampleTimestamp != "2026-10-19T08:00:00+08:00" || ev.SchemaVersion != schemaVersion || string(ev.Payload) != string(line) {
+		t.Errorf("event = %+v", ev)
+	}
+	if want := map[string]string{"env": "prod", "hostname": "web1"}; !reflect.DeepEqual(ev.Labels, want) {
+		t.Errorf("labels = %v, want %v", ev.Labels, want)
+	}
+	// 每筆事件有自己的 labels，不會改到設定
+	if len(b.labels) != 1 {
+		t.Errorf("builder labels modified: %v", b.labels)
+	}
+	if ev.Sample != nil {
+		t.Error("typed sample set without network.typed")
+	}
+
+	// DISK / NETWORK 的時間在第一個項目上
+	ev = b.build(disk.Category, "DISK.log", 0, []byte(`{"Partitions":[{"Name":"sda1","Timestamp":"2026-10-19T08:00:01Z"}]}`))
+	if ev.SampleTimestamp != "2026-10-19T08:00:01Z" {
+		t.Errorf("disk sample timestamp = %q", ev.SampleTimestamp)
+	}
+	ev = b.build(network.Category, "NETWORK.log", 0, []byte(`{"Interfaces":[{"Name":"eth0","Timestamp":"2026-10-19T08:00:02Z"}]}`))
+	if ev.SampleTimestamp != "2026-10-19T08:00:02Z" {
+		t.Errorf("network sample timestamp = %q", ev.SampleTimestamp)
+	}
+
+	// 格式錯誤的行照原樣送出
+	ev = b.build(probe.Category, "PROBE.log", 7, []byte(`{"Timestamp":`))
+	if ev.SampleTimestamp != "" || string(ev.Payload) != `{"Timestamp":` || !reflect.DeepEqual(ev.Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("malformed event = %+v", ev)
+	}
+}
+
+func TestSetTypedSample(t *testing.T) {
+	b := &eventBuilder{agent: "uuid-1", typed: true}
+
+	ev := b.build(cpu.Category, "CPU.log", 0, []byte(`{"CoreCount":4,"CpuModel":"Xeon","CpuMHz":2400,"CpuUsage":12.5,`+
+		`"CoreUsage":[10,15],"LoadAverage":[0.5,0.25,0.1],"CpuTime":{"User":100,"IOWait":3}}`))
+	c := ev.GetCpu()
+	if c == nil || c.CoreCount != 4 || c.Model != "Xeon" || c.UsagePercent != 12.5 || len(c.CoreUsagePercent) != 2 {
+		t.Fatalf("cpu sample = %+v", c)
+	}
+	if !reflect.DeepEqual(c.LoadAverage, []float64{0.5, 0.25, 0.1}) || c.Time.User != 100 || c.Time.Iowait != 3 {
+		t.Errorf("cpu sample = %+v", c)
+	}
+	// Windows 的 LoadAverage 是字串
+	ev = b.build(cpu.Category, "CPU.log", 0, []byte(`{"CoreCount":2,"LoadAverage":"N/A"}`))
+	if c := ev.GetCpu(); c == nil || c.CoreCount != 2 || len(c.LoadAverage) != 0 {
+		t.Errorf("windows cpu sample = %+v", c)
+	}
+
+	ev = b.build(memory.Category, "MEMORY.log", 0, []byte(`{"Total":8000,"Used":6000,"Free":2000,"UsedPct":75}`))
+	if m := ev.GetMemory(); m == nil || m.TotalBytes != 8000 || m.UsedBytes != 6000 || m.FreeBytes != 2000 || m.UsedPercent != 75 {
+		t.Errorf("memory sample = %+v", m)
+	}
+
+	ev = b.build(disk.Category, "DISK.log", 0, []byte(`{"Partitions":[{"Name":"sda1","Mount":"/","Fs":"ext4","Total":100,"Used":40,"Free":60,"Usage":40,"ReadRate":1024,"WriteRate":2048,"Busy":5}]}`))
+	d := ev.GetDisk()
+	if d == nil || len(d.Partitions) != 1 {
+		t.Fatalf("disk sample = %+v", d)
+	}
+	if p := d.Partitions[0]; p.Mount != "/" || p.TotalGib != 100 || p.ReadBytesPerSecond != 1024 || p.WriteBytesPerSecond != 2048 || p.BusyPercent != 5 {
+		t.Errorf("disk partition = %+v", p)
+	}
+
+	ev = b.build(network.Category, "NETWORK.log", 0, []byte(`{"Interfaces":[{"Name":"eth0","IP":"10.0.0.5","MAC":"02:42:ac:11:00:02","Tx":100,"Rx":200,"TxPPS":1.5,"RxPPS":2.5,"TCP":{"ESTABLISHED":12,"TIME_WAIT":3}}]}`))
+	n := ev.GetNetwork()
+	if n == nil || len(n.Interfaces) != 1 {
+		t.Fatalf("network sample = %+v", n)
+	}
+	iface := n.Interfaces[0]
+	if iface.Name != "eth0" || iface.Ip != "10.0.0.5" || iface.TxBytesPerSecond != 100 || iface.RxPacketsPerSecond != 2.5 {
+		t.Errorf("network interface = %+v", iface)
+	}
+	if want := map[string]uint32{"ESTABLISHED": 12, "TIME_WAIT": 3}; !reflect.DeepEqual(iface.TcpStates, want) {
+		t.Errorf("tcp states = %v, want %v", iface.TcpStates, want)
+	}
+
+	// 其他 category 與格式錯誤的行沒有 typed sample
+	for _, tt := range []struct{ category, line string }{
+		{probe.Category, `{"Success":true}`},
+		{memory.Category, `{"Total":"lots"}`},
+	} {
+		if ev := b.build(tt.category, "x.log", 0, []byte(tt.line)); ev.Sample != nil {
+			t.Errorf("%s %s: sample = %v", tt.category, tt.line, ev.Sample)
+		}
+	}
+}
+
+// 設定檔的 category 名稱為 network；舊的拼法 netwrok 仍然接受
+func TestTransferNetworkCategory(t *testing.T) {
+	for _, name := range []string{"network", "netwrok"} {
+		if got := transferCategory(name); got != network.Category {
+			t.Errorf("transferCategory(%q) = %q, want %q", name, got, network.Category)
+		}
+	}
+
+	s, err := newSyslogSink(context.Background(), testNetworkConfig(), config.SinkConfig{
+		Address:    "127.0.0.1:514",
+		Facilities: map[string]string{"network": "daemon"},
+		Severities: map[string]string{"network": "debug"},
+	})
+	if err != nil {
+		t.Fatalf("newSyslogSink: %v", err)
+	}
+	ss := s.(*syslogSink)
+	if ss.facilities[network.Category] != 3 || ss.severities[network.Category] != sevDebug {
+		t.Errorf("facility %d severity %d", ss.facilities[network.Category], ss.severities[network.Category])
+	}
+}
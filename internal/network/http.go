@@ -0,0 +1,244 @@
+package network
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"sysprobe/internal/utils"
+	"time"
+)
+
+const defaultHTTPTimeout = 10 // 秒
+
+// httpSink 以 POST 送出批次（NDJSON 或 JSON 陣列）
+// 內容被拒絕（400 / 413 / 422）的批次寫入 dead letter 後略過，其他錯誤（連線、5xx、429、
+// 以及 401 / 403 / 404 這類設定問題）在原地重試（優先使用 Retry-After），不會丟掉資料
+type httpSink struct {
+	cfg    config.SinkConfig
+	agent  string
+	window int
+	retry  backoffPolicy
+	client *http.Client
+	token  func() (string, error)
+}
+
+func newHTTPSink(cfg config.NetworkConfig, sc config.SinkConfig, agent string) (sink, error) {
+	u, err := url.Parse(sc.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", sc.URL)
+	}
+	switch sc.Format {
+	case "":
+		sc.Format = "ndjson"
+	case "ndjson", "json":
+	default:
+		return nil, fmt.Errorf("unsupported format %q (want ndjson or json)", sc.Format)
+	}
+
+	timeout := sc.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	s := &httpSink{
+		cfg:    sc,
+		agent:  agent,
+		window: sc.Buffer,
+		retry:  newBackoffPolicy(sc.Retry, cfg.Backoff),
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+
+	switch {
+	case sc.TokenEnv != "":
+		token, err := readSecret(sc.TokenEnv, sc.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		s.token = func() (string, error) { return token, nil }
+	case sc.TokenFile != "":
+		t := &fileToken{path: sc.TokenFile}
+		if _, err := t.current(); err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		s.token = t.current
+	}
+	return s, nil
+}
+
+func (s *httpSink) name() string { return s.cfg.Name }
+
+func (s *httpSink) open(context.Context) *ackStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &syncTransport{
+		ctx:   ctx,
+		write: func(events []*logstream.LogEvent) error { return s.post(ctx, events) },
+		acks:  make(chan uint64, 16),
+	}
+	return newAckStream(t, s.cfg.Batch, s.window, cancel)
+}
+
+func (s *httpSink) backoff(n int) time.Duration         { return s.retry.delay(n) }
+func (s *httpSink) failback(*ackStream, time.Time) bool { return false }
+func (s *httpSink) close()                              { s.client.CloseIdleConnections() }
+
+// post 送出一批，直到成功、內容被拒絕或 ctx 結束；回傳錯誤時串流重建並從已確認的 offset 重送
+func (s *httpSink) post(ctx context.Context, events []*logstream.LogEvent) error {
+	body, err := s.encode(events)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		status, retryAfter, msg, err := s.do(ctx, body)
+		switch {
+		case err == nil && status < 300:
+			return nil
+		case err == nil && rejected(status):
+			s.deadLetter(events, status, msg)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wait := s.retry.delay(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		if err == nil {
+			err = fmt.Errorf("status %d", status)
+			if msg != "" {
+				err = fmt.Errorf("status %d: %s", status, msg)
+			}
+		}
+		utils.Log.Warn("[Network] sink %s: post %d events fail: %v, retry in %s", s.cfg.Name, len(events), err, wait.Round(time.Millisecond))
+		if !sleepCtx(ctx, wait) {
+			return ctx.Err()
+		}
+	}
+}
+
+// encode NDJSON 直接串接 payload，JSON 則組成陣列
+func (s *httpSink) encode(events []*logstream.LogEvent) ([]byte, error) {
+	var raw bytes.Buffer
+	if s.cfg.Format == "json" {
+		raw.WriteByte('[')
+	}
+	for i, e := range events {
+		line := bytes.TrimRight(e.Payload, "\r\n")
+		if s.cfg.Format == "json" {
+			if i > 0 {
+				raw.WriteByte(',')
+			}
+			raw.Write(line)
+			continue
+		}
+		raw.Write(line)
+		raw.WriteByte('\n')
+	}
+	if s.cfg.Format == "json" {
+		raw.WriteByte(']')
+	}
+	if !s.cfg.Gzip {
+		return raw.Bytes(), nil
+	}
+
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zipped.Bytes(), nil
+}
+
+// do 送出一次 request，回傳狀態碼、Retry-After 與錯誤訊息（response body 開頭）
+func (s *httpSink) do(ctx context.Context, body []byte) (int, time.Duration, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if s.cfg.Format == "json" {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	if s.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("User-Agent", "SysProbe")
+	req.Header.Set("X-Sysprobe-Agent", s.agent)
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+	if s.token != nil {
+		token, err := s.token()
+		if err != nil {
+			return 0, 0, "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	defer resp.Body.Close()
+
+	var msg string
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		msg = string(bytes.TrimSpace(b))
+	}
+	// 讀完剩下的內容才能重用連線
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")), msg, nil
+}
+
+// rejected collector 拒絕這批內容，重送也不會成功；其他狀態（包含認證失敗、URL 錯誤）修正後即可送出，所以重試
+func rejected(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge || status == http.StatusUnprocessableEntity
+}
+
+// parseRetryAfter 秒數或 HTTP 日期，無法解析時回傳 0
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// deadLetter 被拒絕的批次原樣（JSONL）寫入 dead letter 檔，沒有設定時只記錄
+func (s *httpSink) deadLetter(events []*logstream.LogEvent, status int, msg string) {
+	if s.cfg.DeadLetter == "" {
+		utils.Log.Error("[Network] sink %s: %d events rejected with status %d, dropped: %s", s.cfg.Name, len(events), status, msg)
+		return
+	}
+	utils.Log.Error("[Network] sink %s: %d events rejected with status %d, written to %s: %s", s.cfg.Name, len(events), status, s.cfg.DeadLetter, msg)
+
+	var buf bytes.Buffer
+	for _, e := range events {
+		buf.Write(bytes.TrimRight(e.Payload, "\r\n"))
+		buf.WriteByte('\n')
+	}
+	if err := appendFile(s.cfg.DeadLetter, buf.Bytes()); err != nil {
+		utils.Log.Error("[Network] sink %s: write dead letter fail: %v", s.cfg.Name, err)
+	}
+}
@@ -0,0 +1,229 @@
+package network
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"testing"
+	"time"
+)
+
+func testEvents(lines ...string) []*logstream.LogEvent {
+	events := make([]*logstream.LogEvent, len(lines))
+	for i, l := range lines {
+		events[i] = &logstream.LogEvent{Payload: []byte(l + "\n")}
+	}
+	return events
+}
+
+// statusServer 依序回應 statuses，最後一個之後都回應最後一個狀態
+func statusServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status >= 300 {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, http.StatusText(status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestHTTPSink(t *testing.T, sc config.SinkConfig) *httpSink {
+	t.Helper()
+	sc.Name = "bulk"
+	sc.Retry = config.BackoffConfig{Initial: 1, Max: 1}
+	s, err := newHTTPSink(config.NetworkConfig{}, sc, "agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*httpSink)
+}
+
+func TestHTTPPostRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		header   http.Header
+	}{
+		{"server error", []int{500, 503, 200}, nil},
+		{"too many requests", []int{429, 200}, nil},
+		{"unauthorized", []int{401, 200}, nil},
+		{"forbidden", []int{403, 200}, nil},
+		{"not found", []int{404, 200}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, calls := statusServer(t, tt.header, tt.statuses...)
+			deadLetter := filepath.Join(t.TempDir(), "dead.jsonl")
+			s := newTestHTTPSink(t, config.SinkConfig{URL: srv.URL, DeadLetter: deadLetter})
+
+			if err := s.post(context.Background(), testEvents(`{"a":1}`)); err != nil {
+				t.Fatal(err)
+			}
+			if int(calls.Load()) != len(tt.statuses) {
+				t.Errorf("calls = %d, want %d", calls.Load(), len(tt.statuses))
+			}
+			if _, err := os.Stat(deadLetter); err == nil {
+				t.Error("retried batch was written to the dead letter")
+			}
+		})
+	}
+}
+
+func TestHTTPRetryAfter(t *testing.T) {
+	srv, calls := statusServer(t, http.Header{"Retry-After": {"2"}}, 503, 200)
+	s := newTestHTTPSink(t, config.SinkConfig{URL: srv.URL})
+
+	start := time.Now()
+	if err := s.post(context.Background(), testEvents(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	// 退避設定最多 1 秒，等待 2 秒表示使用了 Retry-After
+	if elapsed := time.Since(start); elapsed < 2*time.Second {
+		t.Errorf("retried after %s, want Retry-After 2s", elapsed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("5"); d != 5*time.Second {
+		t.Errorf("seconds: %s", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d < 58*time.Second || d > time.Minute {
+		t.Errorf("http date: %s", d)
+	}
+	for _, v := range []string{"", "-1", "soon"} {
+		if d := parseRetryAfter(v); d != 0 {
+			t.Errorf("%q: %s, want 0", v, d)
+		}
+	}
+}
+
+func TestHTTPDeadLetter(t *testing.T) {
+	for _, status := range []int{400, 413, 422} {
+		srv, calls := statusServer(t, nil, status)
+		deadLetter := filepath.Join(t.TempDir(), "dead", "bulk.jsonl")
+		s := newTestHTTPSink(t, config.SinkConfig{URL: srv.URL, DeadLetter: deadLetter})
+
+		if err := s.post(context.Background(), testEvents(`{"a":1}`, `{"a":2}`)); err != nil {
+			t.Fatalf("status %d: %v", status, err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("status %d: calls = %d, want 1", status, calls.Load())
+		}
+		data, err := os.ReadFile(deadLetter)
+		if err != nil {
+			t.Fatalf("status %d: %v", status, err)
+		}
+		if string(data) != "{\"a\":1}\n{\"a\":2}\n" {
+			t.Errorf("status %d: dead letter = %q", status, data)
+		}
+	}
+}
+
+func TestHTTPPostCanceled(t *testing.T) {
+	srv, _ := statusServer(t, nil, 503)
+	s := newTestHTTPSink(t, config.SinkConfig{URL: srv.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.post(ctx, testEvents(`{"a":1}`)); err == nil {
+		t.Fatal("post returned nil after the context ended")
+	}
+}
+
+func TestHTTPEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		sc     config.SinkConfig
+		body   string
+		header map[string]string
+	}{
+		{
+			name:   "ndjson gzip bearer",
+			sc:     config.SinkConfig{Format: "ndjson", Gzip: true, TokenEnv: "SYSPROBE_TEST_TOKEN"},
+			body:   "{\"a\":1}\n{\"a\":2}\n",
+			header: map[string]string{"Content-Type": "application/x-ndjson", "Content-Encoding": "gzip", "Authorization": "Bearer secret"},
+		},
+		{
+			name:   "json basic auth",
+			sc:     config.SinkConfig{Format: "json", Username: "u", Password: "p", Headers: map[string]string{"X-Tenant": "ops"}},
+			body:   `[{"a":1},{"a":2}]`,
+			header: map[string]string{"Content-Type": "application/json", "Authorization": "Basic dTpw", "X-Tenant": "ops"},
+		},
+	}
+	t.Setenv("SYSPROBE_TEST_TOKEN", "secret")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			var header http.Header
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				var rd io.Reader = r.Body
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					rd = zr
+				}
+				b, _ := io.ReadAll(rd)
+				body = string(b)
+			}))
+			defer srv.Close()
+
+			tt.sc.URL = srv.URL
+			s := newTestHTTPSink(t, tt.sc)
+			if err := s.post(context.Background(), testEvents(`{"a":1}`, `{"a":2}`)); err != nil {
+				t.Fatal(err)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if tt.sc.Format == "json" && !json.Valid([]byte(body)) {
+				t.Errorf("body is not valid JSON: %s", body)
+			}
+			for k, v := range tt.header {
+				if got := header.Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+			if got := header.Get("X-Sysprobe-Agent"); got != "agent-1" {
+				t.Errorf("X-Sysprobe-Agent = %q", got)
+			}
+		})
+	}
+}
+
+func TestHTTPSinkConfig(t *testing.T) {
+	for _, sc := range []config.SinkConfig{
+		{URL: "ftp://example.com"},
+		{URL: "http://"},
+		{URL: "http://example.com", Format: "xml"},
+		{URL: "http://example.com", TokenFile: "/nonexistent/token"},
+	} {
+		if _, err := newHTTPSink(config.NetworkConfig{}, sc, "agent-1"); err == nil {
+			t.Errorf("%+v: expected error", sc)
+		}
+	}
+}
@@ -22,11 +22,28 @@ const (
 )
 
 type LogEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
-	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
-	Payload       []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Seq       uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Source    string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Payload   []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// 同一筆資料重送時不變（agent UUID + 檔案 + offset），接收端用來去除重複
+	IdempotencyKey string `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// 以下欄位讓接收端不需解析 payload 就能分類；payload 仍保留原始 JSON
+	AgentUuid       string            `protobuf:"bytes,6,opt,name=agent_uuid,json=agentUuid,proto3" json:"agent_uuid,omitempty"`
+	Category        string            `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`                                                                        // 例如 CPU、DISK
+	SampleTimestamp string            `protobuf:"bytes,8,opt,name=sample_timestamp,json=sampleTimestamp,proto3" json:"sample_timestamp,omitempty"`                                   // 收集器產生樣本的時間（RFC3339），timestamp 為送出時間
+	SchemaVersion   uint32            `protobuf:"varint,9,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`                                        // payload 與欄位的格式版本
+	Labels          map[string]string `protobuf:"bytes,10,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // hostname 與 network.labels
+	// network.typed 開啟時附上，只有基本收集器
+	//
+	// Types that are valid to be assigned to Sample:
+	//
+	//	*LogEvent_Cpu
+	//	*LogEvent_Memory
+	//	*LogEvent_Disk
+	//	*LogEvent_Network
+	Sample        isLogEvent_Sample `protobuf_oneof:"sample"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,6 +106,720 @@ func (x *LogEvent) GetPayload() []byte {
 	return nil
 }
 
+func (x *LogEvent) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *LogEvent) GetAgentUuid() string {
+	if x != nil {
+		return x.AgentUuid
+	}
+	return ""
+}
+
+func (x *LogEvent) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *LogEvent) GetSampleTimestamp() string {
+	if x != nil {
+		return x.SampleTimestamp
+	}
+	return ""
+}
+
+func (x *LogEvent) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *LogEvent) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *LogEvent) GetSample() isLogEvent_Sample {
+	if x != nil {
+		return x.Sample
+	}
+	return nil
+}
+
+func (x *LogEvent) GetCpu() *CPUSample {
+	if x != nil {
+		if x, ok := x.Sample.(*LogEvent_Cpu); ok {
+			return x.Cpu
+		}
+	}
+	return nil
+}
+
+func (x *LogEvent) GetMemory() *MemorySample {
+	if x != nil {
+		if x, ok := x.Sample.(*LogEvent_Memory); ok {
+			return x.Memory
+		}
+	}
+	return nil
+}
+
+func (x *LogEvent) GetDisk() *DiskSample {
+	if x != nil {
+		if x, ok := x.Sample.(*LogEvent_Disk); ok {
+			return x.Disk
+		}
+	}
+	return nil
+}
+
+func (x *LogEvent) GetNetwork() *NetworkSample {
+	if x != nil {
+		if x, ok := x.Sample.(*LogEvent_Network); ok {
+			return x.Network
+		}
+	}
+	return nil
+}
+
+type isLogEvent_Sample interface {
+	isLogEvent_Sample()
+}
+
+type LogEvent_Cpu struct {
+	Cpu *CPUSample `protobuf:"bytes,11,opt,name=cpu,proto3,oneof"`
+}
+
+type LogEvent_Memory struct {
+	Memory *MemorySample `protobuf:"bytes,12,opt,name=memory,proto3,oneof"`
+}
+
+type LogEvent_Disk struct {
+	Disk *DiskSample `protobuf:"bytes,13,opt,name=disk,proto3,oneof"`
+}
+
+type LogEvent_Network struct {
+	Network *NetworkSample `protobuf:"bytes,14,opt,name=network,proto3,oneof"`
+}
+
+func (*LogEvent_Cpu) isLogEvent_Sample() {}
+
+func (*LogEvent_Memory) isLogEvent_Sample() {}
+
+func (*LogEvent_Disk) isLogEvent_Sample() {}
+
+func (*LogEvent_Network) isLogEvent_Sample() {}
+
+type CPUSample struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	CoreCount        uint32                 `protobuf:"varint,1,opt,name=core_count,json=coreCount,proto3" json:"core_count,omitempty"`
+	Model            string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	Mhz              float64                `protobuf:"fixed64,3,opt,name=mhz,proto3" json:"mhz,omitempty"`
+	UsagePercent     float64                `protobuf:"fixed64,4,opt,name=usage_percent,json=usagePercent,proto3" json:"usage_percent,omitempty"`
+	CoreUsagePercent []float64              `protobuf:"fixed64,5,rep,packed,name=core_usage_percent,json=coreUsagePercent,proto3" json:"core_usage_percent,omitempty"`
+	LoadAverage      []float64              `protobuf:"fixed64,6,rep,packed,name=load_average,json=loadAverage,proto3" json:"load_average,omitempty"` // load1 / load5 / load15，Windows 沒有
+	Time             *CPUTime               `protobuf:"bytes,7,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CPUSample) Reset() {
+	*x = CPUSample{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CPUSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CPUSample) ProtoMessage() {}
+
+func (x *CPUSample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CPUSample.ProtoReflect.Descriptor instead.
+func (*CPUSample) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CPUSample) GetCoreCount() uint32 {
+	if x != nil {
+		return x.CoreCount
+	}
+	return 0
+}
+
+func (x *CPUSample) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CPUSample) GetMhz() float64 {
+	if x != nil {
+		return x.Mhz
+	}
+	return 0
+}
+
+func (x *CPUSample) GetUsagePercent() float64 {
+	if x != nil {
+		return x.UsagePercent
+	}
+	return 0
+}
+
+func (x *CPUSample) GetCoreUsagePercent() []float64 {
+	if x != nil {
+		return x.CoreUsagePercent
+	}
+	return nil
+}
+
+func (x *CPUSample) GetLoadAverage() []float64 {
+	if x != nil {
+		return x.LoadAverage
+	}
+	return nil
+}
+
+func (x *CPUSample) GetTime() *CPUTime {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+// 累計秒數
+type CPUTime struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          float64                `protobuf:"fixed64,1,opt,name=user,proto3" json:"user,omitempty"`
+	System        float64                `protobuf:"fixed64,2,opt,name=system,proto3" json:"system,omitempty"`
+	Idle          float64                `protobuf:"fixed64,3,opt,name=idle,proto3" json:"idle,omitempty"`
+	Nice          float64                `protobuf:"fixed64,4,opt,name=nice,proto3" json:"nice,omitempty"`
+	Iowait        float64                `protobuf:"fixed64,5,opt,name=iowait,proto3" json:"iowait,omitempty"`
+	Irq           float64                `protobuf:"fixed64,6,opt,name=irq,proto3" json:"irq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CPUTime) Reset() {
+	*x = CPUTime{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CPUTime) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CPUTime) ProtoMessage() {}
+
+func (x *CPUTime) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CPUTime.ProtoReflect.Descriptor instead.
+func (*CPUTime) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CPUTime) GetUser() float64 {
+	if x != nil {
+		return x.User
+	}
+	return 0
+}
+
+func (x *CPUTime) GetSystem() float64 {
+	if x != nil {
+		return x.System
+	}
+	return 0
+}
+
+func (x *CPUTime) GetIdle() float64 {
+	if x != nil {
+		return x.Idle
+	}
+	return 0
+}
+
+func (x *CPUTime) GetNice() float64 {
+	if x != nil {
+		return x.Nice
+	}
+	return 0
+}
+
+func (x *CPUTime) GetIowait() float64 {
+	if x != nil {
+		return x.Iowait
+	}
+	return 0
+}
+
+func (x *CPUTime) GetIrq() float64 {
+	if x != nil {
+		return x.Irq
+	}
+	return 0
+}
+
+type MemorySample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalBytes    uint64                 `protobuf:"varint,1,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	UsedBytes     uint64                 `protobuf:"varint,2,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	FreeBytes     uint64                 `protobuf:"varint,3,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	UsedPercent   float64                `protobuf:"fixed64,4,opt,name=used_percent,json=usedPercent,proto3" json:"used_percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemorySample) Reset() {
+	*x = MemorySample{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemorySample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemorySample) ProtoMessage() {}
+
+func (x *MemorySample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemorySample.ProtoReflect.Descriptor instead.
+func (*MemorySample) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MemorySample) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *MemorySample) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+func (x *MemorySample) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *MemorySample) GetUsedPercent() float64 {
+	if x != nil {
+		return x.UsedPercent
+	}
+	return 0
+}
+
+type DiskSample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Partitions    []*DiskPartition       `protobuf:"bytes,1,rep,name=partitions,proto3" json:"partitions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiskSample) Reset() {
+	*x = DiskSample{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskSample) ProtoMessage() {}
+
+func (x *DiskSample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskSample.ProtoReflect.Descriptor instead.
+func (*DiskSample) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DiskSample) GetPartitions() []*DiskPartition {
+	if x != nil {
+		return x.Partitions
+	}
+	return nil
+}
+
+type DiskPartition struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Mount               string                 `protobuf:"bytes,2,opt,name=mount,proto3" json:"mount,omitempty"`
+	Fs                  string                 `protobuf:"bytes,3,opt,name=fs,proto3" json:"fs,omitempty"`
+	TotalGib            uint64                 `protobuf:"varint,4,opt,name=total_gib,json=totalGib,proto3" json:"total_gib,omitempty"`
+	UsedGib             uint64                 `protobuf:"varint,5,opt,name=used_gib,json=usedGib,proto3" json:"used_gib,omitempty"`
+	FreeGib             uint64                 `protobuf:"varint,6,opt,name=free_gib,json=freeGib,proto3" json:"free_gib,omitempty"`
+	UsagePercent        float64                `protobuf:"fixed64,7,opt,name=usage_percent,json=usagePercent,proto3" json:"usage_percent,omitempty"`
+	ReadBytesPerSecond  uint64                 `protobuf:"varint,8,opt,name=read_bytes_per_second,json=readBytesPerSecond,proto3" json:"read_bytes_per_second,omitempty"`
+	WriteBytesPerSecond uint64                 `protobuf:"varint,9,opt,name=write_bytes_per_second,json=writeBytesPerSecond,proto3" json:"write_bytes_per_second,omitempty"`
+	BusyPercent         float64                `protobuf:"fixed64,10,opt,name=busy_percent,json=busyPercent,proto3" json:"busy_percent,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DiskPartition) Reset() {
+	*x = DiskPartition{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiskPartition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiskPartition) ProtoMessage() {}
+
+func (x *DiskPartition) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiskPartition.ProtoReflect.Descriptor instead.
+func (*DiskPartition) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DiskPartition) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DiskPartition) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+func (x *DiskPartition) GetFs() string {
+	if x != nil {
+		return x.Fs
+	}
+	return ""
+}
+
+func (x *DiskPartition) GetTotalGib() uint64 {
+	if x != nil {
+		return x.TotalGib
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetUsedGib() uint64 {
+	if x != nil {
+		return x.UsedGib
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetFreeGib() uint64 {
+	if x != nil {
+		return x.FreeGib
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetUsagePercent() float64 {
+	if x != nil {
+		return x.UsagePercent
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetReadBytesPerSecond() uint64 {
+	if x != nil {
+		return x.ReadBytesPerSecond
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetWriteBytesPerSecond() uint64 {
+	if x != nil {
+		return x.WriteBytesPerSecond
+	}
+	return 0
+}
+
+func (x *DiskPartition) GetBusyPercent() float64 {
+	if x != nil {
+		return x.BusyPercent
+	}
+	return 0
+}
+
+type NetworkSample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interfaces    []*NetworkInterface    `protobuf:"bytes,1,rep,name=interfaces,proto3" json:"interfaces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkSample) Reset() {
+	*x = NetworkSample{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkSample) ProtoMessage() {}
+
+func (x *NetworkSample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkSample.ProtoReflect.Descriptor instead.
+func (*NetworkSample) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *NetworkSample) GetInterfaces() []*NetworkInterface {
+	if x != nil {
+		return x.Interfaces
+	}
+	return nil
+}
+
+type NetworkInterface struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ip                 string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Mac                string                 `protobuf:"bytes,3,opt,name=mac,proto3" json:"mac,omitempty"`
+	TxBytesPerSecond   uint64                 `protobuf:"varint,4,opt,name=tx_bytes_per_second,json=txBytesPerSecond,proto3" json:"tx_bytes_per_second,omitempty"`
+	RxBytesPerSecond   uint64                 `protobuf:"varint,5,opt,name=rx_bytes_per_second,json=rxBytesPerSecond,proto3" json:"rx_bytes_per_second,omitempty"`
+	TxPacketsPerSecond float64                `protobuf:"fixed64,6,opt,name=tx_packets_per_second,json=txPacketsPerSecond,proto3" json:"tx_packets_per_second,omitempty"`
+	RxPacketsPerSecond float64                `protobuf:"fixed64,7,opt,name=rx_packets_per_second,json=rxPacketsPerSecond,proto3" json:"rx_packets_per_second,omitempty"`
+	TcpStates          map[string]uint32      `protobuf:"bytes,8,rep,name=tcp_states,json=tcpStates,proto3" json:"tcp_states,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *NetworkInterface) Reset() {
+	*x = NetworkInterface{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkInterface) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkInterface) ProtoMessage() {}
+
+func (x *NetworkInterface) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkInterface.ProtoReflect.Descriptor instead.
+func (*NetworkInterface) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *NetworkInterface) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NetworkInterface) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *NetworkInterface) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *NetworkInterface) GetTxBytesPerSecond() uint64 {
+	if x != nil {
+		return x.TxBytesPerSecond
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetRxBytesPerSecond() uint64 {
+	if x != nil {
+		return x.RxBytesPerSecond
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetTxPacketsPerSecond() float64 {
+	if x != nil {
+		return x.TxPacketsPerSecond
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetRxPacketsPerSecond() float64 {
+	if x != nil {
+		return x.RxPacketsPerSecond
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetTcpStates() map[string]uint32 {
+	if x != nil {
+		return x.TcpStates
+	}
+	return nil
+}
+
+type LogBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Events        []*LogEvent            `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatch) Reset() {
+	*x = LogBatch{}
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatch) ProtoMessage() {}
+
+func (x *LogBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatch.ProtoReflect.Descriptor instead.
+func (*LogBatch) Descriptor() ([]byte, []int) {
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogBatch) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *LogBatch) GetEvents() []*LogEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
 type Ack struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
@@ -98,7 +829,7 @@ type Ack struct {
 
 func (x *Ack) Reset() {
 	*x = Ack{}
-	mi := &file_internal_network_logstream_logstream_proto_msgTypes[1]
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -110,7 +841,7 @@ func (x *Ack) String() string {
 func (*Ack) ProtoMessage() {}
 
 func (x *Ack) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_network_logstream_logstream_proto_msgTypes[1]
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -123,7 +854,7 @@ func (x *Ack) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Ack.ProtoReflect.Descriptor instead.
 func (*Ack) Descriptor() ([]byte, []int) {
-	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{1}
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Ack) GetSeq() uint64 {
@@ -141,7 +872,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_internal_network_logstream_logstream_proto_msgTypes[2]
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -153,7 +884,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_network_logstream_logstream_proto_msgTypes[2]
+	mi := &file_internal_network_logstream_logstream_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -166,25 +897,104 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{2}
+	return file_internal_network_logstream_logstream_proto_rawDescGZIP(), []int{10}
 }
 
 var File_internal_network_logstream_logstream_proto protoreflect.FileDescriptor
 
 const file_internal_network_logstream_logstream_proto_rawDesc = "" +
 	"\n" +
-	"*internal/network/logstream/logstream.proto\x12\tlogstream\"l\n" +
+	"*internal/network/logstream/logstream.proto\x12\tlogstream\"\xe0\x04\n" +
 	"\bLogEvent\x12\x10\n" +
 	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12\x16\n" +
 	"\x06source\x18\x03 \x01(\tR\x06source\x12\x18\n" +
-	"\apayload\x18\x04 \x01(\fR\apayload\"\x17\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12'\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tR\x0eidempotencyKey\x12\x1d\n" +
+	"\n" +
+	"agent_uuid\x18\x06 \x01(\tR\tagentUuid\x12\x1a\n" +
+	"\bcategory\x18\a \x01(\tR\bcategory\x12)\n" +
+	"\x10sample_timestamp\x18\b \x01(\tR\x0fsampleTimestamp\x12%\n" +
+	"\x0eschema_version\x18\t \x01(\rR\rschemaVersion\x127\n" +
+	"\x06labels\x18\n" +
+	" \x03(\v2\x1f.logstream.LogEvent.LabelsEntryR\x06labels\x12(\n" +
+	"\x03cpu\x18\v \x01(\v2\x14.logstream.CPUSampleH\x00R\x03cpu\x121\n" +
+	"\x06memory\x18\f \x01(\v2\x17.logstream.MemorySampleH\x00R\x06memory\x12+\n" +
+	"\x04disk\x18\r \x01(\v2\x15.logstream.DiskSampleH\x00R\x04disk\x124\n" +
+	"\anetwork\x18\x0e \x01(\v2\x18.logstream.NetworkSampleH\x00R\anetwork\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
+	"\x06sample\"\xf0\x01\n" +
+	"\tCPUSample\x12\x1d\n" +
+	"\n" +
+	"core_count\x18\x01 \x01(\rR\tcoreCount\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x10\n" +
+	"\x03mhz\x18\x03 \x01(\x01R\x03mhz\x12#\n" +
+	"\rusage_percent\x18\x04 \x01(\x01R\fusagePercent\x12,\n" +
+	"\x12core_usage_percent\x18\x05 \x03(\x01R\x10coreUsagePercent\x12!\n" +
+	"\fload_average\x18\x06 \x03(\x01R\vloadAverage\x12&\n" +
+	"\x04time\x18\a \x01(\v2\x12.logstream.CPUTimeR\x04time\"\x87\x01\n" +
+	"\aCPUTime\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\x01R\x04user\x12\x16\n" +
+	"\x06system\x18\x02 \x01(\x01R\x06system\x12\x12\n" +
+	"\x04idle\x18\x03 \x01(\x01R\x04idle\x12\x12\n" +
+	"\x04nice\x18\x04 \x01(\x01R\x04nice\x12\x16\n" +
+	"\x06iowait\x18\x05 \x01(\x01R\x06iowait\x12\x10\n" +
+	"\x03irq\x18\x06 \x01(\x01R\x03irq\"\x90\x01\n" +
+	"\fMemorySample\x12\x1f\n" +
+	"\vtotal_bytes\x18\x01 \x01(\x04R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x02 \x01(\x04R\tusedBytes\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x03 \x01(\x04R\tfreeBytes\x12!\n" +
+	"\fused_percent\x18\x04 \x01(\x01R\vusedPercent\"F\n" +
+	"\n" +
+	"DiskSample\x128\n" +
+	"\n" +
+	"partitions\x18\x01 \x03(\v2\x18.logstream.DiskPartitionR\n" +
+	"partitions\"\xcc\x02\n" +
+	"\rDiskPartition\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05mount\x18\x02 \x01(\tR\x05mount\x12\x0e\n" +
+	"\x02fs\x18\x03 \x01(\tR\x02fs\x12\x1b\n" +
+	"\ttotal_gib\x18\x04 \x01(\x04R\btotalGib\x12\x19\n" +
+	"\bused_gib\x18\x05 \x01(\x04R\ausedGib\x12\x19\n" +
+	"\bfree_gib\x18\x06 \x01(\x04R\afreeGib\x12#\n" +
+	"\rusage_percent\x18\a \x01(\x01R\fusagePercent\x121\n" +
+	"\x15read_bytes_per_second\x18\b \x01(\x04R\x12readBytesPerSecond\x123\n" +
+	"\x16write_bytes_per_second\x18\t \x01(\x04R\x13writeBytesPerSecond\x12!\n" +
+	"\fbusy_percent\x18\n" +
+	" \x01(\x01R\vbusyPercent\"L\n" +
+	"\rNetworkSample\x12;\n" +
+	"\n" +
+	"interfaces\x18\x01 \x03(\v2\x1b.logstream.NetworkInterfaceR\n" +
+	"interfaces\"\x95\x03\n" +
+	"\x10NetworkInterface\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x10\n" +
+	"\x03mac\x18\x03 \x01(\tR\x03mac\x12-\n" +
+	"\x13tx_bytes_per_second\x18\x04 \x01(\x04R\x10txBytesPerSecond\x12-\n" +
+	"\x13rx_bytes_per_second\x18\x05 \x01(\x04R\x10rxBytesPerSecond\x121\n" +
+	"\x15tx_packets_per_second\x18\x06 \x01(\x01R\x12txPacketsPerSecond\x121\n" +
+	"\x15rx_packets_per_second\x18\a \x01(\x01R\x12rxPacketsPerSecond\x12I\n" +
+	"\n" +
+	"tcp_states\x18\b \x03(\v2*.logstream.NetworkInterface.TcpStatesEntryR\ttcpStates\x1a<\n" +
+	"\x0eTcpStatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"I\n" +
+	"\bLogBatch\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12+\n" +
+	"\x06events\x18\x02 \x03(\v2\x13.logstream.LogEventR\x06events\"\x17\n" +
 	"\x03Ack\x12\x10\n" +
 	"\x03seq\x18\x01 \x01(\x04R\x03seq\"\a\n" +
-	"\x05Empty2D\n" +
+	"\x05Empty2\xb8\x01\n" +
 	"\vLogStreamer\x125\n" +
 	"\n" +
-	"StreamLogs\x12\x13.logstream.LogEvent\x1a\x10.logstream.Empty(\x01B\x0fZ\r./proto;protob\x06proto3"
+	"StreamLogs\x12\x13.logstream.LogEvent\x1a\x10.logstream.Empty(\x01\x128\n" +
+	"\rStreamLogsAck\x12\x13.logstream.LogEvent\x1a\x0e.logstream.Ack(\x010\x01\x128\n" +
+	"\rStreamBatches\x12\x13.logstream.LogBatch\x1a\x0e.logstream.Ack(\x010\x01B\x0fZ\r./proto;protob\x06proto3"
 
 var (
 	file_internal_network_logstream_logstream_proto_rawDescOnce sync.Once
@@ -198,20 +1008,44 @@ func file_internal_network_logstream_logstream_proto_rawDescGZIP() []byte {
 	return file_internal_network_logstream_logstream_proto_rawDescData
 }
 
-var file_internal_network_logstream_logstream_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_internal_network_logstream_logstream_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_internal_network_logstream_logstream_proto_goTypes = []any{
-	(*LogEvent)(nil), // 0: logstream.LogEvent
-	(*Ack)(nil),      // 1: logstream.Ack
-	(*Empty)(nil),    // 2: logstream.Empty
+	(*LogEvent)(nil),         // 0: logstream.LogEvent
+	(*CPUSample)(nil),        // 1: logstream.CPUSample
+	(*CPUTime)(nil),          // 2: logstream.CPUTime
+	(*MemorySample)(nil),     // 3: logstream.MemorySample
+	(*DiskSample)(nil),       // 4: logstream.DiskSample
+	(*DiskPartition)(nil),    // 5: logstream.DiskPartition
+	(*NetworkSample)(nil),    // 6: logstream.NetworkSample
+	(*NetworkInterface)(nil), // 7: logstream.NetworkInterface
+	(*LogBatch)(nil),         // 8: logstream.LogBatch
+	(*Ack)(nil),              // 9: logstream.Ack
+	(*Empty)(nil),            // 10: logstream.Empty
+	nil,                      // 11: logstream.LogEvent.LabelsEntry
+	nil,                      // 12: logstream.NetworkInterface.TcpStatesEntry
 }
 var file_internal_network_logstream_logstream_proto_depIdxs = []int32{
-	0, // 0: logstream.LogStreamer.StreamLogs:input_type -> logstream.LogEvent
-	2, // 1: logstream.LogStreamer.StreamLogs:output_type -> logstream.Empty
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	11, // 0: logstream.LogEvent.labels:type_name -> logstream.LogEvent.LabelsEntry
+	1,  // 1: logstream.LogEvent.cpu:type_name -> logstream.CPUSample
+	3,  // 2: logstream.LogEvent.memory:type_name -> logstream.MemorySample
+	4,  // 3: logstream.LogEvent.disk:type_name -> logstream.DiskSample
+	6,  // 4: logstream.LogEvent.network:type_name -> logstream.NetworkSample
+	2,  // 5: logstream.CPUSample.time:type_name -> logstream.CPUTime
+	5,  // 6: logstream.DiskSample.partitions:type_name -> logstream.DiskPartition
+	7,  // 7: logstream.NetworkSample.interfaces:type_name -> logstream.NetworkInterface
+	12, // 8: logstream.NetworkInterface.tcp_states:type_name -> logstream.NetworkInterface.TcpStatesEntry
+	0,  // 9: logstream.LogBatch.events:type_name -> logstream.LogEvent
+	0,  // 10: logstream.LogStreamer.StreamLogs:input_type -> logstream.LogEvent
+	0,  // 11: logstream.LogStreamer.StreamLogsAck:input_type -> logstream.LogEvent
+	8,  // 12: logstream.LogStreamer.StreamBatches:input_type -> logstream.LogBatch
+	10, // 13: logstream.LogStreamer.StreamLogs:output_type -> logstream.Empty
+	9,  // 14: logstream.LogStreamer.StreamLogsAck:output_type -> logstream.Ack
+	9,  // 15: logstream.LogStreamer.StreamBatches:output_type -> logstream.Ack
+	13, // [13:16] is the sub-list for method output_type
+	10, // [10:13] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_internal_network_logstream_logstream_proto_init() }
@@ -219,13 +1053,19 @@ func file_internal_network_logstream_logstream_proto_init() {
 	if File_internal_network_logstream_logstream_proto != nil {
 		return
 	}
+	file_internal_network_logstream_logstream_proto_msgTypes[0].OneofWrappers = []any{
+		(*LogEvent_Cpu)(nil),
+		(*LogEvent_Memory)(nil),
+		(*LogEvent_Disk)(nil),
+		(*LogEvent_Network)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_network_logstream_logstream_proto_rawDesc), len(file_internal_network_logstream_logstream_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LogStreamer_StreamLogs_FullMethodName = "/logstream.LogStreamer/StreamLogs"
+	LogStreamer_StreamLogs_FullMethodName    = "/logstream.LogStreamer/StreamLogs"
+	LogStreamer_StreamLogsAck_FullMethodName = "/logstream.LogStreamer/StreamLogsAck"
+	LogStreamer_StreamBatches_FullMethodName = "/logstream.LogStreamer/StreamBatches"
 )
 
 // LogStreamerClient is the client API for LogStreamer service.
@@ -27,6 +29,10 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LogStreamerClient interface {
 	StreamLogs(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[LogEvent, Empty], error)
+	// 伺服器收到並保存事件後回傳 Ack，Ack.seq 表示該序號（含）之前的事件都已確認
+	StreamLogsAck(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogEvent, Ack], error)
+	// 批次傳送，Ack.seq 對應 LogBatch.seq
+	StreamBatches(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogBatch, Ack], error)
 }
 
 type logStreamerClient struct {
@@ -50,11 +56,41 @@ func (c *logStreamerClient) StreamLogs(ctx context.Context, opts ...grpc.CallOpt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type LogStreamer_StreamLogsClient = grpc.ClientStreamingClient[LogEvent, Empty]
 
+func (c *logStreamerClient) StreamLogsAck(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogEvent, Ack], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LogStreamer_ServiceDesc.Streams[1], LogStreamer_StreamLogsAck_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogEvent, Ack]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogStreamer_StreamLogsAckClient = grpc.BidiStreamingClient[LogEvent, Ack]
+
+func (c *logStreamerClient) StreamBatches(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogBatch, Ack], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LogStreamer_ServiceDesc.Streams[2], LogStreamer_StreamBatches_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogBatch, Ack]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogStreamer_StreamBatchesClient = grpc.BidiStreamingClient[LogBatch, Ack]
+
 // LogStreamerServer is the server API for LogStreamer service.
 // All implementations must embed UnimplementedLogStreamerServer
 // for forward compatibility.
 type LogStreamerServer interface {
 	StreamLogs(grpc.ClientStreamingServer[LogEvent, Empty]) error
+	// 伺服器收到並保存事件後回傳 Ack，Ack.seq 表示該序號（含）之前的事件都已確認
+	StreamLogsAck(grpc.BidiStreamingServer[LogEvent, Ack]) error
+	// 批次傳送，Ack.seq 對應 LogBatch.seq
+	StreamBatches(grpc.BidiStreamingServer[LogBatch, Ack]) error
 	mustEmbedUnimplementedLogStreamerServer()
 }
 
@@ -68,6 +104,12 @@ type UnimplementedLogStreamerServer struct{}
 func (UnimplementedLogStreamerServer) StreamLogs(grpc.ClientStreamingServer[LogEvent, Empty]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamLogs not implemented")
 }
+func (UnimplementedLogStreamerServer) StreamLogsAck(grpc.BidiStreamingServer[LogEvent, Ack]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLogsAck not implemented")
+}
+func (UnimplementedLogStreamerServer) StreamBatches(grpc.BidiStreamingServer[LogBatch, Ack]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBatches not implemented")
+}
 func (UnimplementedLogStreamerServer) mustEmbedUnimplementedLogStreamerServer() {}
 func (UnimplementedLogStreamerServer) testEmbeddedByValue()                     {}
 
@@ -96,6 +138,20 @@ func _LogStreamer_StreamLogs_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type LogStreamer_StreamLogsServer = grpc.ClientStreamingServer[LogEvent, Empty]
 
+func _LogStreamer_StreamLogsAck_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogStreamerServer).StreamLogsAck(&grpc.GenericServerStream[LogEvent, Ack]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogStreamer_StreamLogsAckServer = grpc.BidiStreamingServer[LogEvent, Ack]
+
+func _LogStreamer_StreamBatches_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogStreamerServer).StreamBatches(&grpc.GenericServerStream[LogBatch, Ack]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogStreamer_StreamBatchesServer = grpc.BidiStreamingServer[LogBatch, Ack]
+
 // LogStreamer_ServiceDesc is the grpc.ServiceDesc for LogStreamer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -109,6 +165,18 @@ var LogStreamer_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _LogStreamer_StreamLogs_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamLogsAck",
+			Handler:       _LogStreamer_StreamLogsAck_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamBatches",
+			Handler:       _LogStreamer_StreamBatches_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "internal/network/logstream/logstream.proto",
 }
@@ -5,21 +5,34 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/applog"
+	"sysprobe/internal/monitor/auth"
+	"sysprobe/internal/monitor/certs"
+	"sysprobe/internal/monitor/cgroup"
+	"sysprobe/internal/monitor/container"
 	"sysprobe/internal/monitor/cpu"
 	"sysprobe/internal/monitor/disk"
+	"sysprobe/internal/monitor/exec"
+	"sysprobe/internal/monitor/integrity"
+	"sysprobe/internal/monitor/kmsg"
 	"sysprobe/internal/monitor/memory"
 	"sysprobe/internal/monitor/network"
-	logstream "sysprobe/internal/network/logstream"
+	"sysprobe/internal/monitor/packages"
+	"sysprobe/internal/monitor/probe"
+	"sysprobe/internal/monitor/scrape"
+	"sysprobe/internal/monitor/session"
+	"sysprobe/internal/monitor/statsd"
+	"sysprobe/internal/monitor/systemd"
 	"sysprobe/internal/utils"
 	"time"
-
-	"google.golang.org/grpc"
 )
 
 type OffsetState struct {
@@ -27,44 +40,113 @@ type OffsetState struct {
 	Timestamp string `json:"timestamp"`
 }
 
-const connectedRetryTime = 30
-
-func LoadNetwork(ctx context.Context, cfg config.Config) {
+// LoadNetwork agent 為 main 建立的 UUID，用於 idempotency key 與 hash 策略
+func LoadNetwork(ctx context.Context, cfg config.Config, agent string) {
 	utils.Log.Info("Network Manager starting...")
-	for _, c := range cfg.Network.Category {
-		prefix := transferCategory(c)
-		if prefix == "" {
-			continue
+	// 沒有 UUID 時各主機的 idempotency key 會重複，collector 可能把事件當成重送而丟棄
+	if agent == "" {
+		utils.Log.Error("[Network] agent UUID unavailable, log shipping disabled")
+		return
+	}
+	builder := &eventBuilder{agent: agent, labels: cfg.Network.Labels, typed: cfg.Network.Typed}
+
+	routes := newSinks(ctx, cfg.Network, agent)
+	if len(routes) == 0 {
+		utils.Log.Error("[Network] no usable sink, log shipping disabled")
+		return
+	}
+
+	// 每個 sink 的每個 category 各自讀檔，某個 sink 停擺不影響其他 sink
+	var wg sync.WaitGroup
+	for _, r := range routes {
+		for _, c := range r.categories {
+			prefix := transferCategory(c)
+			if prefix == "" {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				shipCategory(ctx, cfg, prefix, r.sink, builder)
+			}()
+		}
+	}
+
+	// 所有串流送完剩下的批次後才關閉連線
+	go func() {
+		wg.Wait()
+		for _, r := range routes {
+			r.sink.close()
 		}
-		go func() {
-			dir := cfg.Monitor.Data
-			ignoreOlder := cfg.Network.IgnoreOlder
-			stream := connectStream(cfg.Network)
-
-			for {
-				select {
-				case <-ctx.Done():
-					utils.Log.Info("[Network] STOP: context canceled.")
-					return
-
-				default:
-					// 依照「檔名上的日期」挑最新三個檔
-					logFiles := latestNByFilename(prefix, dir+"/"+prefix, ignoreOlder)
-					for _, file := range logFiles {
-						err := tailOneFile(ctx, file, cfg.Network, stream)
-						if err != nil {
-							utils.Log.Error("[Network] tail error:%v", err)
-						}
+		utils.Log.Info("[Network] STOP: context canceled.")
+	}()
+	utils.Log.Info("Network started")
+}
+
+// shipCategory 持續把一個 category 的檔案送到 sink 上的串流
+func shipCategory(ctx context.Context, cfg config.Config, prefix string, out sink, builder *eventBuilder) {
+	dir := cfg.Monitor.Data
+	ignoreOlder := cfg.Network.IgnoreOlder
+
+	var stream *ackStream
+	var opened time.Time
+	failures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			if stream != nil {
+				stream.close(3 * time.Second)
+			}
+			return
+
+		default:
+			// 串流中斷：未確認的事件會從已確認的 offset 重送
+			if stream == nil || stream.broken() {
+				if stream != nil {
+					stream.close(0)
+					// 剛建立就中斷（例如 server 拒絕）時逐次拉長等待
+					if time.Since(opened) < stableStream {
+						failures++
+					} else {
+						failures = 0
+					}
+					wait := out.backoff(failures)
+					utils.Log.Warn("[Network] sink %s %s: %v, retry in %s", out.name(), prefix, stream.failure(), wait.Round(time.Millisecond))
+					if !sleepCtx(ctx, wait) {
+						stream = nil
+						continue
 					}
-					// 每 30 秒重新取一次最新 3 檔
-					time.Sleep(30 * time.Second)
 				}
+				stream = out.open(ctx)
+				opened = time.Now()
+				continue
 			}
-		}()
+			// 依照「檔名上的日期」挑最新三個檔
+			logFiles := latestNByFilename(prefix, dir+"/"+prefix, ignoreOlder)
+			for _, file := range logFiles {
+				err := tailOneFile(ctx, file, prefix, out, builder, stream)
+				if err != nil {
+					utils.Log.Error("[Network] tail error:%v", err)
+					break
+				}
+			}
+			if stream.broken() {
+				continue
+			}
+			// 每 30 秒重新取一次最新 3 檔
+			select {
+			case <-time.After(30 * time.Second):
+			case <-stream.done:
+			case <-ctx.Done():
+			}
+			if out.failback(stream, opened) {
+				stream.close(3 * time.Second)
+				stream = out.open(ctx)
+				opened = time.Now()
+			}
+		}
 	}
-	// 等待所有 goroutine
-	<-ctx.Done()
-	utils.Log.Info("Netwrok started")
 }
 
 func transferCategory(category string) string {
@@ -75,8 +157,36 @@ func transferCategory(category string) string {
 		return disk.Category
 	case "memory":
 		return memory.Category
-	case "netwrok":
+	case "network", "netwrok": // netwrok 為舊版設定檔的拼法
 		return network.Category
+	case "package":
+		return packages.Category
+	case "systemd":
+		return systemd.Category
+	case "cgroup":
+		return cgroup.Category
+	case "container":
+		return container.Category
+	case "kmsg":
+		return kmsg.Category
+	case "applog":
+		return applog.Category
+	case "auth":
+		return auth.Category
+	case "session":
+		return session.Category
+	case "integrity":
+		return integrity.Category
+	case "cert":
+		return certs.Category
+	case "probe":
+		return probe.Category
+	case "exec":
+		return exec.Category
+	case "scrape":
+		return scrape.Category
+	case "statsd":
+		return statsd.Category
 	}
 	return ""
 }
@@ -102,6 +212,10 @@ func latestNByFilename(prefix, dir string, n int) []string {
 		if !strings.HasPrefix(name, prefix) {
 			continue
 		}
+		// offset 狀態檔不是資料
+		if strings.HasSuffix(name, ".offset") || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
 
 		// 去掉 prefix，例如 "network-2025-11-23" → "2025-11-23"
 		dateStr := strings.TrimPrefix(name, prefix+"-")
@@ -140,15 +254,16 @@ func latestNByFilename(prefix, dir string, n int) []string {
 }
 
 // ------------------------- Tail 單個檔案 -------------------------
-func tailOneFile(ctx context.Context, filePath string, cfg config.NetworkConfig, stream logstream.LogStreamer_StreamLogsClient) error {
-	// 避免 offset 重覆加
-	offsetFile := filePath
-	if !strings.HasSuffix(filePath, ".offset") {
-		offsetFile = filePath + ".offset"
+func tailOneFile(ctx context.Context, filePath, category string, out sink, builder *eventBuilder, stream *ackStream) error {
+	// 每個 sink 各自記錄讀到哪裡
+	offsetFile := offsetPath(filePath, out.name())
+	// 舊的 offset 檔屬於 gRPC，不論 sink 取什麼名稱都沿用
+	if _, ok := out.(*connManager); ok {
+		migrateOffset(filePath, offsetFile)
 	}
 
-	// 讀取 offset 狀態
-	lastOffset := loadOffsetState(offsetFile)
+	// 從這條串流已送出的位置繼續，新串流則從已確認的 offset 開始
+	offset := stream.resumeOffset(offsetFile)
 
 	// 打開檔案
 	f, err := os.Open(filePath)
@@ -159,12 +274,12 @@ func tailOneFile(ctx context.Context, filePath string, cfg config.NetworkConfig,
 	defer f.Close()
 
 	// 從 offset 移動
-	if _, err := f.Seek(lastOffset.Offset, io.SeekStart); err != nil {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
 		return err
 	}
 
 	reader := bufio.NewReader(f)
-	var seq uint64 = 0
+	name := filepath.Base(filePath)
 
 	for {
 		select {
@@ -182,54 +297,15 @@ func tailOneFile(ctx context.Context, filePath string, cfg config.NetworkConfig,
 				continue
 			}
 
-			// 建立 event
-			event := &logstream.LogEvent{
-				Seq:       seq,
-				Timestamp: time.Now().Format(time.RFC3339Nano),
-				Source:    "SysProbe",
-				Payload:   []byte(line),
-			}
+			// 建立 event，seq 由串流指定
+			event := builder.build(category, name, offset, []byte(line))
+			offset += int64(len(line))
 
-			// gRPC 發送
-			for {
-				if err := stream.Send(event); err != nil {
-					utils.Log.Debug("send failed, reconnecting: %v", err)
-					time.Sleep(time.Second)
-					stream = connectStream(cfg)
-					continue
-				}
-				break
+			// gRPC 發送；offset 等收到 Ack 才寫入
+			if err := stream.send(ctx, offsetFile, offset, event); err != nil {
+				return fmt.Errorf("send failed, reconnecting: %w", err)
 			}
-
-			// 更新 offset
-			pos, _ := f.Seek(0, io.SeekCurrent)
-			saveOffsetState(offsetFile, pos)
-			seq++
-		}
-	}
-}
-
-// ------------------------- gRPC -------------------------
-func connectStream(cfg config.NetworkConfig) logstream.LogStreamer_StreamLogsClient {
-	for {
-		conn, err := grpc.Dial(cfg.Host, grpc.WithInsecure())
-		if err != nil {
-			utils.Log.Error("dial failed, retrying: %v", err)
-			time.Sleep(connectedRetryTime * time.Second)
-			continue
 		}
-
-		client := logstream.NewLogStreamerClient(conn)
-
-		stream, err := client.StreamLogs(context.Background())
-		if err != nil {
-			utils.Log.Error("stream failed, retrying: %v", err)
-			time.Sleep(connectedRetryTime * time.Second)
-			continue
-		}
-
-		utils.Log.Debug("gRPC connected")
-		return stream
 	}
 }
 
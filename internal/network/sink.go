@@ -0,0 +1,244 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"sysprobe/internal/utils"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// 沒有設定 sinks 時的 gRPC sink 名稱
+const defaultSink = "grpc"
+
+// sink 輸出目的地，每個 category 在上面開一條 ackStream，確認後才寫入 offset
+type sink interface {
+	name() string
+	// open 建立串流，失敗時自行重試；ctx 結束時回傳 nil
+	open(ctx context.Context) *ackStream
+	// backoff 串流中斷第 n 次後重新建立前的等待時間
+	backoff(n int) time.Duration
+	// failback 串流是否應該重建（例如切回優先的 collector）
+	failback(s *ackStream, opened time.Time) bool
+	close()
+}
+
+// sinkRoute sink 與要送出的 category
+type sinkRoute struct {
+	sink       sink
+	categories []string
+}
+
+// newSinks 依 network.sinks 建立 sink，設定有誤的 sink 不啟動，其他照常
+func newSinks(ctx context.Context, cfg config.NetworkConfig, agent string) []sinkRoute {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []config.SinkConfig{{Name: defaultSink, Type: "grpc"}}
+	}
+
+	var routes []sinkRoute
+	names := make(map[string]bool)
+	hasGRPC := false
+	for _, sc := range sinks {
+		if sc.Name == "" {
+			sc.Name = sc.Type
+		}
+		if names[sc.Name] {
+			utils.Log.Error("[Network] sink %s: duplicate name, skipped", sc.Name)
+			continue
+		}
+		names[sc.Name] = true
+
+		var s sink
+		var err error
+		switch sc.Type {
+		case "grpc":
+			if hasGRPC {
+				err = fmt.Errorf("only one grpc sink is supported")
+				break
+			}
+			hasGRPC = true
+			s, err = newGRPCSink(ctx, cfg, sc, agent)
+		case "http":
+			s, err = newHTTPSink(cfg, sc, agent)
+		case "syslog":
+			s, err = newSyslogSink(ctx, cfg, sc)
+		case "file":
+			if sc.Path == "" {
+				err = fmt.Errorf("path is required")
+				break
+			}
+			s = newWriterSink(cfg, sc, writeFile(sc.Path))
+		case "stdout":
+			s = newWriterSink(cfg, sc, func(_ string, data []byte) error {
+				_, err := os.Stdout.Write(data)
+				return err
+			})
+		default:
+			err = fmt.Errorf("unsupported type %q", sc.Type)
+		}
+		if err != nil {
+			utils.Log.Error("[Network] sink %s disabled: %v", sc.Name, err)
+			continue
+		}
+
+		categories := sc.Category
+		if len(categories) == 0 {
+			categories = cfg.Category
+		}
+		routes = append(routes, sinkRoute{sink: s, categories: categories})
+		utils.Log.Info("[Network] sink %s (%s) started", sc.Name, sc.Type)
+	}
+	return routes
+}
+
+// offsetPath 每個 (檔案, sink) 各自的 offset 檔
+func offsetPath(filePath, sinkName string) string {
+	return filePath + "." + sinkName + ".offset"
+}
+
+// migrateOffset 沿用只有 gRPC 時的 offset 檔（<檔案>.offset），避免升級後整個重送
+func migrateOffset(filePath, offsetFile string) {
+	legacy := filePath + ".offset"
+	if _, err := os.Stat(offsetFile); err == nil {
+		return
+	}
+	if _, err := os.Stat(legacy); err == nil {
+		os.Rename(legacy, offsetFile)
+	}
+}
+
+// ------------------------- gRPC -------------------------
+
+func newGRPCSink(ctx context.Context, cfg config.NetworkConfig, sc config.SinkConfig, agent string) (sink, error) {
+	creds, err := transportCredentials(ctx, cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	auth, err := perRPCCredentials(cfg.Auth, agent, !cfg.TLS.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth config: %w", err)
+	}
+	if auth != nil {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(auth))
+	}
+
+	conn, err := newConnManager(ctx, cfg, sc, agent, dialOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoints config: %w", err)
+	}
+	return conn, nil
+}
+
+// ------------------------- file / stdout -------------------------
+
+// writerSink 同步寫入的 sink，寫入成功即視為確認；一次寫入整批的 payload（JSONL）
+type writerSink struct {
+	cfg    config.SinkConfig
+	window int
+	retry  backoffPolicy
+
+	mu    sync.Mutex // 多個 category 可能寫到同一個目的地
+	write func(category string, data []byte) error
+}
+
+func newWriterSink(cfg config.NetworkConfig, sc config.SinkConfig, write func(category string, data []byte) error) *writerSink {
+	return &writerSink{
+		cfg:    sc,
+		window: sc.Buffer,
+		retry:  newBackoffPolicy(sc.Retry, cfg.Backoff),
+		write:  write,
+	}
+}
+
+func (s *writerSink) name() string { return s.cfg.Name }
+
+func (s *writerSink) open(context.Context) *ackStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &syncTransport{ctx: ctx, write: s.writeEvents, acks: make(chan uint64, 16)}
+	return newAckStream(t, s.cfg.Batch, s.window, cancel)
+}
+
+func (s *writerSink) writeEvents(events []*logstream.LogEvent) error {
+	var buf bytes.Buffer
+	for _, e := range events {
+		buf.Write(e.Payload)
+		if !bytes.HasSuffix(e.Payload, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(events[0].Category, buf.Bytes())
+}
+
+func (s *writerSink) backoff(n int) time.Duration         { return s.retry.delay(n) }
+func (s *writerSink) failback(*ackStream, time.Time) bool { return false }
+func (s *writerSink) close()                              {}
+
+// writeFile 附加到檔案，路徑中的 {category} / {date} 依事件代入
+func writeFile(pattern string) func(category string, data []byte) error {
+	return func(category string, data []byte) error {
+		path := strings.NewReplacer(
+			"{category}", category,
+			"{date}", time.Now().Format("2006-01-02"),
+		).Replace(pattern)
+		return appendFile(path, data)
+	}
+}
+
+// appendFile 附加到檔案，目錄不存在時建立
+func appendFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncTransport 讓同步寫入的 sink 也使用 ackStream：send 寫入成功後立即產生 Ack
+type syncTransport struct {
+	ctx   context.Context
+	write func(events []*logstream.LogEvent) error
+	acks  chan uint64
+}
+
+func (t *syncTransport) send(seq uint64, events []*logstream.LogEvent) error {
+	if err := t.write(events); err != nil {
+		return err
+	}
+	select {
+	case t.acks <- seq:
+		return nil
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+func (t *syncTransport) recv() (uint64, error) {
+	select {
+	case seq := <-t.acks:
+		return seq, nil
+	case <-t.ctx.Done():
+		return 0, t.ctx.Err()
+	}
+}
+
+func (t *syncTransport) CloseSend() error { return nil }
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sysprobe/internal/config"
+	logstream "sysprobe/internal/network/logstream"
+	"testing"
+	"time"
+)
+
+// ackingTransport 記錄送出的事件並立即確認
+type ackingTransport struct {
+	mu     sync.Mutex
+	events []*logstream.LogEvent
+	acks   chan uint64
+	closed chan struct{}
+}
+
+func newAckingTransport() *ackingTransport {
+	return &ackingTransport{acks: make(chan uint64, 100), closed: make(chan struct{})}
+}
+
+func (t *ackingTransport) send(seq uint64, events []*logstream.LogEvent) error {
+	t.mu.Lock()
+	t.events = append(t.events, events...)
+	t.mu.Unlock()
+	t.acks <- seq
+	return nil
+}
+
+func (t *ackingTransport) recv() (uint64, error) {
+	select {
+	case seq := <-t.acks:
+		return seq, nil
+	case <-t.closed:
+		return 0, errStreamClosed
+	}
+}
+
+func (t *ackingTransport) CloseSend() error {
+	close(t.closed)
+	return nil
+}
+
+func (t *ackingTransport) payloads() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]string, len(t.events))
+	for i, e := range t.events {
+		out[i] = string(e.Payload)
+	}
+	return out
+}
+
+func testNetworkConfig() config.NetworkConfig {
+	return config.NetworkConfig{
+		Host:  "127.0.0.1:4317",
+		TLS:   config.TLSConfig{Insecure: true},
+		Auth:  config.AuthConfig{Type: "none"},
+		Batch: config.BatchConfig{Enable: true, MaxEvents: 500},
+	}
+}
+
+func TestGRPCSinkBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		batch config.BatchConfig
+		want  config.BatchConfig
+	}{
+		{"network batch", config.BatchConfig{}, config.BatchConfig{Enable: true, MaxEvents: 500}},
+		{"sink batch", config.BatchConfig{Enable: true, MaxEvents: 50}, config.BatchConfig{Enable: true, MaxEvents: 50}},
+		{"sink batch disabled", config.BatchConfig{MaxEvents: 1}, config.BatchConfig{MaxEvents: 1}},
+	}
+	for _, tt := range tests {
+		s, err := newGRPCSink(ctx, testNetworkConfig(), config.SinkConfig{Name: "primary", Type: "grpc", Batch: tt.batch}, "agent")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.(*connManager).batch; got != tt.want {
+			t.Errorf("%s: batch = %+v, want %+v", tt.name, got, tt.want)
+		}
+		s.close()
+	}
+}
+
+func TestTailMigratesLegacyOffset(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	grpcSink, err := newGRPCSink(ctx, testNetworkConfig(), config.SinkConfig{Name: "primary", Type: "grpc"}, "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcSink.close()
+	// 名稱是 grpc 但不是 gRPC sink，不能拿走舊的 offset 檔
+	fileSink := newWriterSink(testNetworkConfig(), config.SinkConfig{Name: "grpc", Type: "file"}, func(string, []byte) error { return nil })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CPU-2026-10-19.log")
+	lines := "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"
+	os.WriteFile(path, []byte(lines), 0644)
+	legacy := path + ".offset"
+	saveOffsetState(legacy, int64(len("{\"n\":1}\n")))
+
+	tail := func(out sink) *ackingTransport {
+		tr := newAckingTransport()
+		stream := newAckStream(tr, config.BatchConfig{}, 10, func() {})
+		if err := tailOneFile(ctx, path, "CPU", out, &eventBuilder{agent: "agent"}, stream); err != nil {
+			t.Fatal(err)
+		}
+		stream.close(time.Second)
+		return tr
+	}
+
+	tail(fileSink)
+	if _, err := os.Stat(legacy); err != nil {
+		t.Fatalf("legacy offset taken by a non-grpc sink: %v", err)
+	}
+
+	tr := tail(grpcSink)
+	if got := tr.payloads(); len(got) != 2 || got[0] != "{\"n\":2}\n" {
+		t.Fatalf("grpc sink sent %q, want lines 2 and 3", got)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy offset still present: %v", err)
+	}
+	if off := loadOffsetState(offsetPath(path, "primary")).Offset; off != int64(len(lines)) {
+		t.Errorf("offset = %d, want %d", off, len(lines))
+	}
+}
@@ -0,0 +1,381 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/auth"
+	"sysprobe/internal/monitor/certs"
+	"sysprobe/internal/monitor/container"
+	"sysprobe/internal/monitor/integrity"
+	"sysprobe/internal/monitor/kmsg"
+	"sysprobe/internal/monitor/packages"
+	"sysprobe/internal/monitor/session"
+	"sysprobe/internal/monitor/systemd"
+	logstream "sysprobe/internal/network/logstream"
+	"time"
+)
+
+const (
+	defaultSyslogApp      = "sysprobe"
+	defaultSyslogFacility = "local0"
+	defaultSyslogTimeout  = 10 // 秒
+
+	// structured data 的 SD-ID；32473 是 RFC 5612 保留給範例與文件使用的 enterprise number
+	syslogSDID = "sysprobe@32473"
+	labelsSDID = "labels@32473"
+
+	// UDP 一則訊息一個 datagram，超過時截斷（多數 syslog server 的預設上限）
+	maxUDPMessage = 8192
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+const (
+	sevEmerg = iota
+	sevAlert
+	sevCrit
+	sevErr
+	sevWarning
+	sevNotice
+	sevInfo
+	sevDebug
+)
+
+var syslogSeverities = map[string]int{
+	"emerg": sevEmerg, "alert": sevAlert, "crit": sevCrit, "err": sevErr, "error": sevErr,
+	"warning": sevWarning, "warn": sevWarning, "notice": sevNotice, "info": sevInfo, "debug": sevDebug,
+}
+
+// 沒有在 facilities 設定的 category 使用的 facility，其他為 sink 的 facility
+var defaultFacilities = map[string]string{
+	auth.Category:      "authpriv",
+	session.Category:   "authpriv",
+	integrity.Category: "auth",
+	kmsg.Category:      "kern",
+	systemd.Category:   "daemon",
+	container.Category: "daemon",
+}
+
+// syslogSink 以 syslog 送出事件（RFC 5424 或 RFC 3164），寫入成功即視為確認
+// UDP 每則訊息一個 datagram；TCP / TLS 共用一條連線，以 octet counting（RFC 6587）分隔訊息
+type syslogSink struct {
+	cfg        config.SinkConfig
+	window     int
+	retry      backoffPolicy
+	timeout    time.Duration
+	tls        *clientTLS
+	facility   int
+	facilities map[string]int // 事件的 category → facility
+	severities map[string]int // 事件的 category 或事件名稱 → severity
+	hostname   string
+	pid        string
+
+	mu   sync.Mutex // 多個 category 共用同一條連線
+	conn net.Conn
+}
+
+func newSyslogSink(ctx context.Context, cfg config.NetworkConfig, sc config.SinkConfig) (sink, error) {
+	if _, _, err := net.SplitHostPort(sc.Address); err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+	switch sc.Protocol {
+	case "":
+		sc.Protocol = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q (want udp, tcp or tls)", sc.Protocol)
+	}
+	switch sc.Format {
+	case "":
+		sc.Format = "rfc5424"
+	case "rfc5424", "rfc3164":
+	default:
+		return nil, fmt.Errorf("unsupported format %q (want rfc5424 or rfc3164)", sc.Format)
+	}
+	if sc.AppName == "" {
+		sc.AppName = defaultSyslogApp
+	}
+	if sc.Facility == "" {
+		sc.Facility = defaultSyslogFacility
+	}
+
+	timeout := sc.Timeout
+	if timeout <= 0 {
+		timeout = defaultSyslogTimeout
+	}
+	s := &syslogSink{
+		cfg:        sc,
+		window:     sc.Buffer,
+		retry:      newBackoffPolicy(sc.Retry, cfg.Backoff),
+		timeout:    time.Duration(timeout) * time.Second,
+		facilities: make(map[string]int),
+		severities: make(map[string]int),
+		pid:        strconv.Itoa(os.Getpid()),
+	}
+	s.hostname, _ = os.Hostname()
+
+	var ok bool
+	if s.facility, ok = syslogFacilities[sc.Facility]; !ok {
+		return nil, fmt.Errorf("unknown facility %q", sc.Facility)
+	}
+	for category, name := range defaultFacilities {
+		s.facilities[category] = syslogFacilities[name]
+	}
+	for c, name := range sc.Facilities {
+		category := transferCategory(c)
+		if category == "" {
+			return nil, fmt.Errorf("facilities: unknown category %q", c)
+		}
+		f, ok := syslogFacilities[name]
+		if !ok {
+			return nil, fmt.Errorf("facilities: unknown facility %q", name)
+		}
+		s.facilities[category] = f
+	}
+	for key, name := range sc.Severities {
+		sev, ok := syslogSeverities[name]
+		if !ok {
+			return nil, fmt.Errorf("severities: unknown severity %q", name)
+		}
+		// 不是 category 名稱時視為事件名稱，例如 AUTH_BRUTE_FORCE
+		if category := transferCategory(key); category != "" {
+			key = category
+		}
+		s.severities[key] = sev
+	}
+
+	if sc.Protocol == "tls" {
+		if sc.TLS.Insecure {
+			return nil, fmt.Errorf("tls.insecure is not supported, use protocol tcp")
+		}
+		tc, err := newTLSConfig(ctx, sc.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: %w", err)
+		}
+		s.tls = tc
+	}
+	return s, nil
+}
+
+func (s *syslogSink) name() string { return s.cfg.Name }
+
+func (s *syslogSink) open(context.Context) *ackStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &syncTransport{ctx: ctx, write: s.writeEvents, acks: make(chan uint64, 16)}
+	return newAckStream(t, s.cfg.Batch, s.window, cancel)
+}
+
+func (s *syslogSink) backoff(n int) time.Duration         { return s.retry.delay(n) }
+func (s *syslogSink) failback(*ackStream, time.Time) bool { return false }
+
+func (s *syslogSink) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// writeEvents 送出一批；寫入失敗時關閉連線，串流重建後從已確認的 offset 重送（可能重複）
+func (s *syslogSink) writeEvents(events []*logstream.LogEvent) error {
+	msgs := make([][]byte, len(events))
+	for i, e := range events {
+		msgs[i] = s.format(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+
+	var err error
+	if s.cfg.Protocol == "udp" {
+		for _, m := range msgs {
+			if len(m) > maxUDPMessage {
+				m = m[:maxUDPMessage]
+			}
+			if _, err = s.conn.Write(m); err != nil {
+				break
+			}
+		}
+	} else {
+		var buf bytes.Buffer
+		for _, m := range msgs {
+			buf.WriteString(strconv.Itoa(len(m)))
+			buf.WriteByte(' ')
+			buf.Write(m)
+		}
+		_, err = s.conn.Write(buf.Bytes())
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *syslogSink) dial() (net.Conn, error) {
+	d := &net.Dialer{Timeout: s.timeout}
+	switch s.cfg.Protocol {
+	case "tls":
+		// 沒有設定 server_name 時以 address 的主機名稱（或 IP）驗證
+		host, _, _ := net.SplitHostPort(s.cfg.Address)
+		return tls.DialWithDialer(d, "tcp", s.cfg.Address, s.tls.config(host))
+	default:
+		return d.Dial(s.cfg.Protocol, s.cfg.Address)
+	}
+}
+
+// syslogRecord 決定 severity 與 MSGID 需要的 payload 欄位
+type syslogRecord struct {
+	Event   string `json:"Event"`
+	Level   *int   `json:"Level"`
+	Success *bool  `json:"Success"`
+}
+
+// format 一則 syslog 訊息，MSG 為原本的 JSON payload
+func (s *syslogSink) format(e *logstream.LogEvent) []byte {
+	var rec syslogRecord
+	json.Unmarshal(e.Payload, &rec)
+
+	facility, ok := s.facilities[e.Category]
+	if !ok {
+		facility = s.facility
+	}
+	pri := facility*8 + s.severity(e.Category, rec)
+
+	ts, err := time.Parse(time.RFC3339Nano, e.SampleTimestamp)
+	if err != nil {
+		ts, err = time.Parse(time.RFC3339Nano, e.Timestamp)
+		if err != nil {
+			ts = time.Now()
+		}
+	}
+	host := e.Labels["hostname"]
+	if host == "" {
+		host = s.hostname
+	}
+	msg := bytes.TrimRight(e.Payload, "\r\n")
+
+	var buf bytes.Buffer
+	if s.cfg.Format == "rfc3164" {
+		// <PRI>Mmm dd hh:mm:ss HOST TAG[PID]: MSG，沒有 structured data
+		fmt.Fprintf(&buf, "<%d>%s %s %s[%s]: ", pri, ts.Local().Format(time.Stamp),
+			syslogField(host, 255), syslogField(s.cfg.AppName, 32), s.pid)
+		buf.Write(msg)
+		return buf.Bytes()
+	}
+
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+	msgID := rec.Event
+	if msgID == "" {
+		msgID = e.Category
+	}
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s %s ", pri, ts.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(host, 255), syslogField(s.cfg.AppName, 48), s.pid, syslogField(msgID, 32))
+
+	fmt.Fprintf(&buf, `[%s agent="%s" category="%s" schema="%d"]`, syslogSDID,
+		sdValue(e.AgentUuid), sdValue(e.Category), e.SchemaVersion)
+	if len(e.Labels) > 0 {
+		keys := make([]string, 0, len(e.Labels))
+		for k := range e.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteString("[" + labelsSDID)
+		for _, k := range keys {
+			fmt.Fprintf(&buf, ` %s="%s"`, sdName(k), sdValue(e.Labels[k]))
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(' ')
+	buf.Write(msg)
+	return buf.Bytes()
+}
+
+// severity 設定優先（事件名稱、再來是 category），否則依事件內容決定
+func (s *syslogSink) severity(category string, rec syslogRecord) int {
+	if sev, ok := s.severities[rec.Event]; ok && rec.Event != "" {
+		return sev
+	}
+	if sev, ok := s.severities[category]; ok {
+		return sev
+	}
+	if category == kmsg.Category && rec.Level != nil {
+		return min(max(*rec.Level, sevEmerg), sevDebug)
+	}
+
+	switch rec.Event {
+	case auth.EventBruteForce, certs.EventCritical:
+		return sevCrit
+	case certs.EventError:
+		return sevErr
+	case auth.EventLoginFailed, auth.EventInvalidUser, auth.EventFailedSummary, auth.EventMaxAttempts, auth.EventSudoAuthFailed,
+		integrity.EventModified, integrity.EventDeleted, integrity.EventPermChanged,
+		certs.EventWarning, systemd.EventRestarted, container.EventOOM, container.EventDie:
+		return sevWarning
+	case auth.EventLoginSuccess, auth.EventSudoCommand, integrity.EventCreated, session.EventBoot,
+		session.EventLogin, session.EventLogout, packages.EventInstalled, packages.EventRemoved, packages.EventUpgraded:
+		return sevNotice
+	}
+	// probe / exec 等有成功與否的結果
+	if rec.Success != nil && !*rec.Success {
+		return sevWarning
+	}
+	return sevInfo
+}
+
+// syslogField HOSTNAME / APP-NAME / MSGID 只能是可見的 ASCII，空白時為 "-"
+func syslogField(v string, n int) string {
+	b := []byte(v)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > n {
+		b = b[:n]
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// sdName PARAM-NAME 不能有 '='、空白、']'、'"'，最長 32
+func sdName(v string) string {
+	b := []byte(syslogField(v, 32))
+	for i, c := range b {
+		if c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdValue PARAM-VALUE 中的 '"'、'\'、']' 需要跳脫
+func sdValue(v string) string { return sdEscaper.Replace(v) }
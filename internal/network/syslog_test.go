@@ -0,0 +1,200 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sysprobe/internal/config"
+	"sysprobe/internal/monitor/auth"
+	"sysprobe/internal/monitor/certs"
+	"sysprobe/internal/monitor/cpu"
+	"sysprobe/internal/monitor/integrity"
+	"sysprobe/internal/monitor/kmsg"
+	"sysprobe/internal/monitor/probe"
+	logstream "sysprobe/internal/network/logstream"
+	"testing"
+	"time"
+)
+
+func newTestSyslogSink(t *testing.T, sc config.SinkConfig) *syslogSink {
+	t.Helper()
+	if sc.Address == "" {
+		sc.Address = "127.0.0.1:514"
+	}
+	s, err := newSyslogSink(context.Background(), testNetworkConfig(), sc)
+	if err != nil {
+		t.Fatalf("newSyslogSink: %v", err)
+	}
+	ss := s.(*syslogSink)
+	ss.hostname, ss.pid = "agent-host", "1234"
+	return ss
+}
+
+// syslogEvents 固定樣本時間，讓 format 的結果可以比對
+func syslogEvents(lines ...string) []*logstream.LogEvent {
+	events := testEvents(lines...)
+	for _, e := range events {
+		e.Category, e.SampleTimestamp = cpu.Category, "2026-10-19T08:00:02Z"
+	}
+	return events
+}
+
+func TestSyslogFormat(t *testing.T) {
+	ev := &logstream.LogEvent{
+		AgentUuid:       "uuid-1",
+		Category:        auth.Category,
+		SampleTimestamp: "2026-10-19T08:00:02.5Z",
+		SchemaVersion:   1,
+		Labels:          map[string]string{"hostname": "web1", "env": `a"b]c\d`, "bad key=": "x"},
+		Payload:         []byte(`{"Event":"AUTH_BRUTE_FORCE"}` + "\n"),
+	}
+
+	// authpriv(10) * 8 + crit(2)；SD-PARAM 的值跳脫 '"'、']'、'\'，名稱中的空白與 '=' 換成 '_'
+	s := newTestSyslogSink(t, config.SinkConfig{})
+	want := `<82>1 2026-10-19T08:00:02.500000Z web1 sysprobe 1234 AUTH_BRUTE_FORCE ` +
+		`[sysprobe@32473 agent="uuid-1" category="AUTH" schema="1"]` +
+		`[labels@32473 bad_key_="x" env="a\"b\]c\\d" hostname="web1"] {"Event":"AUTH_BRUTE_FORCE"}`
+	if got := string(s.format(ev)); got != want {
+		t.Errorf("rfc5424:\n got %s\nwant %s", got, want)
+	}
+
+	// 沒有事件名稱時 MSGID 為 category，沒有 hostname 標籤時使用本機名稱；local0(16) * 8 + info(6)
+	plain := &logstream.LogEvent{Category: cpu.Category, SampleTimestamp: "2026-10-19T08:00:02Z", Payload: []byte(`{"Usage":1}`)}
+	want = `<134>1 2026-10-19T08:00:02.000000Z agent-host sysprobe 1234 CPU [sysprobe@32473 agent="" category="CPU" schema="0"] {"Usage":1}`
+	if got := string(s.format(plain)); got != want {
+		t.Errorf("rfc5424 without labels:\n got %s\nwant %s", got, want)
+	}
+
+	s = newTestSyslogSink(t, config.SinkConfig{Format: "rfc3164", AppName: "my app"})
+	ts, _ := time.Parse(time.RFC3339Nano, ev.SampleTimestamp)
+	want = fmt.Sprintf(`<82>%s web1 my_app[1234]: {"Event":"AUTH_BRUTE_FORCE"}`, ts.Local().Format(time.Stamp))
+	if got := string(s.format(ev)); got != want {
+		t.Errorf("rfc3164:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	level := func(n int) *int { return &n }
+	failed := false
+
+	s := newTestSyslogSink(t, config.SinkConfig{})
+	tests := []struct {
+		category string
+		rec      syslogRecord
+		want     int
+	}{
+		{auth.Category, syslogRecord{Event: auth.EventBruteForce}, sevCrit},
+		{certs.Category, syslogRecord{Event: certs.EventError}, sevErr},
+		{auth.Category, syslogRecord{Event: auth.EventLoginFailed}, sevWarning},
+		{auth.Category, syslogRecord{Event: auth.EventMaxAttempts}, sevWarning},
+		{integrity.Category, syslogRecord{Event: integrity.EventModified}, sevWarning},
+		{auth.Category, syslogRecord{Event: auth.EventLoginSuccess}, sevNotice},
+		{kmsg.Category, syslogRecord{Level: level(3)}, sevErr},
+		{kmsg.Category, syslogRecord{Level: level(12)}, sevDebug},
+		{probe.Category, syslogRecord{Success: &failed}, sevWarning},
+		{cpu.Category, syslogRecord{}, sevInfo},
+	}
+	for _, tt := range tests {
+		if got := s.severity(tt.category, tt.rec); got != tt.want {
+			t.Errorf("severity(%s, %+v) = %d, want %d", tt.category, tt.rec, got, tt.want)
+		}
+	}
+
+	// 設定優先，事件名稱又優先於 category
+	s = newTestSyslogSink(t, config.SinkConfig{
+		Severities: map[string]string{"auth": "info", auth.EventBruteForce: "alert"},
+		Facilities: map[string]string{"auth": "local3"},
+	})
+	if got := s.severity(auth.Category, syslogRecord{Event: auth.EventBruteForce}); got != sevAlert {
+		t.Errorf("configured event severity = %d, want %d", got, sevAlert)
+	}
+	if got := s.severity(auth.Category, syslogRecord{Event: auth.EventLoginFailed}); got != sevInfo {
+		t.Errorf("configured category severity = %d, want %d", got, sevInfo)
+	}
+	if got := s.facilities[auth.Category]; got != 19 {
+		t.Errorf("configured facility = %d, want 19", got)
+	}
+
+	for _, sc := range []config.SinkConfig{
+		{Severities: map[string]string{"auth": "loud"}},
+		{Facilities: map[string]string{"nope": "local0"}},
+		{Facilities: map[string]string{"auth": "local9"}},
+	} {
+		sc.Address = "127.0.0.1:514"
+		if _, err := newSyslogSink(context.Background(), testNetworkConfig(), sc); err == nil {
+			t.Errorf("config %+v accepted", sc)
+		}
+	}
+}
+
+// TCP 以 octet counting 分隔：每則訊息前為十進位長度與一個空白
+func TestSyslogOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s := newTestSyslogSink(t, config.SinkConfig{Protocol: "tcp", Address: ln.Addr().String()})
+	defer s.close()
+	events := syslogEvents(`{"Event":"A"}`, `{"Msg":"two words"}`, `{"Msg":"line\nbreak"}`)
+	if err := s.writeEvents(events); err != nil {
+		t.Fatalf("writeEvents: %v", err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for i, e := range events {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		n, err := strconv.Atoi(size[:len(size)-1])
+		if err != nil {
+			t.Fatalf("frame %d: bad length %q", i, size)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if want := s.format(e); string(msg) != string(want) {
+			t.Errorf("frame %d = %q, want %q", i, msg, want)
+		}
+	}
+}
+
+// UDP 一則訊息一個 datagram，不加長度前綴
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s := newTestSyslogSink(t, config.SinkConfig{Address: pc.LocalAddr().String()})
+	defer s.close()
+	events := syslogEvents(`{"Event":"A"}`, `{"Event":"B"}`)
+	if err := s.writeEvents(events); err != nil {
+		t.Fatalf("writeEvents: %v", err)
+	}
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, maxUDPMessage)
+	for i, e := range events {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("datagram %d: %v", i, err)
+		}
+		if want := s.format(e); string(buf[:n]) != string(want) {
+			t.Errorf("datagram %d = %q, want %q", i, buf[:n], want)
+		}
+	}
+}
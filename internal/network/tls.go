@@ -0,0 +1,260 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sysprobe/internal/config"
+	"sysprobe/internal/utils"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const defaultTLSReload = 60 // 秒
+
+// 不接受 1.2 以下的版本
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// transportCredentials 依設定建立連線憑證，設定或憑證檔有誤時回傳錯誤（啟動時檢查）
+func transportCredentials(ctx context.Context, cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		utils.Log.Warn("[Network] TLS disabled (network.tls.insecure), logs are sent in plain text")
+		return insecure.NewCredentials(), nil
+	}
+	tc, err := newTLSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tlsCredentials{TransportCredentials: credentials.NewTLS(tc.base), tls: tc}, nil
+}
+
+// clientTLS client 端的 TLS 設定，每條連線依目標主機名稱建立自己的驗證
+type clientTLS struct {
+	base   *tls.Config
+	reload *tlsReloader
+}
+
+// newTLSConfig client 端的 TLS 設定，CA 與 client 憑證更新時自動重新載入
+func newTLSConfig(ctx context.Context, cfg config.TLSConfig) (*clientTLS, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported min_version %q (want 1.2 or 1.3)", cfg.MinVersion)
+		}
+		minVersion = v
+	}
+	if (cfg.Cert == "") != (cfg.Key == "") {
+		return nil, errors.New("cert and key must be set together")
+	}
+
+	r := &tlsReloader{cfg: cfg}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	interval := cfg.Reload
+	if interval <= 0 {
+		interval = defaultTLSReload
+	}
+	go r.watch(ctx, time.Duration(interval)*time.Second)
+
+	// 憑證驗證改由 verify 處理，才能在不重建連線設定的情況下替換 CA
+	base := &tls.Config{
+		ServerName:           cfg.ServerName,
+		MinVersion:           minVersion,
+		InsecureSkipVerify:   true,
+		GetClientCertificate: r.clientCert,
+	}
+	return &clientTLS{base: base, reload: r}, nil
+}
+
+// config 連到 host 時使用的設定：以 server_name（沒有設定時為 host）驗證 server 憑證
+// host 為 IP 時 ConnectionState.ServerName 是空的，所以驗證的名稱在這裡決定
+func (c *clientTLS) config(host string) *tls.Config {
+	tc := c.base.Clone()
+	if tc.ServerName == "" {
+		tc.ServerName = host
+	}
+	name := tc.ServerName
+	tc.VerifyConnection = func(cs tls.ConnectionState) error {
+		return c.reload.verify(cs, name)
+	}
+	return tc
+}
+
+// tlsCredentials gRPC 的 TLS 憑證，連線時依 authority（resolver 給的主機名稱）建立設定
+type tlsCredentials struct {
+	credentials.TransportCredentials
+	tls *clientTLS
+}
+
+func (c *tlsCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	host, _, err := net.SplitHostPort(authority)
+	if err != nil {
+		host = authority
+	}
+	return credentials.NewTLS(c.tls.config(host)).ClientHandshake(ctx, authority, conn)
+}
+
+func (c *tlsCredentials) Clone() credentials.TransportCredentials {
+	return &tlsCredentials{TransportCredentials: c.TransportCredentials.Clone(), tls: c.tls}
+}
+
+// tlsReloader 保存目前的 CA 與 client 憑證，檔案更新時重新載入
+type tlsReloader struct {
+	cfg config.TLSConfig
+
+	mu    sync.RWMutex
+	roots *x509.CertPool
+	cert  *tls.Certificate
+	mods  map[string]time.Time // 檔案 → 上次載入時的修改時間
+}
+
+// load 讀取 CA 與 client 憑證，任一個有誤就不替換目前的設定
+func (r *tlsReloader) load() error {
+	mods := make(map[string]time.Time)
+	for _, path := range []string{r.cfg.CA, r.cfg.Cert, r.cfg.Key} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		mods[path] = info.ModTime()
+	}
+
+	var roots *x509.CertPool
+	if r.cfg.CA == "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return fmt.Errorf("load system CA: %w", err)
+		}
+		roots = pool
+	} else {
+		pem, err := os.ReadFile(r.cfg.CA)
+		if err != nil {
+			return fmt.Errorf("read ca: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("ca %s: no PEM certificates found", r.cfg.CA)
+		}
+	}
+
+	var cert *tls.Certificate
+	if r.cfg.Cert != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.Cert, r.cfg.Key)
+		if err != nil {
+			return fmt.Errorf("load client cert: %w", err)
+		}
+		if now := time.Now(); now.After(c.Leaf.NotAfter) {
+			return fmt.Errorf("client cert %s expired at %s", r.cfg.Cert, c.Leaf.NotAfter.Format(time.RFC3339))
+		} else if now.Before(c.Leaf.NotBefore) {
+			return fmt.Errorf("client cert %s not valid before %s", r.cfg.Cert, c.Leaf.NotBefore.Format(time.RFC3339))
+		}
+		cert = &c
+	}
+
+	r.mu.Lock()
+	r.roots, r.cert, r.mods = roots, cert, mods
+	r.mu.Unlock()
+	return nil
+}
+
+// changed 檔案的修改時間和上次載入時不同
+func (r *tlsReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, mod := range r.mods {
+		info, err := os.Stat(path)
+		if err != nil {
+			// 輪替過程中檔案可能暫時不存在，下一輪再檢查
+			continue
+		}
+		if !info.ModTime().Equal(mod) {
+			return true
+		}
+	}
+	return false
+}
+
+// seen 更新記錄的修改時間，不替換憑證
+func (r *tlsReloader) seen() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for path := range r.mods {
+		if info, err := os.Stat(path); err == nil {
+			r.mods[path] = info.ModTime()
+		}
+	}
+}
+
+// watch 定期檢查憑證檔，更新後的連線使用新的憑證；載入失敗則沿用舊的
+func (r *tlsReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				// 記下這次的修改時間，同一份錯誤的檔案只回報一次
+				r.seen()
+				utils.Log.Error("[Network] reload TLS certificates fail, keep using the previous ones: %v", err)
+				continue
+			}
+			utils.Log.Info("[Network] TLS certificates reloaded")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *tlsReloader) clientCert(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil {
+		// 沒有設定 client 憑證，server 要求時由 server 拒絕
+		return &tls.Certificate{}, nil
+	}
+	return r.cert, nil
+}
+
+// verify 以目前的 CA 驗證 server 憑證鏈，並確認憑證屬於 name（主機名稱或 IP）
+func (r *tlsReloader) verify(cs tls.ConnectionState, name string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	if name == "" {
+		return errors.New("no server name to verify")
+	}
+	r.mu.RLock()
+	roots := r.roots
+	r.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	leaf := cs.PeerCertificates[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		return err
+	}
+	// VerifyHostname 同時處理 DNS 名稱與 IP SAN
+	return leaf.VerifyHostname(name)
+}
@@ -0,0 +1,155 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sysprobe/internal/config"
+	"testing"
+	"time"
+)
+
+// testCA 測試用的 CA，可簽發 server 憑證
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	path string // PEM
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, path: path}
+}
+
+// serverCert 簽發 server 憑證，names 中的 IP 放在 IP SAN，其他放在 DNS SAN
+func (ca *testCA) serverCert(t *testing.T, names ...string) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, n := range names {
+		if ip := net.ParseIP(n); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, n)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// tlsServer 接受連線並完成 handshake 的 TLS server
+func tlsServer(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.(*tls.Conn).Handshake()
+			c.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTLSVerifyServerName(t *testing.T) {
+	ca := newTestCA(t)
+	tests := []struct {
+		name       string
+		certNames  []string
+		serverName string
+		ok         bool
+	}{
+		{"ip san", []string{"127.0.0.1"}, "", true},
+		{"dns name only for ip endpoint", []string{"localhost"}, "", false},
+		{"server_name matches", []string{"localhost"}, "localhost", true},
+		{"server_name mismatch", []string{"127.0.0.1"}, "collector.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := tlsServer(t, ca.serverCert(t, tt.certNames...))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			tc, err := newTLSConfig(ctx, config.TLSConfig{CA: ca.path, ServerName: tt.serverName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			host, _, _ := net.SplitHostPort(addr)
+			conn, err := tls.Dial("tcp", addr, tc.config(host))
+			if err == nil {
+				conn.Close()
+			}
+			if (err == nil) != tt.ok {
+				t.Fatalf("dial err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTLSUntrustedCA(t *testing.T) {
+	addr := tlsServer(t, newTestCA(t).serverCert(t, "127.0.0.1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tc, err := newTLSConfig(ctx, config.TLSConfig{CA: newTestCA(t).path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := tls.Dial("tcp", addr, tc.config("127.0.0.1")); err == nil {
+		conn.Close()
+		t.Fatal("certificate from an untrusted CA was accepted")
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	ca := newTestCA(t)
+	for version, ok := range map[string]bool{"": true, "1.2": true, "1.3": true, "1.0": false, "1.1": false} {
+		_, err := newTLSConfig(context.Background(), config.TLSConfig{CA: ca.path, MinVersion: version})
+		if (err == nil) != ok {
+			t.Errorf("min_version %q: err = %v, want ok = %v", version, err, ok)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package utils
+
+import "os"
+
+// fileID Windows 的 FileInfo 沒有 inode，重啟後只能依位置與大小判斷
+func fileID(fi os.FileInfo) uint64 {
+	return 0
+}
+
+func fileDevice(fi os.FileInfo) uint64 {
+	return 0
+}
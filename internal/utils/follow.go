@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FollowState 檔案讀取位置，Inode 用來判斷重啟後是否仍是同一個檔案
+type FollowState struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// Follower 類似 tail -F：追蹤單一路徑，處理輪替（檔案被換掉）與截斷
+type Follower struct {
+	path    string
+	sep     []byte // 換行序列，UTF-16 需要兩個位元組
+	maxLine int
+	fromEnd bool
+	pinned  bool // 只讀開啟時的檔案，不跟著路徑換檔
+	state   FollowState
+
+	file     *os.File
+	info     os.FileInfo
+	offset   int64  // 已回傳的最後一行結尾位置
+	buf      []byte // 尚未遇到換行的資料
+	skipping bool   // 超過長度限制，丟棄直到下一個換行
+}
+
+// NewFollower 建立 Follower。state 為上次儲存的位置；
+// 沒有可用的位置時，fromEnd 決定從檔尾或檔頭開始。
+func NewFollower(path string, state FollowState, fromEnd bool, sep []byte, maxLine int) *Follower {
+	if len(sep) == 0 {
+		sep = []byte{'\n'}
+	}
+	return &Follower{
+		path:    path,
+		sep:     sep,
+		maxLine: maxLine,
+		fromEnd: fromEnd,
+		state:   state,
+	}
+}
+
+// OpenFollower 立即開啟 path 並只追蹤這個檔案：檔案改名後繼續讀同一個檔案，
+// 路徑上出現的新檔案由呼叫端依 FileKey 另外追蹤
+func OpenFollower(path string, state FollowState, fromEnd bool, sep []byte, maxLine int) (*Follower, error) {
+	fw := NewFollower(path, state, fromEnd, sep, maxLine)
+	fw.pinned = true
+	if err := fw.open(); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+// FileKey 檔案的識別（裝置:inode），改名後不變；無法取得時（Windows）為空字串
+func FileKey(fi os.FileInfo) string {
+	inode := fileID(fi)
+	if inode == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", fileDevice(fi), inode)
+}
+
+// Key 目前開啟的檔案的 FileKey
+func (fw *Follower) Key() string {
+	if fw.info == nil {
+		return ""
+	}
+	return FileKey(fw.info)
+}
+
+// Poll 讀取目前所有完整的行並逐一呼叫 fn（不含換行，fn 返回後 line 即失效）。
+// start 為該行在檔案中的起始位置；truncated 表示超過 maxLine 被截斷。
+func (fw *Follower) Poll(fn func(line []byte, start int64, truncated bool)) error {
+	if fw.file == nil {
+		if fw.pinned {
+			return os.ErrClosed
+		}
+		if err := fw.open(); err != nil {
+			return err
+		}
+	}
+
+	if err := fw.drain(fn); err != nil {
+		return err
+	}
+
+	if fw.pinned {
+		fi, err := fw.file.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() < fw.offset {
+			return fw.rewind(fn)
+		}
+		return nil
+	}
+
+	// 讀到結尾後檢查路徑是否已指向新檔案（輪替）或被截斷
+	fi, err := os.Stat(fw.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// 檔案已被移走，舊 fd 已讀完，等待新檔案出現
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case !os.SameFile(fi, fw.info):
+		// 舊檔在上面讀完之後、換檔之前可能又寫入了幾行，關閉前再讀一次
+		if err := fw.drain(fn); err != nil {
+			return err
+		}
+		fw.Close()
+		fw.state = FollowState{}
+		fw.fromEnd = false
+		if err := fw.open(); err != nil {
+			return err
+		}
+		return fw.drain(fn)
+	case fi.Size() < fw.offset:
+		return fw.rewind(fn)
+	}
+	return nil
+}
+
+// rewind 檔案被截斷，從頭重新讀取
+func (fw *Follower) rewind(fn func(line []byte, start int64, truncated bool)) error {
+	if _, err := fw.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	fw.offset = 0
+	fw.buf = fw.buf[:0]
+	fw.skipping = false
+	return fw.drain(fn)
+}
+
+// State 目前已處理的位置，可持久化後傳回 NewFollower
+func (fw *Follower) State() FollowState {
+	return FollowState{Inode: fw.state.Inode, Offset: fw.offset}
+}
+
+// Close 關閉目前開啟的檔案
+func (fw *Follower) Close() {
+	if fw.file != nil {
+		fw.file.Close()
+		fw.file = nil
+	}
+}
+
+func (fw *Follower) open() error {
+	f, err := os.Open(fw.path)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	inode := fileID(fi)
+	var offset int64
+	switch {
+	case fw.state != (FollowState{}) && fw.state.Inode == inode && fw.state.Offset <= fi.Size():
+		offset = fw.state.Offset
+	case fw.fromEnd:
+		offset = fi.Size()
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	fw.file = f
+	fw.info = fi
+	fw.offset = offset
+	fw.state = FollowState{Inode: inode, Offset: offset}
+	fw.buf = fw.buf[:0]
+	fw.skipping = false
+	return nil
+}
+
+// drain 讀到 EOF，切出完整的行
+func (fw *Follower) drain(fn func(line []byte, start int64, truncated bool)) error {
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := fw.file.Read(chunk)
+		if n > 0 {
+			fw.buf = append(fw.buf, chunk[:n]...)
+			fw.split(fn)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (fw *Follower) split(fn func(line []byte, start int64, truncated bool)) {
+	for {
+		i := fw.indexSep()
+		if i < 0 {
+			// 沒有換行且已超過長度限制：先輸出截斷的內容，其餘丟棄
+			if fw.maxLine > 0 && len(fw.buf) > fw.maxLine {
+				if !fw.skipping {
+					fn(fw.buf[:fw.maxLine], fw.offset, true)
+					fw.skipping = true
+				}
+				// 保持多位元組換行的對齊
+				n := len(fw.buf) - len(fw.buf)%len(fw.sep)
+				fw.offset += int64(n)
+				fw.buf = fw.buf[n:]
+			}
+			return
+		}
+
+		line := fw.buf[:i]
+		size := int64(i + len(fw.sep))
+		if fw.skipping {
+			fw.skipping = false
+		} else if fw.maxLine > 0 && len(line) > fw.maxLine {
+			fn(line[:fw.maxLine], fw.offset, true)
+		} else {
+			fn(line, fw.offset, false)
+		}
+		fw.offset += size
+		fw.buf = fw.buf[size:]
+	}
+}
+
+// indexSep 找下一個換行；多位元組換行必須對齊字元邊界
+func (fw *Follower) indexSep() int {
+	from := 0
+	for {
+		i := bytes.Index(fw.buf[from:], fw.sep)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		if i%len(fw.sep) == 0 {
+			return i
+		}
+		from = i + 1
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, l := range lines {
+		f.WriteString(l + "\n")
+	}
+}
+
+func poll(t *testing.T, fw *Follower) []string {
+	t.Helper()
+	var got []string
+	if err := fw.Poll(func(line []byte, _ int64, _ bool) { got = append(got, string(line)) }); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestFollowerRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "a", "b")
+
+	fw := NewFollower(path, FollowState{}, false, nil, 0)
+	defer fw.Close()
+	if got := poll(t, fw); strings.Join(got, ",") != "a,b" {
+		t.Fatalf("first poll = %v", got)
+	}
+
+	// 輪替前寫入舊檔的行與新檔的行都要讀到
+	appendLines(t, path, "c")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path+".1", "d")
+	appendLines(t, path, "e")
+	if got := poll(t, fw); strings.Join(got, ",") != "c,d,e" {
+		t.Fatalf("after rotation = %v, want c,d,e", got)
+	}
+	if st := fw.State(); st.Offset != 2 {
+		t.Errorf("offset = %d, want 2", st.Offset)
+	}
+}
+
+func TestFollowerTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "first line", "second line")
+
+	fw := NewFollower(path, FollowState{}, false, nil, 0)
+	defer fw.Close()
+	poll(t, fw)
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "x")
+	if got := poll(t, fw); strings.Join(got, ",") != "x" {
+		t.Fatalf("after truncate = %v, want x", got)
+	}
+}
+
+func TestFollowerResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "a", "b")
+
+	fw := NewFollower(path, FollowState{}, true, nil, 0)
+	if got := poll(t, fw); len(got) != 0 {
+		t.Fatalf("fromEnd poll = %v, want nothing", got)
+	}
+	appendLines(t, path, "c")
+	poll(t, fw)
+	state := fw.State()
+	fw.Close()
+
+	// 重啟後從儲存的位置繼續
+	appendLines(t, path, "d")
+	fw = NewFollower(path, state, true, nil, 0)
+	defer fw.Close()
+	if got := poll(t, fw); strings.Join(got, ",") != "d" {
+		t.Fatalf("resumed poll = %v, want d", got)
+	}
+}
+
+func TestFollowerMaxLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "0123456789", "ok")
+
+	fw := NewFollower(path, FollowState{}, false, nil, 4)
+	defer fw.Close()
+	var lines []string
+	var truncated []bool
+	fw.Poll(func(line []byte, _ int64, tr bool) {
+		lines = append(lines, string(line))
+		truncated = append(truncated, tr)
+	})
+	if strings.Join(lines, ",") != "0123,ok" || !truncated[0] || truncated[1] {
+		t.Fatalf("lines = %v truncated = %v", lines, truncated)
+	}
+}
@@ -22,8 +22,18 @@ type dailyLogger struct {
 var (
 	loggers  = make(map[string]*dailyLogger)
 	globalMu sync.Mutex
+
+	hooks   []func(category string, line []byte)
+	hooksMu sync.RWMutex
 )
 
+// AddWriteHook 每筆紀錄寫入後呼叫 fn（例如提供最新樣本給 /metrics），fn 不可保留 line
+func AddWriteHook(fn func(category string, line []byte)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, fn)
+}
+
 // GetLogger 依 category 取得 logger（例如 cpu、disk、ram）
 func GetLogger(dir, category string, retentionDays int) *dailyLogger {
 	key := filepath.Join(dir, category)
@@ -77,6 +87,12 @@ func (lg *dailyLogger) Write(data any) error {
 		return err
 	}
 
+	hooksMu.RLock()
+	for _, fn := range hooks {
+		fn(lg.category, b)
+	}
+	hooksMu.RUnlock()
+
 	// 一天只清一次舊檔
 	if time.Since(lg.lastCleanup) > 24*time.Hour {
 		lg.cleanup()
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PromSample Prometheus / OpenMetrics 文字格式中的單一樣本
+type PromSample struct {
+	Name      string            `json:"Name"`
+	Type      string            `json:"Type"` // counter / gauge / histogram / summary / untyped ...
+	Labels    map[string]string `json:"Labels"`
+	Value     float64           `json:"Value"`
+	Timestamp int64             `json:"Timestamp"` // 毫秒，未提供為 0
+}
+
+// 這些後綴屬於同一個 metric family（例如 http_requests_total 的 TYPE 寫在 http_requests）
+var promSuffixes = []string{"_bucket", "_count", "_sum", "_total", "_created", "_gcount", "_gsum", "_info"}
+
+// ParsePromText 解析 Prometheus text exposition 或 OpenMetrics 格式
+// JSON 無法表示 NaN / Inf，這類數值的樣本會被略過
+func ParsePromText(r io.Reader, openMetrics bool) ([]PromSample, error) {
+	types := make(map[string]string)
+	var out []PromSample
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			if fields[0] == "#" && len(fields) == 2 && fields[1] == "EOF" {
+				break
+			}
+			continue
+		}
+
+		s, err := parsePromLine(line, openMetrics)
+		if err != nil {
+			return out, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		s.Type = promType(types, s.Name)
+		out = append(out, s)
+	}
+	return out, sc.Err()
+}
+
+func promType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+	for _, suffix := range promSuffixes {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if t, ok := types[base]; ok {
+				return t
+			}
+		}
+	}
+	return "untyped"
+}
+
+// parsePromLine 解析 name{label="value",...} value [timestamp] [# exemplar]
+func parsePromLine(line string, openMetrics bool) (PromSample, error) {
+	s := PromSample{Labels: make(map[string]string)}
+
+	i := strings.IndexAny(line, "{ \t")
+	if i < 0 {
+		return s, fmt.Errorf("missing value")
+	}
+	s.Name = line[:i]
+	rest := line[i:]
+
+	if rest[0] == '{' {
+		n, err := parsePromLabels(rest, s.Labels)
+		if err != nil {
+			return s, err
+		}
+		rest = rest[n:]
+	}
+
+	// OpenMetrics exemplar 接在 # 之後
+	if j := strings.Index(rest, " # "); j >= 0 {
+		rest = rest[:j]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return s, fmt.Errorf("missing value")
+	}
+	v, err := parsePromFloat(fields[0])
+	if err != nil {
+		return s, err
+	}
+	s.Value = v
+
+	if len(fields) > 1 {
+		// Prometheus 為毫秒整數，OpenMetrics 為秒（可有小數）
+		if openMetrics {
+			ts, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return s, fmt.Errorf("invalid timestamp %q", fields[1])
+			}
+			s.Timestamp = int64(ts * 1000)
+		} else {
+			ts, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return s, fmt.Errorf("invalid timestamp %q", fields[1])
+			}
+			s.Timestamp = ts
+		}
+	}
+	return s, nil
+}
+
+// parsePromLabels 解析 {...}，回傳已消耗的長度
+func parsePromLabels(s string, labels map[string]string) (int, error) {
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated labels")
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return 0, fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return 0, fmt.Errorf("label %s: missing quote", name)
+		}
+		i++
+
+		var b strings.Builder
+		for {
+			if i >= len(s) {
+				return 0, fmt.Errorf("label %s: unterminated value", name)
+			}
+			c := s[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		}
+		labels[name] = b.String()
+	}
+}
+
+func parsePromFloat(s string) (float64, error) {
+	switch s {
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
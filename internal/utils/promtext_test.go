@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const promFixture = `# HELP http_requests_total Total requests.
+# TYPE http_requests counter
+http_requests_total{method="post",path="/api/v1/\"quoted\"",note="a\\b\nc"} 1027 1395066363000
+http_requests_total{method="get", path="/"} 3
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.05"} 24054
+http_request_duration_seconds_bucket{le="+Inf"} 144320
+http_request_duration_seconds_sum 53423
+http_request_duration_seconds_count 144320
+# TYPE process_start_time_seconds gauge
+process_start_time_seconds 1.7e+09
+go_gc_last NaN
+temperature{sensor="x"} +Inf
+free_form{} -2.5
+`
+
+func TestParsePromText(t *testing.T) {
+	samples, err := ParsePromText(strings.NewReader(promFixture), false)
+	if err != nil {
+		t.Fatalf("ParsePromText: %v", err)
+	}
+
+	// NaN / Inf 略過
+	want := []struct {
+		name, typ string
+		value     float64
+		ts        int64
+	}{
+		{"http_requests_total", "counter", 1027, 1395066363000},
+		{"http_requests_total", "counter", 3, 0},
+		{"http_request_duration_seconds_bucket", "histogram", 24054, 0},
+		{"http_request_duration_seconds_bucket", "histogram", 144320, 0},
+		{"http_request_duration_seconds_sum", "histogram", 53423, 0},
+		{"http_request_duration_seconds_count", "histogram", 144320, 0},
+		{"process_start_time_seconds", "gauge", 1.7e9, 0},
+		{"free_form", "untyped", -2.5, 0},
+	}
+	if len(samples) != len(want) {
+		t.Fatalf("got %d samples, want %d: %+v", len(samples), len(want), samples)
+	}
+	for i, w := range want {
+		s := samples[i]
+		if s.Name != w.name || s.Type != w.typ || s.Value != w.value || s.Timestamp != w.ts {
+			t.Errorf("sample %d = %s %s %v %d, want %s %s %v %d", i, s.Name, s.Type, s.Value, s.Timestamp, w.name, w.typ, w.value, w.ts)
+		}
+	}
+
+	labels := samples[0].Labels
+	if labels["method"] != "post" || labels["path"] != `/api/v1/"quoted"` || labels["note"] != "a\\b\nc" {
+		t.Errorf("escaped labels = %q", labels)
+	}
+	if samples[1].Labels["path"] != "/" {
+		t.Errorf("labels with spaces = %q", samples[1].Labels)
+	}
+	if samples[3].Labels["le"] != "+Inf" {
+		t.Errorf("bucket labels = %q", samples[3].Labels)
+	}
+}
+
+func TestParseOpenMetrics(t *testing.T) {
+	text := `# TYPE build info
+build_info{version="1.2.3"} 1
+# TYPE requests counter
+requests_total 17 1700000000.25 # {trace_id="abc"} 1 1700000000.1
+requests_created 1700000000
+# EOF
+after_eof 1
+`
+	samples, err := ParsePromText(strings.NewReader(text), true)
+	if err != nil {
+		t.Fatalf("ParsePromText: %v", err)
+	}
+	// # EOF 之後的內容不解析
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, want 3: %+v", len(samples), samples)
+	}
+	if s := samples[0]; s.Name != "build_info" || s.Type != "info" || s.Labels["version"] != "1.2.3" {
+		t.Errorf("info sample = %+v", s)
+	}
+	// 時間戳記為秒，轉為毫秒；exemplar 不影響數值
+	if s := samples[1]; s.Type != "counter" || s.Value != 17 || s.Timestamp != 1700000000250 {
+		t.Errorf("counter sample = %+v", s)
+	}
+	if s := samples[2]; s.Name != "requests_created" || s.Type != "counter" {
+		t.Errorf("created sample = %+v", s)
+	}
+}
+
+func TestParsePromLineErrors(t *testing.T) {
+	for _, tt := range []struct {
+		line        string
+		openMetrics bool
+	}{
+		{"no_value", false},
+		{"no_value{a=\"b\"}", false},
+		{`bad_label{a=b} 1`, false},
+		{`unterminated{a="b} 1`, false},
+		{"bad_value abc", false},
+		{"float_ts 1 1.5", false},
+		{"bad_ts 1 soon", true},
+	} {
+		if s, err := parsePromLine(tt.line, tt.openMetrics); err == nil {
+			t.Errorf("parsePromLine(%q) = %+v, want error", tt.line, s)
+		}
+	}
+
+	if _, err := ParsePromText(strings.NewReader("ok 1\nbroken\n"), false); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want line number", err)
+	}
+}